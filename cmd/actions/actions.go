@@ -0,0 +1,23 @@
+package actions
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/cmd/actions/lock"
+	"github.com/aweris/ghx/cmd/actions/ls"
+	"github.com/aweris/ghx/cmd/actions/prune"
+)
+
+// NewCommand  creates a new root command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "actions",
+		Short: "Manages actions used by the configured steps",
+	}
+
+	cmd.AddCommand(lock.NewCommand())
+	cmd.AddCommand(ls.NewCommand())
+	cmd.AddCommand(prune.NewCommand())
+
+	return cmd
+}
@@ -0,0 +1,110 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+
+	"dagger.io/dagger"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/pkg/cache"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// NewCommand  creates a new root command.
+func NewCommand() *cobra.Command {
+	var (
+		opts         statepkg.ActionOptions
+		lockfilePath string
+		cacheDir     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Writes the lockfile for actions used by the configured steps",
+		Long:  "Resolves actions used by the configured steps and pins them to resolved commit SHAs and content hashes of the action trees.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// read token from environment instead of flag default to avoid printing it in the help message
+			if opts.Source.Token == "" {
+				opts.Source.Token = os.Getenv("GITHUB_TOKEN")
+			}
+
+			var clientOpts []dagger.ClientOpt
+
+			if os.Getenv("RUNNER_DEBUG") == "1" {
+				clientOpts = append(clientOpts, dagger.WithLogOutput(os.Stdout))
+			}
+
+			client, err := dagger.Connect(cmd.Context(), clientOpts...)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			actionCache, err := cache.Open(cacheDir, cache.DefaultMaxSize)
+			if err != nil {
+				return err
+			}
+			defer actionCache.Close()
+
+			opts.Cache = actionCache
+
+			state, err := statepkg.GetState()
+			if err != nil {
+				return err
+			}
+
+			opts.Source.Replacements = state.Replacements
+
+			// start from an empty lockfile, so actions no longer used by the steps are dropped
+			opts.Lockfile = statepkg.NewLockfile(lockfilePath)
+			opts.Update = true
+
+			var sources []string
+
+			for _, stepID := range state.GetStepOrder() {
+				ss, _ := state.GetStepState(stepID)
+
+				if ss.Step.Type() != model.StepTypeAction {
+					continue
+				}
+
+				sources = append(sources, ss.Step.Uses)
+			}
+
+			// actions used by composite actions are verified against the lockfile as well, so lock them too
+			actions, err := statepkg.LoadActionTree(sources, func(source string) (*statepkg.ActionState, error) {
+				return statepkg.LoadAction(cmd.Context(), client, source, opts)
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, as := range actions {
+				if as.Replacement != "" {
+					fmt.Printf("Skip action '%s' replaced by '%s'\n", as.Source, as.Replacement)
+					continue
+				}
+
+				if as.Commit == "" {
+					fmt.Printf("Skip local action '%s'\n", as.Source)
+					continue
+				}
+
+				fmt.Printf("Lock action '%s' (SHA:%s)\n", as.Source, as.Commit)
+			}
+
+			return opts.Lockfile.Save()
+		},
+	}
+
+	cmd.Flags().StringVar(&lockfilePath, "lockfile", statepkg.DefaultLockfile, "Path of the lockfile to write")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "Directory of the action cache shared across runs. Can be set with $GHX_CACHE_DIR")
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "Use only cached actions without accessing the network")
+	cmd.Flags().StringVar(&opts.Source.ServerURL, "server-url", os.Getenv("GITHUB_SERVER_URL"), "URL of the GitHub server to resolve actions from. Enterprise servers fallback to github.com")
+	cmd.Flags().StringVar(&opts.Source.Token, "action-token", "", "Token to access private action repositories on the server. Defaults to $GITHUB_TOKEN")
+
+	return cmd
+}
@@ -0,0 +1,76 @@
+package ls
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/pkg/cache"
+)
+
+// NewCommand  creates a new ls command.
+func NewCommand() *cobra.Command {
+	var cacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "Lists actions in the cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := cache.Open(cacheDir, 0)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+			fmt.Fprintln(w, "HOST\tREPOSITORY\tPATH\tCOMMIT\tREFS\tSIZE\tLAST USED")
+
+			for _, entry := range c.Entries() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					entry.Host,
+					entry.Repo,
+					entry.Path,
+					entry.Commit,
+					strings.Join(entry.Refs, ","),
+					formatSize(entry.Size),
+					entry.LastUsed.Format(time.RFC3339),
+				)
+			}
+
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Printf("\nTotal size: %s\n", formatSize(c.Size()))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "Directory of the action cache. Can be set with $GHX_CACHE_DIR")
+
+	return cmd
+}
+
+// formatSize formats the size in bytes in human-readable binary units.
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
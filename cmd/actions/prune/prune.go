@@ -0,0 +1,43 @@
+package prune
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/pkg/cache"
+)
+
+// NewCommand  creates a new prune command.
+func NewCommand() *cobra.Command {
+	var (
+		cacheDir string
+		maxSize  int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Removes actions from the cache",
+		Long:  "Removes least recently used actions from the cache until the cache size is less than or equal to --max-size. By default, all actions are removed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := cache.Open(cacheDir, 0)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			evicted, err := c.Prune(maxSize)
+
+			for _, entry := range evicted {
+				fmt.Printf("Removed %s/%s/%s (SHA:%s)\n", entry.Host, entry.Repo, entry.Path, entry.Commit)
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "Directory of the action cache. Can be set with $GHX_CACHE_DIR")
+	cmd.Flags().Int64Var(&maxSize, "max-size", 0, "Size limit of the cache in bytes to prune to")
+
+	return cmd
+}
@@ -0,0 +1,40 @@
+package annotations
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/pkg/annotations"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// NewCommand  creates a new annotations command.
+func NewCommand() *cobra.Command {
+	// Flags for the annotations command
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "annotations",
+		Short: "Print annotations created by the steps",
+		Long:  "Prints error, warning and notice annotations created by the steps of the job grouped by file.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := statepkg.GetState()
+			if err != nil {
+				return err
+			}
+
+			if err := annotations.Write(os.Stdout, state.Annotations, annotations.Format(format)); err != nil {
+				return fmt.Errorf("failed to write annotations: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	// Define flags for the annotations command
+	cmd.Flags().StringVar(&format, "format", string(annotations.FormatText), "Output format of the annotations. One of: text, json, sarif, checks")
+
+	return cmd
+}
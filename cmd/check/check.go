@@ -0,0 +1,136 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"dagger.io/dagger"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aweris/ghx/pkg/annotations"
+	"github.com/aweris/ghx/pkg/cache"
+	checkpkg "github.com/aweris/ghx/pkg/check"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// NewCommand  creates a new check command.
+func NewCommand() *cobra.Command {
+	var (
+		opts        statepkg.ActionOptions
+		cacheDir    string
+		format      string
+		skipActions bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Type checks the expressions of the configured steps before running them",
+		Long:  "Type checks the expressions of the configured steps against the contexts available to them, like ids of the previous steps, matrix variables and inputs and outputs declared by the actions.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// read token from environment instead of flag default to avoid printing it in the help message
+			if opts.Source.Token == "" {
+				opts.Source.Token = os.Getenv("GITHUB_TOKEN")
+			}
+
+			state, err := statepkg.GetState()
+			if err != nil {
+				return err
+			}
+
+			checkOpts := checkpkg.Options{
+				WorkflowPath: state.WorkflowPath,
+				Job:          state.Job,
+				Actions:      make(map[string]*model.Action),
+			}
+
+			for _, stepID := range state.GetStepOrder() {
+				ss, _ := state.GetStepState(stepID)
+
+				checkOpts.Steps = append(checkOpts.Steps, ss.Step)
+			}
+
+			if !skipActions {
+				if err := loadActions(cmd.Context(), state, checkOpts, opts, cacheDir); err != nil {
+					return err
+				}
+			}
+
+			result := checkpkg.Job(checkOpts)
+
+			if err := annotations.Write(os.Stdout, result, annotations.Format(format)); err != nil {
+				return fmt.Errorf("failed to write annotations: %w", err)
+			}
+
+			for _, annotation := range result {
+				if annotation.Level == model.AnnotationLevelError {
+					return fmt.Errorf("check found problems in the steps")
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", string(annotations.FormatText), "Output format of the problems found. One of: text, json, sarif, checks")
+	cmd.Flags().BoolVar(&skipActions, "skip-actions", false, "Skip loading actions. Inputs and outputs of the actions are not checked")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "Directory of the action cache shared across runs. Can be set with $GHX_CACHE_DIR")
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "Use only cached actions without accessing the network")
+	cmd.Flags().StringVar(&opts.Source.ServerURL, "server-url", os.Getenv("GITHUB_SERVER_URL"), "URL of the GitHub server to resolve actions from. Enterprise servers fallback to github.com")
+	cmd.Flags().StringVar(&opts.Source.Token, "action-token", "", "Token to access private action repositories on the server. Defaults to $GITHUB_TOKEN")
+
+	return cmd
+}
+
+// loadActions loads the metadata of the actions used by the steps into the check options. Actions already loaded to
+// the state are reused. Actions that can't be loaded, e.g. local actions before checkout, are skipped.
+func loadActions(ctx context.Context, state *statepkg.State, checkOpts checkpkg.Options, opts statepkg.ActionOptions, cacheDir string) error {
+	var clientOpts []dagger.ClientOpt
+
+	if os.Getenv("RUNNER_DEBUG") == "1" {
+		clientOpts = append(clientOpts, dagger.WithLogOutput(os.Stdout))
+	}
+
+	client, err := dagger.Connect(ctx, clientOpts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	actionCache, err := cache.Open(cacheDir, cache.DefaultMaxSize)
+	if err != nil {
+		return err
+	}
+	defer actionCache.Close()
+
+	opts.Cache = actionCache
+	opts.Source.Replacements = state.Replacements
+
+	for _, step := range checkOpts.Steps {
+		if step.Type() != model.StepTypeAction {
+			continue
+		}
+
+		if _, ok := checkOpts.Actions[step.Uses]; ok {
+			continue
+		}
+
+		if as, ok := state.GetActionState(step.Uses); ok && as.Metadata != nil {
+			checkOpts.Actions[step.Uses] = as.Metadata
+			continue
+		}
+
+		as, err := statepkg.LoadAction(ctx, client, step.Uses, opts)
+		if err != nil {
+			// stdout is reserved for the report, so the selected format stays parseable
+			fmt.Fprintf(os.Stderr, "Skip checking action '%s': %v\n", step.Uses, err)
+			continue
+		}
+
+		checkOpts.Actions[step.Uses] = as.Metadata
+	}
+
+	return nil
+}
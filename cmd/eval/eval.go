@@ -0,0 +1,46 @@
+package eval
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	evalpkg "github.com/aweris/ghx/pkg/eval"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// NewCommand  creates a new eval command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eval [expression]",
+		Short: "Evaluates expressions against the context of the configured steps",
+		Long: "Evaluates the expression against the current context of the configured steps and prints the result as JSON. " +
+			"Secrets are masked in the results. Starts an interactive session completing context paths with tab if no " +
+			"expression is given.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := statepkg.GetState()
+			if err != nil {
+				return err
+			}
+
+			evaluator := evalpkg.New(state.GetActionsContext())
+
+			if len(args) == 0 {
+				return evaluator.Run(os.Stdin, os.Stdout)
+			}
+
+			result, err := evaluator.Eval(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(result)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -6,6 +6,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/aweris/ghx/cmd/actions"
+	"github.com/aweris/ghx/cmd/annotations"
+	"github.com/aweris/ghx/cmd/check"
+	"github.com/aweris/ghx/cmd/eval"
 	"github.com/aweris/ghx/cmd/run"
 	"github.com/aweris/ghx/cmd/version"
 	"github.com/aweris/ghx/cmd/with"
@@ -26,6 +30,10 @@ func Execute() {
 
 	rootCmd.AddCommand(with.NewCommand())
 	rootCmd.AddCommand(run.NewCommand())
+	rootCmd.AddCommand(check.NewCommand())
+	rootCmd.AddCommand(eval.NewCommand())
+	rootCmd.AddCommand(actions.NewCommand())
+	rootCmd.AddCommand(annotations.NewCommand())
 	rootCmd.AddCommand(version.NewCommand())
 
 	if err := rootCmd.Execute(); err != nil {
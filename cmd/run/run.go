@@ -2,13 +2,18 @@ package run
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"dagger.io/dagger"
 
 	"github.com/spf13/cobra"
 
+	"github.com/aweris/ghx/pkg/cache"
 	"github.com/aweris/ghx/pkg/config"
 	runnerpkg "github.com/aweris/ghx/pkg/runner"
 	statepkg "github.com/aweris/ghx/pkg/state"
@@ -16,11 +21,22 @@ import (
 
 // NewCommand  creates a new root command.
 func NewCommand() *cobra.Command {
+	var (
+		opts         runnerpkg.Options
+		lockfilePath string
+		cacheOpts    cacheOptions
+	)
+
 	cmd := &cobra.Command{
 		Use:   "run",
 		Short: "Runs all configured steps",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := run(cmd.Context())
+			// read token from environment instead of flag default to avoid printing it in the help message
+			if opts.Actions.Source.Token == "" {
+				opts.Actions.Source.Token = os.Getenv("GITHUB_TOKEN")
+			}
+
+			err := run(cmd.Context(), opts, lockfilePath, cacheOpts)
 
 			// dagger and buildkit doesn't allow running commands if container is failed.
 			//
@@ -41,10 +57,55 @@ func NewCommand() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.Actions.Source.ServerURL, "server-url", os.Getenv("GITHUB_SERVER_URL"), "URL of the GitHub server to resolve actions from. Enterprise servers fallback to github.com")
+	cmd.Flags().StringVar(&opts.Actions.Source.Token, "action-token", "", "Token to access private action repositories on the server. Defaults to $GITHUB_TOKEN")
+	cmd.Flags().StringVar(&lockfilePath, "lockfile", statepkg.DefaultLockfile, "Path of the lockfile to verify actions against. Verification is skipped if the file doesn't exist")
+	cmd.Flags().BoolVar(&opts.Actions.Update, "update", false, "Update the lockfile with the resolved actions instead of failing on drift")
+	cmd.Flags().StringVar(&cacheOpts.dir, "cache-dir", cache.DefaultDir(), "Directory of the action cache shared across runs. Can be set with $GHX_CACHE_DIR")
+	cmd.Flags().Int64Var(&cacheOpts.maxSize, "cache-max-size", cache.DefaultMaxSize, "Size limit of the action cache in bytes. Least recently used actions are evicted first")
+	cmd.Flags().BoolVar(&cacheOpts.disabled, "no-cache", false, "Download actions on every run without using the action cache")
+	cmd.Flags().BoolVar(&opts.Actions.Offline, "offline", false, "Use only cached actions without accessing the network")
+	cmd.Flags().StringVar(&opts.ExternalsDir, "externals-dir", os.Getenv("GHX_EXTERNALS_DIR"), "Directory containing node runtimes in <dir>/<runtime>/bin/node layout. Missing runtimes are fetched with dagger")
+
 	return cmd
 }
 
-func run(ctx context.Context) error {
+// cacheOptions represents the flags to configure the action cache.
+type cacheOptions struct {
+	dir      string // directory of the action cache
+	maxSize  int64  // size limit of the action cache in bytes
+	disabled bool   // disables the action cache
+}
+
+func run(ctx context.Context, runnerOpts runnerpkg.Options, lockfilePath string, cacheOpts cacheOptions) error {
+	if cacheOpts.disabled && runnerOpts.Actions.Offline {
+		return fmt.Errorf("--offline requires the action cache, it can't be used with --no-cache")
+	}
+
+	if !cacheOpts.disabled {
+		actionCache, err := cache.Open(cacheOpts.dir, cacheOpts.maxSize)
+		if err != nil {
+			return err
+		}
+		defer actionCache.Close()
+
+		runnerOpts.Actions.Cache = actionCache
+	}
+
+	lockfile, err := statepkg.LoadLockfile(lockfilePath)
+
+	switch {
+	case err == nil:
+		runnerOpts.Actions.Lockfile = lockfile
+	case errors.Is(err, fs.ErrNotExist):
+		// create the lockfile on update, otherwise run without verification
+		if runnerOpts.Actions.Update {
+			runnerOpts.Actions.Lockfile = statepkg.NewLockfile(lockfilePath)
+		}
+	default:
+		return err
+	}
+
 	var opts []dagger.ClientOpt
 
 	if os.Getenv("RUNNER_DEBUG") == "1" {
@@ -62,10 +123,20 @@ func run(ctx context.Context) error {
 		return err
 	}
 	defer state.Close()
-	runner, err := runnerpkg.New(client, state)
+	runner, err := runnerpkg.New(client, state, runnerOpts)
 	if err != nil {
 		return err
 	}
 
-	return runner.Execute(ctx)
+	// the first interrupt cancels the job and the remaining steps checking the status still run, e.g. with always().
+	// dagger is connected with the parent context, so it's not closed on cancel. A second interrupt terminates ghx.
+	jobCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-jobCtx.Done()
+		stop()
+	}()
+
+	return runner.Execute(jobCtx)
 }
@@ -0,0 +1,74 @@
+package replace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// NewCommand  creates a new replace command.
+func NewCommand() *cobra.Command {
+	// Flags for the replace command
+	var drop bool
+
+	cmd := &cobra.Command{
+		Use:   "replace <source> [=>] <replacement>",
+		Short: "Replace an action with a local path or another action",
+		Long: `Loads the replacement instead of the action source, similar to replace directives of go.mod.
+
+Source without ref, e.g. actions/checkout, replaces all refs of the action. Replacement can be a local path starting
+with ./ or a remote action with ref, e.g.
+
+  ghx with replace actions/checkout@v3 ./local/checkout
+  ghx with replace "actions/checkout => myfork/checkout@fix"
+  ghx with replace --drop actions/checkout`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := statepkg.GetState()
+			if err != nil {
+				return err
+			}
+			defer state.Close()
+
+			if drop {
+				if len(args) != 1 {
+					return fmt.Errorf("only action source is accepted with --drop")
+				}
+
+				state.RemoveReplacement(args[0])
+
+				return nil
+			}
+
+			source, replacement, err := parseReplaceArgs(args)
+			if err != nil {
+				return err
+			}
+
+			return state.AddReplacement(source, replacement)
+		},
+	}
+
+	// Define flags for the replace command
+	cmd.Flags().BoolVar(&drop, "drop", false, "Remove the replacement of the action source")
+
+	return cmd
+}
+
+// parseReplaceArgs parses the source and replacement from `<source> <replacement>`, `<source> => <replacement>` or
+// `"<source> => <replacement>"` arguments.
+func parseReplaceArgs(args []string) (string, string, error) {
+	parts := strings.Fields(strings.Join(args, " "))
+
+	switch {
+	case len(parts) == 3 && parts[1] == "=>":
+		return parts[0], parts[2], nil
+	case len(parts) == 2 && parts[0] != "=>" && parts[1] != "=>":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("invalid replace %q, expected <source> => <replacement>", strings.Join(args, " "))
+	}
+}
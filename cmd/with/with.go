@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aweris/ghx/cmd/with/job"
+	"github.com/aweris/ghx/cmd/with/replace"
 	"github.com/aweris/ghx/cmd/with/step"
 )
 
@@ -16,6 +17,7 @@ func NewCommand() *cobra.Command {
 
 	cmd.AddCommand(step.NewCommand())
 	cmd.AddCommand(job.NewCommand())
+	cmd.AddCommand(replace.NewCommand())
 
 	return cmd
 }
@@ -6,6 +6,8 @@ require (
 	dagger.io/dagger v0.7.2
 	github.com/rhysd/actionlint v1.6.24
 	github.com/spf13/cobra v1.7.0
+	golang.org/x/sys v0.8.0
+	golang.org/x/term v0.8.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -25,6 +27,5 @@ require (
 	github.com/vektah/gqlparser/v2 v2.5.1 // indirect
 	golang.org/x/mod v0.10.0 // indirect
 	golang.org/x/sync v0.2.0 // indirect
-	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/tools v0.9.3 // indirect
 )
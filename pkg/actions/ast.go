@@ -1,8 +1,9 @@
 package actions
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"math"
 
 	"github.com/aweris/ghx/pkg/expression"
 )
@@ -14,6 +15,8 @@ import (
 type String struct {
 	Value  string // Value is a raw value of the string.
 	Quoted bool   // Quoted represents the string is quoted with ' or " in the YAML source.
+	Line   int    // Line is the line of the string in the YAML source. 0 if the position is unknown.
+	Column int    // Column is the column of the string in the YAML source. 0 if the position is unknown.
 }
 
 // NewString creates a new String instance.
@@ -24,35 +27,32 @@ func NewString(value string) *String {
 	}
 }
 
-// Eval evaluates the expression and returns the string value.
+// Eval evaluates the expressions in the string and returns the string with the expressions replaced by their values
+// converted to strings. Expression errors are returned as *expression.Error with the position of the string in the
+// YAML source.
 func (s *String) Eval(ctx *Context) (string, error) {
 	if s.Quoted {
 		return s.Value, nil
 	}
 
-	exprs, err := expression.ParseExpressions(s.Value)
+	str, err := expression.Interpolate(s.Value, ctx)
 	if err != nil {
-		return "", err
+		return "", s.withPosition(err)
 	}
 
-	if len(exprs) == 0 {
-		return s.Value, nil
-	}
-
-	str := s.Value
+	return str, nil
+}
 
-	for _, expr := range exprs {
-		val, err := expr.Evaluate(ctx)
-		if err != nil {
-			return "", err
-		}
+// withPosition sets the position of the string to the expression error if the error doesn't have a position yet.
+func (s *String) withPosition(err error) error {
+	var exprErr *expression.Error
 
-		if v, ok := val.(string); ok {
-			str = strings.Replace(str, expr.Value, v, 1)
-		}
+	if errors.As(err, &exprErr) && exprErr.Line == 0 {
+		exprErr.Line = s.Line
+		exprErr.Col = s.Column
 	}
 
-	return str, nil
+	return err
 }
 
 // value represents generic value with Github Actions expression support.
@@ -68,18 +68,30 @@ func (v *value[T]) Eval(ctx *Context) (T, error) {
 
 	expr, err := expression.NewExpression(v.Expression.Value)
 	if err != nil {
-		return *new(T), err
+		return *new(T), v.Expression.withPosition(err)
 	}
 
 	val, err := expr.Evaluate(ctx)
 	if err != nil {
-		return *new(T), err
+		return *new(T), v.Expression.withPosition(err)
 	}
 
 	if v, ok := val.(T); ok {
 		return v, nil
 	}
 
+	// booleans are truthiness checks like conditions, so any value converts to bool, e.g. a non-empty string is true
+	if _, ok := any(*new(T)).(bool); ok {
+		return any(expression.IsTruthy(val)).(T), nil
+	}
+
+	// numbers are float64 in expressions, convert them to int if they don't have a fractional part
+	if f, ok := val.(float64); ok && f == math.Trunc(f) {
+		if v, ok := any(int(f)).(T); ok {
+			return v, nil
+		}
+	}
+
 	return *new(T), fmt.Errorf("cannot convert %v to %T", val, *new(T))
 }
 
@@ -1,12 +1,14 @@
 package actions_test
 
 import (
+	"errors"
 	"math"
 	"testing"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/expression"
 )
 
 func TestString_Eval(t *testing.T) {
@@ -14,6 +16,7 @@ func TestString_Eval(t *testing.T) {
 		Github: &actions.GithubContext{
 			Token: "1234567890",
 		},
+		Env: map[string]string{"TEMPLATE": "${{ github.token }}"},
 	}
 
 	tests := []struct {
@@ -26,6 +29,10 @@ func TestString_Eval(t *testing.T) {
 		{"single expression", "${{ github.token }}", "1234567890"},
 		{"inline expression", "foobar-${{ github.token }}-baz", "foobar-1234567890-baz"},
 		{"multiple expressions", "foobar-${{ github.token }}-${{ github.token }}-baz", "foobar-1234567890-1234567890-baz"},
+		{"mixed types", "${{ fromJSON('1.50') }}-${{ true }}-${{ null }}-${{ github }}", "1.5-true--Object"},
+		{"replaced by position", "${{ env.TEMPLATE }} ${{ github.token }}", "${{ github.token }} 1234567890"},
+		{"braces in string literal", "${{ format('{{{0}}}', github.token) }}", "{1234567890}"},
+		{"closing braces in string literal", "${{ '}}' }}-${{ github.token }}", "}}-1234567890"},
 	}
 
 	for _, tt := range tests {
@@ -49,6 +56,32 @@ func TestString_Eval(t *testing.T) {
 	}
 }
 
+func TestString_EvalError(t *testing.T) {
+	var doc struct {
+		Value actions.String `yaml:"value"`
+	}
+
+	if err := yaml.Unmarshal([]byte("\nvalue: echo ${{ foo( }}"), &doc); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	_, err := doc.Value.Eval(&actions.Context{})
+
+	var exprErr *expression.Error
+
+	if !errors.As(err, &exprErr) {
+		t.Fatalf("Expected expression error, but got %v", err)
+	}
+
+	if exprErr.Line != 2 || exprErr.Col != 8 {
+		t.Errorf("Expected position 2:8, but got %d:%d", exprErr.Line, exprErr.Col)
+	}
+
+	if exprErr.Input != "echo ${{ foo( }}" {
+		t.Errorf("Expected input %q, but got %q", "echo ${{ foo( }}", exprErr.Input)
+	}
+}
+
 func TestBool_Eval(t *testing.T) {
 	ctx := actions.Context{
 		Github: &actions.GithubContext{
@@ -102,6 +135,7 @@ func TestInt_Eval(t *testing.T) {
 	}{
 		{"raw integer value", "123", 123},
 		{"expression with integer value", "${{ 123 }}", 123},
+		{"expression with json number", "${{ fromJSON('5') }}", 5},
 	}
 
 	for _, tt := range tests {
@@ -38,6 +38,8 @@ func (s *String) UnmarshalYAML(n *yaml.Node) error {
 
 	s.Value = n.Value
 	s.Quoted = isQuotedString(n)
+	s.Line = n.Line
+	s.Column = n.Column
 
 	return nil
 }
@@ -69,7 +71,7 @@ func (b *Bool) UnmarshalYAML(n *yaml.Node) error {
 
 		b.Value = val
 	case yamlStrTag:
-		b.Expression = &String{Value: n.Value, Quoted: isQuotedString(n)}
+		b.Expression = &String{Value: n.Value, Quoted: isQuotedString(n), Line: n.Line, Column: n.Column}
 	default:
 		return fmt.Errorf("expected !!bool or !!str tag but got %q", n.Tag)
 	}
@@ -104,7 +106,7 @@ func (i *Int) UnmarshalYAML(n *yaml.Node) error {
 
 		i.Value = int(val)
 	case yamlStrTag:
-		i.Expression = &String{Value: n.Value, Quoted: isQuotedString(n)}
+		i.Expression = &String{Value: n.Value, Quoted: isQuotedString(n), Line: n.Line, Column: n.Column}
 	default:
 		return fmt.Errorf("expected !!int or !!str tag but got %q", n.Tag)
 	}
@@ -139,7 +141,7 @@ func (f *Float) UnmarshalYAML(n *yaml.Node) error {
 
 		f.Value = val
 	case yamlStrTag:
-		f.Expression = &String{Value: n.Value, Quoted: isQuotedString(n)}
+		f.Expression = &String{Value: n.Value, Quoted: isQuotedString(n), Line: n.Line, Column: n.Column}
 	default:
 		return fmt.Errorf("expected !!float or !!str tag but got %q", n.Tag)
 	}
@@ -106,6 +106,16 @@ func TestUnmarshalYAML(t *testing.T) {
 			node:     &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "foobar"},
 			expected: NewString("foobar"),
 		},
+		{
+			name:     "string with position",
+			node:     &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "foobar", Line: 3, Column: 7},
+			expected: &String{Value: "foobar", Line: 3, Column: 7},
+		},
+		{
+			name:     "bool expression with position",
+			node:     &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "${{ example }}", Line: 3, Column: 7},
+			expected: &Bool{value: value[bool]{Expression: &String{Value: "${{ example }}", Line: 3, Column: 7}}},
+		},
 	}
 
 	for _, tt := range tests {
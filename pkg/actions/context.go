@@ -11,7 +11,7 @@ import (
 
 var _ expression.VariableProvider = new(Context)
 
-// TODO: add jobs and inputs context. Currently skipped because ghx not support re-usable workflows or workflow dispatch event
+// TODO: add jobs context. Currently skipped because ghx not support re-usable workflows
 
 type Context struct {
 	Github   *GithubContext               // Github context
@@ -24,6 +24,7 @@ type Context struct {
 	Strategy *StrategyContext             // Strategy context
 	Matrix   map[string]string            // Matrix context
 	Needs    map[string]string            // Needs context
+	Inputs   map[string]string            // Inputs context. Only available in composite actions
 }
 
 // NewContextFromEnv creates a new context from the environment variables
@@ -83,6 +84,7 @@ func NewContextFromEnv() *Context {
 		Strategy: &StrategyContext{},
 		Matrix:   make(map[string]string),
 		Needs:    make(map[string]string),
+		Inputs:   make(map[string]string),
 	}
 }
 
@@ -108,6 +110,8 @@ func (c *Context) GetVariable(name string) (interface{}, error) {
 		return c.Matrix, nil
 	case "needs":
 		return c.Needs, nil
+	case "inputs":
+		return c.Inputs, nil
 	case "infinity":
 		return math.Inf(1), nil
 	case "nan":
@@ -290,22 +294,20 @@ type GithubContext struct {
 }
 
 type JobContext struct {
-	Container JobContainer           // The container in which the job is running.
-	Services  map[string]JobServices // The services running in the job.
-	Status    string                 // The current status of the job. Possible values are queued, in_progress, or completed.
+	Container JobContainer           `json:"container"` // The container in which the job is running.
+	Services  map[string]JobServices `json:"services"`  // The services running in the job.
+	Status    string                 `json:"status"`    // The current status of the job. Possible values are success, failure, or cancelled.
 }
 
 type JobContainer struct {
-	ID      string // The ID of the container
-	Network string // The ID of the container network. The runner creates the network used by all containers in a job.
+	ID      string `json:"id"`      // The ID of the container
+	Network string `json:"network"` // The ID of the container network. The runner creates the network used by all containers in a job.
 }
 
-// TODO: add ports type and custom unmarshaler to handle the different types of ports config
-
 type JobServices struct {
-	ID      string      // The ID of the service container.
-	Network string      // The ID of the service container network. The runner creates the network used by all containers in a job.
-	Ports   interface{} // The exposed ports of the service container.
+	ID      string            `json:"id"`      // The ID of the service container.
+	Network string            `json:"network"` // The ID of the service container network. The runner creates the network used by all containers in a job.
+	Ports   map[string]string `json:"ports"`   // The exposed ports of the service container. Maps container ports to host ports.
 }
 
 // RunnerContext contains information about the runner that is executing the current job.
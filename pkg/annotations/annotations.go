@@ -0,0 +1,150 @@
+// Package annotations provides helpers to group and export annotations collected from workflow commands.
+package annotations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// Format represents the output format of the annotations.
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatSARIF  Format = "sarif"
+	FormatChecks Format = "checks"
+)
+
+// Group represents a list of annotations associated with the same file.
+type Group struct {
+	File        string              // File is the path of the file. Empty for annotations without file.
+	Annotations []*model.Annotation // Annotations is the list of annotations for the file ordered by position.
+}
+
+// GroupByFile groups the given annotations by file. Groups are sorted by file name and annotations without file are
+// placed at the end. Annotations in a group are ordered by their position in the file.
+func GroupByFile(annotations []*model.Annotation) []*Group {
+	index := make(map[string]*Group)
+
+	var groups []*Group
+
+	for _, annotation := range annotations {
+		group, ok := index[annotation.File]
+		if !ok {
+			group = &Group{File: annotation.File}
+			index[annotation.File] = group
+			groups = append(groups, group)
+		}
+
+		group.Annotations = append(group.Annotations, annotation)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		// annotations without file always goes to the end
+		if groups[i].File == "" || groups[j].File == "" {
+			return groups[j].File == "" && groups[i].File != ""
+		}
+
+		return groups[i].File < groups[j].File
+	})
+
+	for _, group := range groups {
+		sort.SliceStable(group.Annotations, func(i, j int) bool {
+			a, b := group.Annotations[i], group.Annotations[j]
+
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+
+			return a.Col < b.Col
+		})
+	}
+
+	return groups
+}
+
+// Write writes the annotations to the given writer in the given format.
+func Write(w io.Writer, annotations []*model.Annotation, format Format) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(w, annotations)
+	case FormatJSON:
+		// make sure empty list is written as an empty array instead of null
+		if annotations == nil {
+			annotations = []*model.Annotation{}
+		}
+
+		return writeJSON(w, annotations)
+	case FormatSARIF:
+		return writeJSON(w, ToSARIF(annotations))
+	case FormatChecks:
+		return writeJSON(w, ToChecksOutput(annotations))
+	default:
+		return fmt.Errorf("unsupported annotation format: %s", format)
+	}
+}
+
+// WriteText writes the annotations to the given writer as human-readable text grouped by file.
+func WriteText(w io.Writer, annotations []*model.Annotation) error {
+	for _, group := range GroupByFile(annotations) {
+		file := group.File
+		if file == "" {
+			file = "(no file)"
+		}
+
+		if _, err := fmt.Fprintln(w, file); err != nil {
+			return err
+		}
+
+		for _, annotation := range group.Annotations {
+			if _, err := fmt.Fprintf(w, "  %s\n", formatTextLine(annotation)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatTextLine formats a single annotation as a line of text e.g. `12:5 error [title] message (step: build)`.
+func formatTextLine(annotation *model.Annotation) string {
+	var parts []string
+
+	if annotation.Line > 0 {
+		position := fmt.Sprintf("%d", annotation.Line)
+
+		if annotation.Col > 0 {
+			position = fmt.Sprintf("%s:%d", position, annotation.Col)
+		}
+
+		parts = append(parts, position)
+	}
+
+	parts = append(parts, string(annotation.Level))
+
+	if annotation.Title != "" {
+		parts = append(parts, fmt.Sprintf("[%s]", annotation.Title))
+	}
+
+	parts = append(parts, annotation.Message)
+
+	if annotation.StepID != "" {
+		parts = append(parts, fmt.Sprintf("(step: %s)", annotation.StepID))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// writeJSON writes the given value to the writer as indented JSON.
+func writeJSON(w io.Writer, val interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(val)
+}
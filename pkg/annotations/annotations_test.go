@@ -0,0 +1,139 @@
+package annotations_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aweris/ghx/pkg/annotations"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+var testAnnotations = []*model.Annotation{
+	{Level: model.AnnotationLevelWarning, Message: "no file"},
+	{Level: model.AnnotationLevelError, File: "main.go", Line: 10, Col: 2, Message: "second", StepID: "build"},
+	{Level: model.AnnotationLevelNotice, File: "go.mod", Line: 1, Title: "Go", Message: "go version"},
+	{Level: model.AnnotationLevelError, File: "main.go", Line: 3, EndLine: 5, Col: 1, EndCol: 4, Message: "first"},
+}
+
+func TestGroupByFile(t *testing.T) {
+	groups := annotations.GroupByFile(testAnnotations)
+
+	expected := []struct {
+		file     string
+		messages []string
+	}{
+		{"go.mod", []string{"go version"}},
+		{"main.go", []string{"first", "second"}},
+		{"", []string{"no file"}},
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, but got %d", len(expected), len(groups))
+	}
+
+	for i, group := range groups {
+		if group.File != expected[i].file {
+			t.Errorf("Expected group %d to be %q, but got %q", i, expected[i].file, group.File)
+		}
+
+		if len(group.Annotations) != len(expected[i].messages) {
+			t.Fatalf("Expected %d annotations for %q, but got %d", len(expected[i].messages), group.File, len(group.Annotations))
+		}
+
+		for j, annotation := range group.Annotations {
+			if annotation.Message != expected[i].messages[j] {
+				t.Errorf("Expected annotation %d of %q to be %q, but got %q", j, group.File, expected[i].messages[j], annotation.Message)
+			}
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := annotations.WriteText(&buf, testAnnotations); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	expected := `go.mod
+  1 notice [Go] go version
+main.go
+  3:1 error first
+  10:2 error second (step: build)
+(no file)
+  warning no file
+`
+
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
+
+func TestToSARIF(t *testing.T) {
+	log := annotations.ToSARIF(testAnnotations)
+
+	if log.Version != "2.1.0" {
+		t.Errorf("Expected version 2.1.0, but got %s", log.Version)
+	}
+
+	if len(log.Runs) != 1 {
+		t.Fatalf("Expected 1 run, but got %d", len(log.Runs))
+	}
+
+	results := log.Runs[0].Results
+
+	if len(results) != len(testAnnotations) {
+		t.Fatalf("Expected %d results, but got %d", len(testAnnotations), len(results))
+	}
+
+	if results[0].Level != "warning" || results[0].Locations != nil {
+		t.Errorf("Expected warning without location, but got %s with %v", results[0].Level, results[0].Locations)
+	}
+
+	if results[2].Level != "note" {
+		t.Errorf("Expected note level for notice, but got %s", results[2].Level)
+	}
+
+	region := results[3].Locations[0].PhysicalLocation.Region
+	if region == nil || region.StartLine != 3 || region.EndLine != 5 || region.StartColumn != 1 || region.EndColumn != 4 {
+		t.Errorf("Expected region 3:1-5:4, but got %+v", region)
+	}
+}
+
+func TestToChecksOutput(t *testing.T) {
+	output := annotations.ToChecksOutput(testAnnotations)
+
+	if output.Summary != "2 error(s), 1 warning(s), 1 notice(s)" {
+		t.Errorf("Unexpected summary: %s", output.Summary)
+	}
+
+	tests := []struct {
+		name     string
+		actual   *annotations.ChecksAnnotation
+		expected annotations.ChecksAnnotation
+	}{
+		{
+			name:     "without file",
+			actual:   output.Annotations[0],
+			expected: annotations.ChecksAnnotation{Path: ".github", StartLine: 1, EndLine: 1, AnnotationLevel: "warning", Message: "no file"},
+		},
+		{
+			name:     "single line",
+			actual:   output.Annotations[1],
+			expected: annotations.ChecksAnnotation{Path: "main.go", StartLine: 10, EndLine: 10, StartColumn: 2, AnnotationLevel: "failure", Message: "second"},
+		},
+		{
+			name:     "multi line drops columns",
+			actual:   output.Annotations[3],
+			expected: annotations.ChecksAnnotation{Path: "main.go", StartLine: 3, EndLine: 5, AnnotationLevel: "failure", Message: "first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if *tt.actual != tt.expected {
+				t.Errorf("Expected %+v, but got %+v", tt.expected, *tt.actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package annotations
+
+import (
+	"fmt"
+
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// checksDefaultPath is the path GitHub uses for annotations that are not associated with any file.
+const checksDefaultPath = ".github"
+
+// ChecksOutput represents the `output` object of a check run in GitHub Checks API.
+//
+// See more: https://docs.github.com/en/rest/checks/runs#create-a-check-run
+type ChecksOutput struct {
+	Title       string              `json:"title"`
+	Summary     string              `json:"summary"`
+	Annotations []*ChecksAnnotation `json:"annotations"`
+}
+
+// ChecksAnnotation represents a single annotation in GitHub Checks API.
+type ChecksAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	StartColumn     int    `json:"start_column,omitempty"`
+	EndColumn       int    `json:"end_column,omitempty"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+	Title           string `json:"title,omitempty"`
+}
+
+// ToChecksOutput converts the given annotations to GitHub Checks API check run output.
+func ToChecksOutput(annotations []*model.Annotation) *ChecksOutput {
+	var errors, warnings, notices int
+
+	result := make([]*ChecksAnnotation, 0, len(annotations))
+
+	for _, annotation := range annotations {
+		switch annotation.Level {
+		case model.AnnotationLevelError:
+			errors++
+		case model.AnnotationLevelWarning:
+			warnings++
+		default:
+			notices++
+		}
+
+		ca := &ChecksAnnotation{
+			Path:            annotation.File,
+			StartLine:       annotation.Line,
+			EndLine:         annotation.EndLine,
+			AnnotationLevel: checksLevel(annotation.Level),
+			Message:         annotation.Message,
+			Title:           annotation.Title,
+		}
+
+		if ca.Path == "" {
+			ca.Path = checksDefaultPath
+		}
+
+		// start_line and end_line are required by the API.
+		if ca.StartLine < 1 {
+			ca.StartLine = 1
+		}
+
+		if ca.EndLine < ca.StartLine {
+			ca.EndLine = ca.StartLine
+		}
+
+		// columns are only allowed when the annotation is on a single line.
+		if ca.StartLine == ca.EndLine {
+			ca.StartColumn = annotation.Col
+			ca.EndColumn = annotation.EndCol
+		}
+
+		result = append(result, ca)
+	}
+
+	return &ChecksOutput{
+		Title:       "ghx",
+		Summary:     fmt.Sprintf("%d error(s), %d warning(s), %d notice(s)", errors, warnings, notices),
+		Annotations: result,
+	}
+}
+
+// checksLevel converts the annotation level to GitHub Checks API annotation level.
+func checksLevel(level model.AnnotationLevel) string {
+	switch level {
+	case model.AnnotationLevelError:
+		return "failure"
+	case model.AnnotationLevelWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
@@ -0,0 +1,143 @@
+package annotations
+
+import (
+	"github.com/aweris/ghx/internal/version"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFLog represents the root object of a SARIF 2.1.0 log file. Only the subset of the specification required to
+// report annotations is modeled.
+//
+// See more: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type SARIFLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SARIFRun `json:"runs"`
+}
+
+// SARIFRun represents a single run of an analysis tool.
+type SARIFRun struct {
+	Tool    SARIFTool      `json:"tool"`
+	Results []*SARIFResult `json:"results"`
+}
+
+// SARIFTool represents the analysis tool that produced the results.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver represents the component of the tool that produced the results.
+type SARIFDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+}
+
+// SARIFResult represents a single result produced by the tool.
+type SARIFResult struct {
+	RuleID     string            `json:"ruleId,omitempty"`
+	Level      string            `json:"level"`
+	Message    SARIFMessage      `json:"message"`
+	Locations  []*SARIFLocation  `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// SARIFMessage represents a message of the result.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFLocation represents a location of the result.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation represents a physical location of the result in a file.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation represents the location of the file.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion represents the region of the file the result is associated with.
+type SARIFRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// ToSARIF converts the given annotations to a SARIF 2.1.0 log with a single run.
+func ToSARIF(annotations []*model.Annotation) *SARIFLog {
+	results := make([]*SARIFResult, 0, len(annotations))
+
+	for _, annotation := range annotations {
+		result := &SARIFResult{
+			RuleID:  annotation.Title,
+			Level:   sarifLevel(annotation.Level),
+			Message: SARIFMessage{Text: annotation.Message},
+		}
+
+		if annotation.StepID != "" {
+			result.Properties = map[string]string{"stepId": annotation.StepID}
+		}
+
+		if annotation.File != "" {
+			location := &SARIFLocation{
+				PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: annotation.File}},
+			}
+
+			// region requires at least start line to be meaningful
+			if annotation.Line > 0 {
+				location.PhysicalLocation.Region = &SARIFRegion{
+					StartLine:   annotation.Line,
+					StartColumn: annotation.Col,
+					EndLine:     annotation.EndLine,
+					EndColumn:   annotation.EndCol,
+				}
+			}
+
+			result.Locations = []*SARIFLocation{location}
+		}
+
+		results = append(results, result)
+	}
+
+	return &SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []*SARIFRun{
+			{
+				Tool: SARIFTool{
+					Driver: SARIFDriver{
+						Name:           "ghx",
+						Version:        version.GetVersion().GitVersion,
+						InformationURI: "https://github.com/aweris/ghx",
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// sarifLevel converts the annotation level to SARIF result level.
+func sarifLevel(level model.AnnotationLevel) string {
+	switch level {
+	case model.AnnotationLevelError:
+		return "error"
+	case model.AnnotationLevelWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,540 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnvCacheDir is the environment variable to override the default cache directory.
+const EnvCacheDir = "GHX_CACHE_DIR"
+
+// DefaultMaxSize is the default size limit of the cache in bytes.
+const DefaultMaxSize int64 = 1 << 30 // 1 GiB
+
+const (
+	indexFile    = "index.json" // name of the index file in the cache directory
+	indexVersion = 2            // version of the index format
+	lockFileName = ".lock"      // name of the file locked while reading and writing the index
+	leasesDir    = ".leases"    // directory of the files locked by the processes using the entries
+)
+
+// errLocked is returned when a file is locked by another process and waiting for the lock is not requested.
+var errLocked = errors.New("file is locked by another process")
+
+// DefaultDir returns the default cache directory. GHX_CACHE_DIR is used if it's set, otherwise the directory is
+// created under the user cache directory.
+func DefaultDir() string {
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		return dir
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "ghx", "actions")
+}
+
+// Entry represents a single action tree stored in the cache. Entries are content-addressed by server host, repository,
+// commit and path in the repository, so the content of an entry never changes once it's stored.
+type Entry struct {
+	Key      string    `json:"key"`       // key of the entry, also the name of the entry directory
+	Host     string    `json:"host"`      // host of the server the action is fetched from, e.g. github.com
+	Repo     string    `json:"repo"`      // repository of the action, e.g. actions/checkout
+	Commit   string    `json:"commit"`    // commit SHA of the action tree
+	Path     string    `json:"path"`      // path of the action in the repository, "." for the root
+	Refs     []string  `json:"refs"`      // refs resolved to the commit so far, used to resolve refs in offline mode
+	Hash     string    `json:"hash"`      // content hash of the action tree
+	Size     int64     `json:"size"`      // size of the action tree in bytes
+	LastUsed time.Time `json:"last-used"` // last time the entry is used, used for LRU eviction
+}
+
+// index is the on disk representation of the cache entries.
+type index struct {
+	Version int               `json:"version"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Cache is a persistent, content-addressed store for action trees shared across runs. The cache is safe to use from
+// multiple processes. The index is read and written under a file lock and changes of the process are merged into the
+// latest index on write. Entries are leased with shared file locks while they're used, so no process evicts them.
+type Cache struct {
+	dir     string
+	maxSize int64
+	index   *index
+	changes []func(idx *index)  // changes applied to the index by this process, replayed on the latest index on write
+	leases  map[string]*os.File // lease files of the entries used by this process, locked until the cache is closed
+}
+
+// Open opens the cache in the given directory. Entries are evicted in least recently used order when the total size
+// exceeds maxSize. A maxSize of zero or less disables the limit.
+func Open(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, leasesDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %v", dir, err)
+	}
+
+	lock, err := lockFile(filepath.Join(dir, lockFileName), false, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock cache directory %s: %v", dir, err)
+	}
+	defer lock.Close()
+
+	idx, _, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{dir: dir, maxSize: maxSize, index: idx, leases: make(map[string]*os.File)}, nil
+}
+
+// readIndex reads the index in the cache directory. Indexes of older versions are dropped, since their keys can't be
+// mapped to the current ones, and keys of their entries are returned as obsolete to remove the entry directories.
+func readIndex(dir string) (*index, []string, error) {
+	idx := &index{Version: indexVersion, Entries: make(map[string]*Entry)}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stored index
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse cache index %s: %v", filepath.Join(dir, indexFile), err)
+	}
+
+	if stored.Version > indexVersion {
+		return nil, nil, fmt.Errorf("unsupported cache index version %d in %s", stored.Version, dir)
+	}
+
+	if stored.Version < indexVersion {
+		obsolete := make([]string, 0, len(stored.Entries))
+
+		for key := range stored.Entries {
+			obsolete = append(obsolete, key)
+		}
+
+		return idx, obsolete, nil
+	}
+
+	if stored.Entries != nil {
+		idx.Entries = stored.Entries
+	}
+
+	return idx, nil, nil
+}
+
+// writeIndex writes the index to the cache directory. The index is replaced atomically, so it's never read partially.
+func writeIndex(dir string, idx *index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".index-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, indexFile))
+}
+
+// Key returns the cache key of the action tree.
+func Key(host, repo, commit, path string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%s@%s:%s", strings.ToLower(host), repo, commit, filepath.Clean(path)))))
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// EntryDir returns the directory the entry content is stored in.
+func (c *Cache) EntryDir(entry *Entry) string {
+	return filepath.Join(c.dir, entry.Key)
+}
+
+// Get returns the entry of the action tree with the given server host, repository, commit and path. The entry is
+// leased until the cache is closed, so it's not evicted while it's used.
+func (c *Cache) Get(host, repo, commit, path string) (*Entry, bool) {
+	key := Key(host, repo, commit, path)
+
+	entry, ok := c.index.Entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	// lease the entry before checking the directory, so it can't be evicted once it's found
+	if err := c.lease(key); err != nil {
+		return nil, false
+	}
+
+	// index might be out of sync if the directory is removed manually or evicted by another process
+	if _, err := os.Stat(c.EntryDir(entry)); err != nil {
+		c.release(key)
+		c.apply(func(idx *index) { delete(idx.Entries, key) })
+
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Resolve looks up the entry for the given ref without accessing the network. The ref can be a commit SHA or a ref
+// resolved before. If the ref resolved to multiple commits over time, the most recently used one is returned.
+func (c *Cache) Resolve(host, repo, ref, path string) (*Entry, bool) {
+	if entry, ok := c.Get(host, repo, ref, path); ok {
+		return entry, true
+	}
+
+	var found *Entry
+
+	for _, entry := range c.index.Entries {
+		if !strings.EqualFold(entry.Host, host) || entry.Repo != repo || entry.Path != filepath.Clean(path) || !contains(entry.Refs, ref) {
+			continue
+		}
+
+		if found == nil || entry.LastUsed.After(found.LastUsed) {
+			found = entry
+		}
+	}
+
+	if found == nil {
+		return nil, false
+	}
+
+	return c.Get(found.Host, found.Repo, found.Commit, found.Path)
+}
+
+// Put stores the action tree in the cache. The fill function writes the content into the given directory. Content is
+// moved into the cache only if fill succeeds, so a failed download never leaves a partial entry behind. The entry is
+// leased until the cache is closed, same as Get.
+func (c *Cache) Put(host, repo, commit, path string, fill func(dir string) error, hash func(dir string) (string, error)) (*Entry, error) {
+	entry := &Entry{
+		Key:    Key(host, repo, commit, path),
+		Host:   strings.ToLower(host),
+		Repo:   repo,
+		Commit: commit,
+		Path:   filepath.Clean(path),
+	}
+
+	tmp, err := os.MkdirTemp(c.dir, ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	content := filepath.Join(tmp, "content")
+
+	if err := fill(content); err != nil {
+		return nil, err
+	}
+
+	if entry.Hash, err = hash(content); err != nil {
+		return nil, err
+	}
+
+	if entry.Size, err = dirSize(content); err != nil {
+		return nil, err
+	}
+
+	if err := c.lease(entry.Key); err != nil {
+		return nil, err
+	}
+
+	// another process might have stored the same content meanwhile, it might be in use, so keep it
+	if _, err := os.Stat(c.EntryDir(entry)); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Rename(content, c.EntryDir(entry)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.apply(func(idx *index) {
+		if _, ok := idx.Entries[entry.Key]; !ok {
+			idx.Entries[entry.Key] = entry
+		}
+	})
+
+	return c.index.Entries[entry.Key], nil
+}
+
+// Touch marks the entry as used now and records the ref resolved to the entry commit.
+func (c *Cache) Touch(entry *Entry, ref string) {
+	key, now := entry.Key, time.Now()
+
+	c.apply(func(idx *index) {
+		entry, ok := idx.Entries[key]
+		if !ok {
+			return
+		}
+
+		if now.After(entry.LastUsed) {
+			entry.LastUsed = now
+		}
+
+		if ref != "" && ref != entry.Commit && !contains(entry.Refs, ref) {
+			entry.Refs = append(entry.Refs, ref)
+		}
+
+		// a ref can only point one commit at a time, so drop it from other entries of the same action
+		for _, other := range idx.Entries {
+			if other == entry || other.Host != entry.Host || other.Repo != entry.Repo || other.Path != entry.Path {
+				continue
+			}
+
+			other.Refs = remove(other.Refs, ref)
+		}
+	})
+}
+
+// apply applies the change to the index and records it to replay on the latest index when the index is written.
+func (c *Cache) apply(change func(idx *index)) {
+	change(c.index)
+
+	c.changes = append(c.changes, change)
+}
+
+// lease locks the lease file of the entry with a shared lock, so other processes skip the entry on eviction.
+func (c *Cache) lease(key string) error {
+	if _, ok := c.leases[key]; ok {
+		return nil
+	}
+
+	f, err := lockFile(filepath.Join(c.dir, leasesDir, key), false, true)
+	if err != nil {
+		return fmt.Errorf("failed to lease cache entry %s: %v", key, err)
+	}
+
+	c.leases[key] = f
+
+	return nil
+}
+
+// release releases the lease of the entry.
+func (c *Cache) release(key string) {
+	if f, ok := c.leases[key]; ok {
+		f.Close()
+		delete(c.leases, key)
+	}
+}
+
+// Entries returns all entries in the cache ordered by repository, path and last usage.
+func (c *Cache) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(c.index.Entries))
+
+	for _, entry := range c.index.Entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Repo != entries[j].Repo {
+			return entries[i].Repo < entries[j].Repo
+		}
+
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+
+	return entries
+}
+
+// Size returns the total size of the entries in the cache.
+func (c *Cache) Size() int64 {
+	var size int64
+
+	for _, entry := range c.index.Entries {
+		size += entry.Size
+	}
+
+	return size
+}
+
+// Prune evicts least recently used entries until the total size is less than or equal to maxSize and returns the
+// evicted entries. Entries leased by any process are never evicted. A maxSize of zero evicts all unused entries.
+func (c *Cache) Prune(maxSize int64) ([]*Entry, error) {
+	var evicted []*Entry
+
+	err := c.update(func() (err error) {
+		evicted, err = c.prune(maxSize)
+		return err
+	})
+
+	return evicted, err
+}
+
+// prune evicts entries as described in Prune. The caller must hold the lock of the cache.
+func (c *Cache) prune(maxSize int64) ([]*Entry, error) {
+	entries := make([]*Entry, 0, len(c.index.Entries))
+
+	for _, entry := range c.index.Entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+
+	var (
+		size    = c.Size()
+		evicted []*Entry
+	)
+
+	for _, entry := range entries {
+		if size <= maxSize {
+			break
+		}
+
+		if _, ok := c.leases[entry.Key]; ok {
+			continue
+		}
+
+		// entries leased by other processes are in use
+		lease, err := lockFile(filepath.Join(c.dir, leasesDir, entry.Key), true, false)
+		if errors.Is(err, errLocked) {
+			continue
+		}
+
+		if err != nil {
+			return evicted, err
+		}
+
+		err = os.RemoveAll(c.EntryDir(entry))
+		if err == nil {
+			err = os.Remove(lease.Name())
+		}
+
+		lease.Close()
+
+		if err != nil {
+			return evicted, err
+		}
+
+		delete(c.index.Entries, entry.Key)
+
+		size -= entry.Size
+		evicted = append(evicted, entry)
+	}
+
+	return evicted, nil
+}
+
+// update locks the cache, reloads the index with the changes of this process replayed on it and calls fn before
+// writing the index back, so changes of other processes since the cache is opened are not lost.
+func (c *Cache) update(fn func() error) error {
+	lock, err := lockFile(filepath.Join(c.dir, lockFileName), true, true)
+	if err != nil {
+		return fmt.Errorf("failed to lock cache directory %s: %v", c.dir, err)
+	}
+	defer lock.Close()
+
+	latest, obsolete, err := readIndex(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range obsolete {
+		if err := os.RemoveAll(filepath.Join(c.dir, key)); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range c.changes {
+		change(latest)
+	}
+
+	c.index, c.changes = latest, nil
+
+	return errors.Join(fn(), writeIndex(c.dir, c.index))
+}
+
+// Close evicts entries exceeding the size limit, writes the index to the cache directory and releases the leases of
+// the entries.
+func (c *Cache) Close() error {
+	err := c.update(func() error {
+		if c.maxSize <= 0 {
+			return nil
+		}
+
+		_, err := c.prune(c.maxSize)
+
+		return err
+	})
+
+	for key := range c.leases {
+		c.release(key)
+	}
+
+	return err
+}
+
+// dirSize returns the total size of the regular files in the directory.
+func dirSize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remove(list []string, s string) []string {
+	result := list[:0]
+
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
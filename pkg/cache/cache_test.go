@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	host     = "github.com"
+	commitV3 = "8e5e7e5ab8b370d6c329ec480221332ada57f0ab"
+	commitV4 = "f43a0e5ff2bd294095638e18286ca9a3d1956744"
+)
+
+func fillWith(content string) func(dir string) error {
+	return func(dir string) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(dir, "action.yml"), []byte(content), 0600)
+	}
+}
+
+func hashContent(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "action.yml"))
+	if err != nil {
+		return "", err
+	}
+
+	return "hash:" + string(data), nil
+}
+
+func TestCache_PutGetResolve(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	entry, err := c.Put(host, "actions/checkout", commitV3, ".", fillWith("name: checkout"), hashContent)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	c.Touch(entry, "v3")
+
+	if entry.Hash != "hash:name: checkout" {
+		t.Errorf("Expected hash to be calculated from content, but got %s", entry.Hash)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// reopen to make sure index is persisted
+	c, err = Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if _, ok := c.Get(host, "actions/checkout", commitV3, ""); !ok {
+		t.Errorf("Expected entry for commit %s, but got none", commitV3)
+	}
+
+	tests := []struct {
+		name  string
+		ref   string
+		path  string
+		found bool
+	}{
+		{name: "resolved ref", ref: "v3", path: ".", found: true},
+		{name: "commit sha", ref: commitV3, path: ".", found: true},
+		{name: "unknown ref", ref: "v4", path: "."},
+		{name: "different path", ref: "v3", path: "sub"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := c.Resolve(host, "actions/checkout", tt.ref, tt.path)
+
+			if ok != tt.found {
+				t.Fatalf("Expected found %v, but got %v", tt.found, ok)
+			}
+
+			if ok && entry.Commit != commitV3 {
+				t.Errorf("Expected commit %s, but got %s", commitV3, entry.Commit)
+			}
+		})
+	}
+}
+
+func TestCache_TouchMovesRef(t *testing.T) {
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	old, _ := c.Put(host, "actions/checkout", commitV3, ".", fillWith("old"), hashContent)
+	c.Touch(old, "main")
+
+	latest, _ := c.Put(host, "actions/checkout", commitV4, ".", fillWith("new"), hashContent)
+	c.Touch(latest, "main")
+
+	entry, ok := c.Resolve(host, "actions/checkout", "main", ".")
+	if !ok {
+		t.Fatalf("Expected entry for ref main, but got none")
+	}
+
+	if entry.Commit != commitV4 {
+		t.Errorf("Expected ref to point %s, but got %s", commitV4, entry.Commit)
+	}
+
+	if len(old.Refs) != 0 {
+		t.Errorf("Expected ref to be removed from old entry, but got %v", old.Refs)
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	old, _ := c.Put(host, "actions/checkout", commitV3, ".", fillWith(strings.Repeat("a", 100)), hashContent)
+	latest, _ := c.Put(host, "actions/setup-go", commitV4, ".", fillWith(strings.Repeat("b", 100)), hashContent)
+
+	c.Touch(old, "v3")
+	c.Touch(latest, "v4")
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// reopen the cache, so entries are not protected as used by the current process
+	c, err = Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	evicted, err := c.Prune(150)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if len(evicted) != 1 || evicted[0].Repo != "actions/checkout" {
+		t.Fatalf("Expected least recently used entry to be evicted, but got %v", evicted)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, evicted[0].Key)); !os.IsNotExist(err) {
+		t.Errorf("Expected evicted entry directory to be removed, but got %v", err)
+	}
+
+	if _, ok := c.Get(host, "actions/setup-go", commitV4, "."); !ok {
+		t.Errorf("Expected recently used entry to be kept")
+	}
+
+	latest, _ = c.Get(host, "actions/setup-go", commitV4, ".")
+	c.Touch(latest, "v4")
+
+	if evicted, _ := c.Prune(0); len(evicted) != 0 {
+		t.Errorf("Expected entries used by the process to be protected, but got %v evicted", len(evicted))
+	}
+}
+
+func TestCache_HostInKey(t *testing.T) {
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	entry, _ := c.Put("ghes.example.com", "actions/checkout", commitV3, ".", fillWith("enterprise"), hashContent)
+	c.Touch(entry, "v3")
+
+	if _, ok := c.Get(host, "actions/checkout", commitV3, "."); ok {
+		t.Errorf("Expected no entry for %s, but got one", host)
+	}
+
+	if _, ok := c.Resolve(host, "actions/checkout", "v3", "."); ok {
+		t.Errorf("Expected ref not to be resolved for %s, but got an entry", host)
+	}
+
+	if _, ok := c.Resolve("GHES.example.com", "actions/checkout", "v3", "."); !ok {
+		t.Errorf("Expected ref to be resolved for host case insensitively, but got none")
+	}
+}
+
+func TestCache_ConcurrentProcesses(t *testing.T) {
+	dir := t.TempDir()
+
+	// caches opened separately behave like different processes, since flock locks belong to the open files
+	first, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	second, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	checkout, _ := first.Put(host, "actions/checkout", commitV3, ".", fillWith("checkout"), hashContent)
+	first.Touch(checkout, "v3")
+
+	setupGo, _ := second.Put(host, "actions/setup-go", commitV4, ".", fillWith("setup-go"), hashContent)
+	second.Touch(setupGo, "v4")
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// entry used by the second cache is leased, so it's not evicted by others
+	pruner, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	evicted, err := pruner.Prune(0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if len(evicted) != 1 || evicted[0].Repo != "actions/checkout" {
+		t.Fatalf("Expected only the unused entry to be evicted, but got %v", evicted)
+	}
+
+	if err := pruner.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// closing the second cache keeps the eviction and adds its own entry
+	if err := second.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	c, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if _, ok := c.Resolve(host, "actions/checkout", "v3", "."); ok {
+		t.Errorf("Expected evicted entry to stay removed, but got one")
+	}
+
+	if _, ok := c.Resolve(host, "actions/setup-go", "v4", "."); !ok {
+		t.Errorf("Expected entry of the second cache to be merged into the index, but got none")
+	}
+}
+
+func TestOpen_OldIndexVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, indexFile), []byte(`{"version":1,"entries":{"abc":{"key":"abc"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fillWith("old")(filepath.Join(dir, "abc"))
+
+	c, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if len(c.Entries()) != 0 {
+		t.Errorf("Expected entries of the old index to be dropped, but got %v", c.Entries())
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "abc")); !os.IsNotExist(err) {
+		t.Errorf("Expected directory of the old entry to be removed, but got %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package cache
+
+import "os"
+
+// lockFile opens the file at the path, creating it if it's missing. File locks aren't supported on the platform, so
+// the file is not locked and the cache is only safe to use from a single process at a time.
+func lockFile(path string, _, _ bool) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+}
@@ -0,0 +1,64 @@
+//go:build linux || darwin
+
+package cache
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile opens the file at the path, creating it if it's missing, and locks it with flock(2). Shared locks can be
+// held by multiple processes at once, exclusive locks by only one. If wait is false, errLocked is returned instead of
+// waiting for the lock. The lock is released when the file is closed.
+func lockFile(path string, exclusive, wait bool) (*os.File, error) {
+	how := unix.LOCK_SH
+	if exclusive {
+		how = unix.LOCK_EX
+	}
+
+	if !wait {
+		how |= unix.LOCK_NB
+	}
+
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := flock(f, how); err != nil {
+			f.Close()
+
+			if errors.Is(err, unix.EWOULDBLOCK) {
+				return nil, errLocked
+			}
+
+			return nil, err
+		}
+
+		// the file might be removed by the previous holder of the lock, lock the file at the path again in that case
+		locked, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if current, err := os.Stat(path); err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+
+		f.Close()
+	}
+}
+
+// flock locks the file, retrying if the call is interrupted by a signal.
+func flock(f *os.File, how int) error {
+	for {
+		err := unix.Flock(int(f.Fd()), how)
+		if !errors.Is(err, unix.EINTR) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package check provides static checks of the expressions in the steps of a job before running it.
+package check
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+
+	"github.com/aweris/ghx/pkg/expression"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// Options represents the job to check.
+type Options struct {
+	WorkflowPath string                   // WorkflowPath is the path of the workflow file used in the annotations.
+	Job          *model.Job               // Job is the job of the steps. Nil if the steps are added without a job.
+	Steps        []*model.Step            // Steps is the list of steps of the job in execution order.
+	Actions      map[string]*model.Action // Actions maps `uses` of the steps to the metadata of the actions.
+}
+
+// Job type checks the expressions in the steps of the job against the contexts available to them and returns the
+// problems found as annotations. Contexts are typed with what's known before running the job:
+//
+//   - steps: ids of the previous steps. Outputs are checked against the outputs declared by the actions.
+//   - matrix: variables of the matrix strategy of the job.
+//   - needs: ids of the jobs the job depends on.
+//
+// Values that can't be known statically, like outputs of run steps or actions missing in the options, are not checked.
+func Job(opts Options) []*model.Annotation {
+	c := &checker{
+		opts:   opts,
+		matrix: matrixType(opts.Job),
+		needs:  needsType(opts.Job),
+		steps:  actionlint.NewEmptyStrictObjectType(),
+	}
+
+	for _, step := range opts.Steps {
+		action := opts.Actions[step.Uses]
+
+		c.checkStep(step, action)
+
+		// steps context only contains the steps run before the current step
+		c.steps.Props[strings.ToLower(step.ID)] = actionlint.NewStrictObjectType(map[string]actionlint.ExprType{
+			"outputs":    outputsType(step, action),
+			"conclusion": actionlint.StringType{},
+			"outcome":    actionlint.StringType{},
+		})
+	}
+
+	return c.annotations
+}
+
+// checker keeps the context types while checking the steps of a job.
+type checker struct {
+	opts        Options
+	matrix      *actionlint.ObjectType
+	needs       *actionlint.ObjectType
+	steps       *actionlint.ObjectType
+	annotations []*model.Annotation
+}
+
+// checkStep checks the expressions in the step and the inputs passed to the action of the step.
+func (c *checker) checkStep(step *model.Step, action *model.Action) {
+	c.checkCondition(step, "if", step.If)
+	c.checkString(step, "name", step.Name, "jobs.<job_id>.steps.name")
+	c.checkString(step, "run", step.Run, "jobs.<job_id>.steps.run")
+
+	for _, key := range sortedKeys(step.With) {
+		c.checkString(step, "with."+key, step.With[key], "jobs.<job_id>.steps.with")
+	}
+
+	for _, key := range sortedKeys(step.Environment) {
+		c.checkString(step, "env."+key, step.Environment[key], "jobs.<job_id>.steps.env")
+	}
+
+	if action == nil {
+		return
+	}
+
+	warnings, err := action.ValidateInputs(step.With)
+
+	for _, warning := range warnings {
+		c.report(step, "uses", model.AnnotationLevelWarning, "Action inputs", warning)
+	}
+
+	if err != nil {
+		c.report(step, "uses", model.AnnotationLevelError, "Action inputs", err.Error())
+	}
+}
+
+// checkCondition checks the if condition of the step. Conditions can omit the expression syntax, so the whole value
+// is checked as an expression unless it contains `${{ }}`.
+func (c *checker) checkCondition(step *model.Step, key, condition string) {
+	if strings.Contains(condition, "${{") && strings.Contains(condition, "}}") {
+		c.checkString(step, key, condition, "jobs.<job_id>.steps.if")
+		return
+	}
+
+	if strings.TrimSpace(condition) == "" {
+		return
+	}
+
+	// }} marks the end of the expression for the lexer
+	c.checkExpr(step, key, condition, condition+"}}", 0, "jobs.<job_id>.steps.if")
+}
+
+// checkString checks the expressions in the `${{ }}` placeholders of the value.
+func (c *checker) checkString(step *model.Step, key, value, workflowKey string) {
+	offset := 0
+
+	for {
+		idx := strings.Index(value[offset:], "${{")
+		if idx < 0 {
+			return
+		}
+
+		start := offset + idx + len("${{")
+
+		end, ok := c.checkExpr(step, key, value, value[start:], start, workflowKey)
+		if !ok || end == 0 {
+			return
+		}
+
+		offset = start + end
+	}
+}
+
+// checkExpr parses and type checks the expression at the start of the src and returns the offset of the end of the
+// expression in the src. It returns false if the expression can't be parsed. The offset is the offset of the src in the
+// value, used to point the problems in the value.
+func (c *checker) checkExpr(step *model.Step, key, value, src string, offset int, workflowKey string) (int, bool) {
+	lexer := actionlint.NewExprLexer(src)
+
+	node, err := actionlint.NewExprParser().Parse(lexer)
+	if err != nil {
+		c.reportAt(step, key, value, offset+err.Offset, err.Message)
+		return lexer.Offset(), false
+	}
+
+	sema := actionlint.NewExprSemanticsChecker(false, nil)
+
+	sema.UpdateMatrix(c.matrix)
+	sema.UpdateNeeds(c.needs)
+	sema.UpdateSteps(c.steps)
+
+	// inputs of workflow triggers aren't known by the job, so any input is allowed
+	sema.UpdateInputs(actionlint.NewEmptyObjectType())
+
+	contexts, funcs := actionlint.WorkflowKeyAvailability(workflowKey)
+
+	sema.SetContextAvailability(contexts)
+	sema.SetSpecialFunctionAvailability(funcs)
+
+	_, errs := sema.Check(node)
+
+	for _, err := range errs {
+		c.reportAt(step, key, value, offset+err.Offset, err.Message)
+	}
+
+	return lexer.Offset(), true
+}
+
+// reportAt reports an error in the value of the step at the given offset.
+func (c *checker) reportAt(step *model.Step, key, value string, offset int, msg string) {
+	err := &expression.Error{Input: value, Offset: offset, Err: errors.New(msg)}
+
+	c.annotate(step, step.Position(key).At(value, offset), model.AnnotationLevelError, fmt.Sprintf("Invalid expression in %s", key), err.Message())
+}
+
+// report adds an annotation for the value of the step with the given key. The annotation is placed at the position
+// of the value in the workflow file if it's known.
+func (c *checker) report(step *model.Step, key string, level model.AnnotationLevel, title, msg string) {
+	c.annotate(step, step.Position(key), level, title, msg)
+}
+
+// annotate adds an annotation for the step placed at the given position, unknown positions are left empty.
+func (c *checker) annotate(step *model.Step, pos model.Position, level model.AnnotationLevel, title, msg string) {
+	annotation := &model.Annotation{
+		Level:   level,
+		Title:   title,
+		Message: msg,
+		StepID:  step.ID,
+	}
+
+	if pos.Line > 0 {
+		annotation.File = c.opts.WorkflowPath
+		annotation.Line = pos.Line
+		annotation.Col = pos.Col
+	}
+
+	c.annotations = append(c.annotations, annotation)
+}
+
+// matrixType returns the type of the matrix context of the job. Matrix generated from an expression can have any
+// variable.
+func matrixType(job *model.Job) *actionlint.ObjectType {
+	if job == nil {
+		return actionlint.NewEmptyObjectType()
+	}
+
+	if job.Strategy == nil || job.Strategy.Matrix == nil {
+		return actionlint.NewEmptyStrictObjectType()
+	}
+
+	matrix := job.Strategy.Matrix
+
+	if matrix.Expression != "" {
+		return actionlint.NewEmptyObjectType()
+	}
+
+	props := make(map[string]actionlint.ExprType)
+
+	for name := range matrix.Rows {
+		props[strings.ToLower(name)] = actionlint.AnyType{}
+	}
+
+	for _, include := range matrix.Include {
+		for name := range include {
+			props[strings.ToLower(name)] = actionlint.AnyType{}
+		}
+	}
+
+	return actionlint.NewStrictObjectType(props)
+}
+
+// needsType returns the type of the needs context of the job. Outputs of the needed jobs aren't known, so any output
+// is allowed.
+func needsType(job *model.Job) *actionlint.ObjectType {
+	if job == nil {
+		return actionlint.NewEmptyObjectType()
+	}
+
+	props := make(map[string]actionlint.ExprType)
+
+	for _, name := range job.Needs {
+		props[strings.ToLower(name)] = actionlint.NewStrictObjectType(map[string]actionlint.ExprType{
+			"outputs": actionlint.NewMapObjectType(actionlint.StringType{}),
+			"result":  actionlint.StringType{},
+		})
+	}
+
+	return actionlint.NewStrictObjectType(props)
+}
+
+// outputsType returns the type of the outputs of the step. Outputs of actions are the outputs declared by the action,
+// outputs of run steps and unknown actions are set dynamically, so any output is allowed.
+func outputsType(step *model.Step, action *model.Action) *actionlint.ObjectType {
+	if step.Type() != model.StepTypeAction || action == nil {
+		return actionlint.NewMapObjectType(actionlint.StringType{})
+	}
+
+	props := make(map[string]actionlint.ExprType)
+
+	for name := range action.Outputs {
+		props[strings.ToLower(name)] = actionlint.StringType{}
+	}
+
+	return actionlint.NewStrictObjectType(props)
+}
+
+// sortedKeys returns the keys of the map in sorted order to report problems in a stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
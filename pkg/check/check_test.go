@@ -0,0 +1,149 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aweris/ghx/pkg/model"
+)
+
+const testJob = `
+needs: build
+strategy:
+  matrix:
+    go: ["1.20", "1.21"]
+    include:
+      - experimental: true
+steps:
+  - id: version
+    run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+  - id: checkout
+    uses: actions/checkout@v3
+    with:
+      ref: ${{ steps.version.outputs.version }}
+  - name: Build ${{ matrix.go }}
+    if: steps.checkout.outputs.ref != '' && matrix.experimental
+    run: echo ${{ steps.biuld.outputs.x }}
+    env:
+      COMMIT: ${{ steps.checkout.outputs.commit }}
+      RESULT: ${{ needs.build.result }} ${{ needs.test.result }}
+  - id: release
+    if: ${{ always() && matrix.os == 'linux' }}
+    uses: actions/checkout@v3
+    with:
+      token: ${{ success() }}
+      unknown: foo
+  - run: echo ${{ steps.release.outputs.ref }} ${{ github.ref == }}
+`
+
+func TestJob(t *testing.T) {
+	var job model.Job
+
+	if err := yaml.Unmarshal([]byte(testJob), &job); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	for i, step := range job.Steps {
+		if step.ID == "" {
+			step.ID = strings.Repeat("s", i+1)
+		}
+	}
+
+	checkout := &model.Action{
+		Inputs:  map[string]model.ActionInput{"ref": {}, "token": {}},
+		Outputs: map[string]model.ActionOutput{"ref": {}},
+	}
+
+	annotations := Job(Options{
+		WorkflowPath: ".github/workflows/ci.yaml",
+		Job:          &job,
+		Steps:        job.Steps,
+		Actions:      map[string]*model.Action{"actions/checkout@v3": checkout},
+	})
+
+	expected := []struct {
+		level   model.AnnotationLevel
+		line    int
+		message string
+	}{
+		{model.AnnotationLevelError, 17, `property "biuld" is not defined in object type`},
+		{model.AnnotationLevelError, 19, `property "commit" is not defined in object type {ref: string}`},
+		{model.AnnotationLevelError, 20, `property "test" is not defined in object type`},
+		{model.AnnotationLevelError, 22, `property "os" is not defined in object type`},
+		{model.AnnotationLevelError, 25, `calling function "success" is not allowed here`},
+		{model.AnnotationLevelWarning, 23, `Unexpected input(s) 'unknown'`},
+		{model.AnnotationLevelError, 27, `unexpected end of input`},
+	}
+
+	if len(annotations) != len(expected) {
+		for _, a := range annotations {
+			t.Logf("%d:%d %s %s", a.Line, a.Col, a.Level, a.Message)
+		}
+
+		t.Fatalf("Expected %d annotations, but got %d", len(expected), len(annotations))
+	}
+
+	for i, e := range expected {
+		a := annotations[i]
+
+		if a.Level != e.level || a.Line != e.line || !strings.Contains(a.Message, e.message) {
+			t.Errorf("Expected %s at line %d containing %q, but got %s at line %d: %s", e.level, e.line, e.message, a.Level, a.Line, a.Message)
+		}
+
+		if a.File != ".github/workflows/ci.yaml" {
+			t.Errorf("Expected file .github/workflows/ci.yaml, but got %s", a.File)
+		}
+	}
+}
+
+func TestJob_WithoutJob(t *testing.T) {
+	steps := []*model.Step{
+		{ID: "build", Run: "echo ${{ matrix.os }} ${{ needs.build.outputs.foo }}"},
+		{ID: "test", Run: "echo ${{ steps.test.outputs.foo }} ${{ steps.build.outputs.foo }} ${{ steps.missing.outputs.foo }}"},
+	}
+
+	annotations := Job(Options{Steps: steps})
+
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, but got %d", len(annotations))
+	}
+
+	// matrix and needs aren't known without a job, only the current and missing steps are reported
+	for _, a := range annotations {
+		if a.File != "" || a.Line != 0 {
+			t.Errorf("Expected annotation without position, but got %s:%d", a.File, a.Line)
+		}
+
+		if a.StepID != "test" {
+			t.Errorf("Expected annotation for step test, but got %s", a.StepID)
+		}
+	}
+}
+
+func TestJob_BlockScalar(t *testing.T) {
+	content := `steps:
+  - id: build
+    run: |
+      echo start
+      echo ${{ steps.missing.outputs.foo }}
+`
+
+	var job model.Job
+
+	if err := yaml.Unmarshal([]byte(content), &job); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	annotations := Job(Options{WorkflowPath: "ci.yaml", Job: &job, Steps: job.Steps})
+
+	if len(annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, but got %d", len(annotations))
+	}
+
+	// the error is reported at the line of the expression in the script, not at the block indicator
+	if a := annotations[0]; a.Line != 5 || a.Col != 16 {
+		t.Errorf("Expected annotation at 5:16, but got %d:%d", a.Line, a.Col)
+	}
+}
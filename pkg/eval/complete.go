@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aweris/ghx/pkg/expression"
+)
+
+// contexts is the list of the contexts available in the expressions.
+var contexts = []string{"env", "github", "inputs", "job", "matrix", "needs", "runner", "secrets", "steps", "strategy", "vars"}
+
+// functions is the list of the functions available in the expressions.
+var functions = []string{
+	"always", "cancelled", "contains", "endsWith", "failure", "format", "fromJSON", "hashFiles", "join", "startsWith",
+	"success", "toJSON",
+}
+
+// Complete returns the context path at the end of the line and the candidates to complete it. Context names and
+// functions are completed at the top level, properties are completed from the values in the context, e.g. `steps.b`
+// is completed to the ids of the steps starting with `b`.
+func (e *Evaluator) Complete(line string) (string, []string) {
+	word := line[strings.LastIndexFunc(line, func(r rune) bool { return !isPathChar(r) })+1:]
+
+	var candidates []string
+
+	dot := strings.LastIndexByte(word, '.')
+
+	if dot < 0 {
+		for _, name := range contexts {
+			if hasPrefixFold(name, word) {
+				candidates = append(candidates, name)
+			}
+		}
+
+		for _, name := range functions {
+			if hasPrefixFold(name, word) {
+				candidates = append(candidates, name+"(")
+			}
+		}
+
+		return word, candidates
+	}
+
+	parent, partial := word[:dot], word[dot+1:]
+
+	for _, key := range e.keys(parent) {
+		if hasPrefixFold(key, partial) {
+			candidates = append(candidates, parent+"."+key)
+		}
+	}
+
+	return word, candidates
+}
+
+// keys returns the sorted property names of the value of the given context path. Values other than objects don't have
+// any properties.
+func (e *Evaluator) keys(path string) []string {
+	if path == "" || strings.HasSuffix(path, ".") {
+		return nil
+	}
+
+	// convert the value to JSON to list the properties of the maps and structs in the same way
+	expr, err := expression.NewExpression(fmt.Sprintf("toJSON(%s)", path))
+	if err != nil {
+		return nil
+	}
+
+	out, err := expr.Evaluate(e.ctx)
+	if err != nil {
+		return nil
+	}
+
+	var obj map[string]interface{}
+
+	if err := json.Unmarshal([]byte(out.(string)), &obj); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(obj))
+
+	for key := range obj {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// isPathChar returns true if the rune can be a part of a context path.
+func isPathChar(r rune) bool {
+	return r == '.' || r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// hasPrefixFold returns true if the string starts with the prefix ignoring the case, since context paths are case
+// insensitive.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,107 @@
+// Package eval evaluates expressions against the context of the configured job to debug them without running the job.
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/expression"
+)
+
+// mask is the replacement of the secret values in the results.
+const mask = "***"
+
+// Evaluator evaluates expressions against an actions context. Secret values are masked in the results and errors.
+type Evaluator struct {
+	ctx     *actions.Context
+	secrets []string // secrets is the list of the secret values to mask, longest first
+}
+
+// New creates a new Evaluator for the given context.
+func New(ctx *actions.Context) *Evaluator {
+	var secrets []string
+
+	for _, secret := range ctx.Secrets {
+		secrets = append(secrets, secret)
+	}
+
+	if ctx.Github != nil {
+		secrets = append(secrets, ctx.Github.Token)
+	}
+
+	// mask longer values first, so a secret containing another one isn't partially revealed
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	return &Evaluator{ctx: ctx, secrets: secrets}
+}
+
+// Eval evaluates the input and returns the result as JSON. The input could be a single expression with or without the
+// expression syntax (${{ }}), or a string containing expressions which evaluates to a string like the step values.
+func (e *Evaluator) Eval(input string) (string, error) {
+	result, err := e.evaluate(input)
+	if err != nil {
+		return "", errors.New(e.Mask(err.Error()))
+	}
+
+	// evaluate the result with toJSON, so values are converted to JSON the same way as in the workflows
+	expr, err := expression.NewExpression("toJSON(result)")
+	if err != nil {
+		return "", err
+	}
+
+	out, err := expr.Evaluate(&resultProvider{result: result})
+	if err != nil {
+		return "", err
+	}
+
+	return e.Mask(out.(string)), nil
+}
+
+// evaluate evaluates the input and returns the typed result.
+func (e *Evaluator) evaluate(input string) (interface{}, error) {
+	trimmed := strings.TrimSpace(input)
+
+	// a single expression keeps its type, strings containing expressions are interpolated
+	single := strings.HasPrefix(trimmed, "${{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "${{") == 1
+
+	if single || !strings.Contains(input, "${{") {
+		expr, err := expression.NewExpression(input)
+		if err != nil {
+			return nil, err
+		}
+
+		return expr.Evaluate(e.ctx)
+	}
+
+	return actions.NewString(input).Eval(e.ctx)
+}
+
+// Mask replaces the secret values in the given string with ***. Values are also masked in their JSON encoded form,
+// since results are printed as JSON.
+func (e *Evaluator) Mask(s string) string {
+	for _, secret := range e.secrets {
+		if secret == "" {
+			continue
+		}
+
+		s = strings.ReplaceAll(s, secret, mask)
+
+		if encoded, err := json.Marshal(secret); err == nil {
+			s = strings.ReplaceAll(s, strings.Trim(string(encoded), `"`), mask)
+		}
+	}
+
+	return s
+}
+
+// resultProvider provides the result of an evaluation as a variable to convert it to JSON with toJSON.
+type resultProvider struct {
+	result interface{}
+}
+
+func (p *resultProvider) GetVariable(name string) (interface{}, error) {
+	return p.result, nil
+}
@@ -0,0 +1,142 @@
+package eval
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+func newTestEvaluator() *Evaluator {
+	ctx := actions.NewContextFromEnv()
+
+	ctx.Github.Token = "gh-token"
+	ctx.Env["FOO"] = "bar"
+	ctx.Secrets["PASSWORD"] = `pa"ss`
+	ctx.Steps["build"] = &model.StepResult{
+		Outputs:    map[string]string{"version": "1.0.0", "value": "42"},
+		Conclusion: model.StepStatusSuccess,
+		Outcome:    model.StepStatusSuccess,
+	}
+
+	return New(ctx)
+}
+
+func TestEvaluator_Eval(t *testing.T) {
+	evaluator := newTestEvaluator()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "String", input: "${{ env.FOO }}", expected: `"bar"`},
+		{name: "Without expression syntax", input: "env.foo", expected: `"bar"`},
+		{name: "Number", input: "${{ fromJSON(steps.build.outputs.value) }}", expected: "42"},
+		{name: "Boolean", input: "steps.build.outcome == 'success'", expected: "true"},
+		{name: "Null", input: "steps.missing", expected: "null"},
+		{name: "Object", input: "${{ steps.build.outputs }}", expected: "{\n  \"value\": \"42\",\n  \"version\": \"1.0.0\"\n}"},
+		{name: "Template", input: "v${{ steps.build.outputs.version }}-${{ env.FOO }}", expected: `"v1.0.0-bar"`},
+		{name: "Masked token", input: "format('token={0}', github.token)", expected: `"token=***"`},
+		{name: "Masked secret in JSON", input: "secrets", expected: "{\n  \"PASSWORD\": \"***\"\n}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := evaluator.Eval(test.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			if result != test.expected {
+				t.Errorf("Expected %q, but got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestEvaluator_EvalError(t *testing.T) {
+	evaluator := newTestEvaluator()
+
+	// the snippet of the error contains the input
+	_, err := evaluator.Eval("'gh-token' == ")
+	if err == nil {
+		t.Fatal("Expected error, but got nil")
+	}
+
+	if strings.Contains(err.Error(), "gh-token") {
+		t.Errorf("Expected secret to be masked in the error, but got %s", err.Error())
+	}
+}
+
+func TestEvaluator_Complete(t *testing.T) {
+	evaluator := newTestEvaluator()
+
+	tests := []struct {
+		line       string
+		word       string
+		candidates []string
+	}{
+		{line: "st", word: "st", candidates: []string{"steps", "strategy", "startsWith("}},
+		{line: "${{ Ste", word: "Ste", candidates: []string{"steps"}},
+		{line: "steps.", word: "steps.", candidates: []string{"steps.build"}},
+		{line: "steps.build.outputs.v", word: "steps.build.outputs.v", candidates: []string{"steps.build.outputs.value", "steps.build.outputs.version"}},
+		{line: "github.repository_o", word: "github.repository_o", candidates: []string{"github.repository_owner", "github.repository_owner_id"}},
+		{line: "env.FOO.", word: "env.FOO.", candidates: nil},
+		{line: "missing.", word: "missing.", candidates: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			word, candidates := evaluator.Complete(test.line)
+
+			if word != test.word {
+				t.Errorf("Expected word %q, but got %q", test.word, word)
+			}
+
+			if !reflect.DeepEqual(candidates, test.candidates) {
+				t.Errorf("Expected candidates %v, but got %v", test.candidates, candidates)
+			}
+		})
+	}
+}
+
+func TestEvaluator_Run(t *testing.T) {
+	evaluator := newTestEvaluator()
+
+	in, err := os.CreateTemp(t.TempDir(), "input")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+	defer in.Close()
+
+	if _, err := in.WriteString("env.FOO\n\nfoo(\nsecrets.PASSWORD"); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if _, err := in.Seek(0, 0); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	var out bytes.Buffer
+
+	if err := evaluator.Run(in, &out); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+
+	if len(lines) < 3 || lines[0] != `"bar"` || !strings.HasPrefix(lines[1], "Error: ") || lines[len(lines)-1] != `"***"` {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	if prefix := commonPrefix([]string{"steps.build", "steps.bump", "steps.b"}); prefix != "steps.b" {
+		t.Errorf("Expected steps.b, but got %s", prefix)
+	}
+}
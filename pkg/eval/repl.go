@@ -0,0 +1,115 @@
+package eval
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// prompt is the prompt of the REPL.
+const prompt = "> "
+
+// Run reads the inputs from in line by line, evaluates them and writes the results to out until the end of the input.
+// If in is a terminal, lines are read in raw mode to complete context paths with tab.
+func (e *Evaluator) Run(in *os.File, out io.Writer) error {
+	var readLine func() (string, error)
+
+	if fd := int(in.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+
+		terminal := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{in, out}, prompt)
+
+		terminal.AutoCompleteCallback = e.autoComplete(terminal)
+
+		// results are written through the terminal as well, so the line endings are translated in raw mode
+		readLine, out = terminal.ReadLine, terminal
+	} else {
+		reader := bufio.NewReader(in)
+
+		readLine = func() (string, error) {
+			line, err := reader.ReadString('\n')
+			if err == io.EOF && line != "" {
+				return line, nil
+			}
+
+			return strings.TrimRight(line, "\r\n"), err
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		result, err := e.Eval(line)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			continue
+		}
+
+		fmt.Fprintln(out, result)
+	}
+}
+
+// autoComplete returns the auto complete callback of the terminal. On tab, the context path before the cursor is
+// completed with the common prefix of the candidates. Candidates are listed if the path can't be extended.
+func (e *Evaluator) autoComplete(terminal *term.Terminal) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		word, candidates := e.Complete(line[:pos])
+		if len(candidates) == 0 {
+			return "", 0, false
+		}
+
+		prefix := commonPrefix(candidates)
+
+		if len(prefix) > len(word) {
+			start := pos - len(word)
+
+			return line[:start] + prefix + line[pos:], start + len(prefix), true
+		}
+
+		if len(candidates) > 1 {
+			fmt.Fprintln(terminal, strings.Join(candidates, "  "))
+		}
+
+		return line, pos, true
+	}
+}
+
+// commonPrefix returns the longest common prefix of the given strings.
+func commonPrefix(values []string) string {
+	prefix := values[0]
+
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
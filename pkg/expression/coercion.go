@@ -0,0 +1,209 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// The coercion rules follow the GitHub Actions expression spec:
+// https://docs.github.com/en/actions/learn-github-actions/expressions#operators
+
+// valueKind is the kind of the value in the expression type system.
+type valueKind int
+
+const (
+	kindNull valueKind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// jsonNumberRe matches numbers in JSON number format. Strings are coerced to numbers only in this format.
+var jsonNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// indirect de-references pointers and interfaces. Nil pointers and interfaces are returned as invalid values.
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+
+		value = value.Elem()
+	}
+
+	return value
+}
+
+// kindOf returns the kind of the value in the expression type system.
+func kindOf(value reflect.Value) valueKind {
+	value = indirect(value)
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return kindNull
+	case reflect.Bool:
+		return kindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return kindNumber
+	case reflect.String:
+		return kindString
+	case reflect.Slice, reflect.Array:
+		return kindArray
+	default:
+		return kindObject
+	}
+}
+
+// toNumber coerces the value to a number. null is 0, true is 1 and false is 0. Strings are parsed in JSON number
+// format, empty string is 0 and other strings are NaN. Arrays and objects are NaN.
+func toNumber(value reflect.Value) float64 {
+	value = indirect(value)
+
+	switch kindOf(value) {
+	case kindNull:
+		return 0
+	case kindBool:
+		if value.Bool() {
+			return 1
+		}
+
+		return 0
+	case kindNumber:
+		switch {
+		case value.CanInt():
+			return float64(value.Int())
+		case value.CanUint():
+			return float64(value.Uint())
+		default:
+			return value.Float()
+		}
+	case kindString:
+		s := strings.TrimSpace(value.String())
+
+		if s == "" {
+			return 0
+		}
+
+		if !jsonNumberRe.MatchString(s) {
+			return math.NaN()
+		}
+
+		number, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return math.NaN()
+		}
+
+		return number
+	default:
+		return math.NaN()
+	}
+}
+
+// IsTruthy returns true if the given value is truthy, false otherwise. false, 0, -0, NaN, "" and null are falsy,
+// everything else including arrays and objects is truthy.
+func IsTruthy(input interface{}) bool {
+	value := indirect(reflect.ValueOf(input))
+
+	switch kindOf(value) {
+	case kindNull:
+		return false
+	case kindBool:
+		return value.Bool()
+	case kindNumber:
+		number := toNumber(value)
+		return number != 0 && !math.IsNaN(number)
+	case kindString:
+		return value.String() != ""
+	default:
+		return true
+	}
+}
+
+// looseEqual compares the values for equality. Values of different kinds are coerced to numbers. Strings are compared
+// case-insensitively. Arrays and objects are only equal if they're the same instance.
+func looseEqual(left, right reflect.Value) bool {
+	left, right = indirect(left), indirect(right)
+
+	leftKind, rightKind := kindOf(left), kindOf(right)
+
+	if leftKind != rightKind {
+		// NaN is never equal to anything, so the comparison is false if either side can't be coerced
+		return toNumber(left) == toNumber(right)
+	}
+
+	switch leftKind {
+	case kindNull:
+		return true
+	case kindBool:
+		return left.Bool() == right.Bool()
+	case kindNumber:
+		return toNumber(left) == toNumber(right)
+	case kindString:
+		return strings.EqualFold(left.String(), right.String())
+	default:
+		return sameInstance(left, right)
+	}
+}
+
+// lessThan returns true if the left value is less than the right value. Values of different kinds are coerced to
+// numbers. Strings are compared case-insensitively. Arrays and objects aren't ordered, so it's always false for them.
+func lessThan(left, right reflect.Value) bool {
+	left, right = indirect(left), indirect(right)
+
+	leftKind, rightKind := kindOf(left), kindOf(right)
+
+	if leftKind == kindString && rightKind == kindString {
+		return strings.ToUpper(left.String()) < strings.ToUpper(right.String())
+	}
+
+	if leftKind == rightKind && (leftKind == kindArray || leftKind == kindObject) {
+		return false
+	}
+
+	// comparisons with NaN are always false
+	return toNumber(left) < toNumber(right)
+}
+
+// sameInstance returns true if the arrays or objects are the same instance.
+func sameInstance(left, right reflect.Value) bool {
+	switch left.Kind() {
+	case reflect.Map, reflect.Slice:
+		if left.Kind() != right.Kind() || left.Type() != right.Type() {
+			return false
+		}
+
+		return left.Pointer() == right.Pointer() && (left.Kind() != reflect.Slice || left.Len() == right.Len())
+	default:
+		return false
+	}
+}
+
+// compareValues compares the given values using the specified comparison operator.
+func compareValues(leftValue reflect.Value, rightValue reflect.Value, kind actionlint.CompareOpNodeKind) (interface{}, error) {
+	switch kind {
+	case actionlint.CompareOpNodeKindEq:
+		return looseEqual(leftValue, rightValue), nil
+	case actionlint.CompareOpNodeKindNotEq:
+		return !looseEqual(leftValue, rightValue), nil
+	case actionlint.CompareOpNodeKindLess:
+		return lessThan(leftValue, rightValue), nil
+	case actionlint.CompareOpNodeKindLessEq:
+		return lessThan(leftValue, rightValue) || looseEqual(leftValue, rightValue), nil
+	case actionlint.CompareOpNodeKindGreater:
+		return lessThan(rightValue, leftValue), nil
+	case actionlint.CompareOpNodeKindGreaterEq:
+		return lessThan(rightValue, leftValue) || looseEqual(leftValue, rightValue), nil
+	default:
+		return nil, fmt.Errorf("compare operator '%+v' not supported", kind)
+	}
+}
@@ -0,0 +1,288 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/rhysd/actionlint"
+)
+
+func TestIsTruthy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected bool
+	}{
+		{name: "Null", input: nil, expected: false},
+		{name: "Bool true", input: true, expected: true},
+		{name: "Bool false", input: false, expected: false},
+		{name: "Non-empty string", input: "hello", expected: true},
+		{name: "Empty string", input: "", expected: false},
+		{name: "Non-zero integer", input: 42, expected: true},
+		{name: "Zero integer", input: 0, expected: false},
+		{name: "Zero int64", input: int64(0), expected: false},
+		{name: "Non-zero float", input: 3.14, expected: true},
+		{name: "Zero float", input: 0.0, expected: false},
+		{name: "Negative zero float", input: math.Copysign(0, -1), expected: false},
+		{name: "NaN float", input: math.NaN(), expected: false},
+		{name: "Map", input: map[string]int{"a": 1}, expected: true},
+		{name: "Empty map", input: map[string]int{}, expected: true},
+		{name: "Slice", input: []string{"apple", "banana"}, expected: true},
+		{name: "Struct", input: struct{ Name string }{Name: "John"}, expected: true},
+		{name: "Nil pointer", input: (*struct{})(nil), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := IsTruthy(tc.input)
+			if result != tc.expected {
+				t.Errorf("Incorrect result. Expected: %v, Got: %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected bool
+	}{
+		{name: "Int", value: 10, expected: true},
+		{name: "Float64", value: 3.14, expected: true},
+		{name: "String", value: "123", expected: false},
+		{name: "Bool", value: true, expected: false},
+		{name: "Invalid", value: nil, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := kindOf(reflect.ValueOf(test.value)) == kindNumber
+
+			if result != test.expected {
+				t.Errorf("Unexpected result. Expected: %v, Got: %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCoerceToNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected float64 // NaN is checked with math.IsNaN
+	}{
+		{name: "Invalid", value: nil, expected: 0},
+		{name: "Bool_True", value: true, expected: 1},
+		{name: "Bool_False", value: false, expected: 0},
+		{name: "Int", value: 42, expected: 42},
+		{name: "Float", value: 1.5, expected: 1.5},
+		{name: "String_Empty", value: "", expected: 0},
+		{name: "String_Valid", value: "123.45", expected: 123.45},
+		{name: "String_Exponent", value: "1.5e1", expected: 15},
+		{name: "String_Negative", value: "-1", expected: -1},
+		{name: "String_Whitespace", value: " 12 ", expected: 12},
+		{name: "String_Invalid", value: "hello", expected: math.NaN()},
+		{name: "String_Hex", value: "0x10", expected: math.NaN()},
+		{name: "String_Plus", value: "+1", expected: math.NaN()},
+		{name: "String_Inf", value: "Infinity", expected: math.NaN()},
+		{name: "Array", value: []interface{}{1}, expected: math.NaN()},
+		{name: "Object", value: map[string]interface{}{}, expected: math.NaN()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := toNumber(reflect.ValueOf(test.value))
+
+			if math.IsNaN(test.expected) {
+				if !math.IsNaN(value) {
+					t.Errorf("Expected NaN value, Got: %v", value)
+				}
+
+				return
+			}
+
+			if value != test.expected {
+				t.Errorf("Unexpected value. Expected: %v, Got: %v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	object := map[string]interface{}{"foo": "bar"}
+
+	tests := []struct {
+		name           string
+		leftValue      interface{}
+		rightValue     interface{}
+		kind           actionlint.CompareOpNodeKind
+		expectedResult interface{}
+	}{
+		{name: "Bool_Less", leftValue: true, rightValue: false, kind: actionlint.CompareOpNodeKindLess, expectedResult: false},
+		{name: "String_Eq", leftValue: "abc", rightValue: "abc", kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "String_Eq_IgnoreCase", leftValue: "abc", rightValue: "ABC", kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "String_Less", leftValue: "abc", rightValue: "def", kind: actionlint.CompareOpNodeKindLess, expectedResult: true},
+		{name: "String_Less_IgnoreCase", leftValue: "a", rightValue: "B", kind: actionlint.CompareOpNodeKindLess, expectedResult: true},
+		{name: "String_GreaterEq", leftValue: "def", rightValue: "abc", kind: actionlint.CompareOpNodeKindGreaterEq, expectedResult: true},
+		{name: "String_NotEq", leftValue: "abc", rightValue: "def", kind: actionlint.CompareOpNodeKindNotEq, expectedResult: true},
+		{name: "Int_LessEq", leftValue: 10, rightValue: 20, kind: actionlint.CompareOpNodeKindLessEq, expectedResult: true},
+		{name: "Int_Float_Eq", leftValue: 1, rightValue: 1.0, kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "Float_Less", leftValue: 3.14, rightValue: 2.718, kind: actionlint.CompareOpNodeKindLess, expectedResult: false},
+		{name: "Float_Greater", leftValue: 20.0, rightValue: 10.0, kind: actionlint.CompareOpNodeKindGreater, expectedResult: true},
+		{name: "Invalid_Invalid", leftValue: nil, rightValue: nil, kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "Invalid_String", leftValue: "abc", rightValue: nil, kind: actionlint.CompareOpNodeKindEq, expectedResult: false},
+		{name: "Invalid_Zero", leftValue: nil, rightValue: 0, kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "NaN_NaN", leftValue: math.NaN(), rightValue: math.NaN(), kind: actionlint.CompareOpNodeKindEq, expectedResult: false},
+		{name: "NaN_NotEq", leftValue: math.NaN(), rightValue: math.NaN(), kind: actionlint.CompareOpNodeKindNotEq, expectedResult: true},
+		{name: "NaN_LessEq", leftValue: "abc", rightValue: 1, kind: actionlint.CompareOpNodeKindLessEq, expectedResult: false},
+		{name: "NaN_GreaterEq", leftValue: "abc", rightValue: 1, kind: actionlint.CompareOpNodeKindGreaterEq, expectedResult: false},
+		{name: "Object_SameInstance", leftValue: object, rightValue: object, kind: actionlint.CompareOpNodeKindEq, expectedResult: true},
+		{name: "Object_OtherInstance", leftValue: object, rightValue: map[string]interface{}{"foo": "bar"}, kind: actionlint.CompareOpNodeKindEq, expectedResult: false},
+		{name: "Object_Less", leftValue: object, rightValue: object, kind: actionlint.CompareOpNodeKindLess, expectedResult: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			leftValue := reflect.ValueOf(test.leftValue)
+			rightValue := reflect.ValueOf(test.rightValue)
+
+			result, err := compareValues(leftValue, rightValue, test.kind)
+
+			if result != test.expectedResult {
+				t.Errorf("Unexpected result. Expected: %v, Got: %v", test.expectedResult, result)
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		left     interface{}
+		right    interface{}
+		kind     actionlint.CompareOpNodeKind
+		expected bool
+	}{
+		{name: "Less_Int", left: float64(10), right: float64(20), kind: actionlint.CompareOpNodeKindLess, expected: true},
+		{name: "LessEq_Int", left: float64(10), right: float64(20), kind: actionlint.CompareOpNodeKindLessEq, expected: true},
+		{name: "Greater_Int", left: float64(20), right: float64(10), kind: actionlint.CompareOpNodeKindGreater, expected: true},
+		{name: "GreaterEq_Int", left: float64(20), right: float64(10), kind: actionlint.CompareOpNodeKindGreaterEq, expected: true},
+		{name: "Eq_Int", left: float64(10), right: float64(10), kind: actionlint.CompareOpNodeKindEq, expected: true},
+		{name: "NotEq_Int", left: float64(10), right: float64(20), kind: actionlint.CompareOpNodeKindNotEq, expected: true},
+		{name: "Less_String", left: "abc", right: "def", kind: actionlint.CompareOpNodeKindLess, expected: true},
+		{name: "LessEq_String", left: "abc", right: "def", kind: actionlint.CompareOpNodeKindLessEq, expected: true},
+		{name: "Greater_String", left: "def", right: "abc", kind: actionlint.CompareOpNodeKindGreater, expected: true},
+		{name: "GreaterEq_String", left: "def", right: "abc", kind: actionlint.CompareOpNodeKindGreaterEq, expected: true},
+		{name: "Eq_String", left: "abc", right: "abc", kind: actionlint.CompareOpNodeKindEq, expected: true},
+		{name: "NotEq_String", left: "abc", right: "def", kind: actionlint.CompareOpNodeKindNotEq, expected: true},
+		{name: "False_Int", left: float64(20), right: float64(10), kind: actionlint.CompareOpNodeKindLess, expected: false},
+		{name: "False_String", left: "def", right: "abc", kind: actionlint.CompareOpNodeKindLess, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := compareValues(reflect.ValueOf(test.left), reflect.ValueOf(test.right), test.kind)
+			if err != nil {
+				t.Errorf("Error comparing values: %v", err)
+			}
+
+			if result != test.expected {
+				t.Errorf("Comparison result mismatch. Expected: %v, Got: %v", test.expected, result)
+			}
+		})
+	}
+}
+
+// TestExpression_Conformance evaluates the examples from the expression documentation, see:
+// https://docs.github.com/en/actions/learn-github-actions/expressions
+func TestExpression_Conformance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// literals and truthiness
+		{"null", nil},
+		{"!null", true},
+		{"!0", true},
+		{"!''", true},
+		{"!fromJSON('[]')", false},
+		{"!obj", false},
+
+		// null is coerced to 0, booleans to 1 and 0, strings in JSON number format, others to NaN
+		{"null == 0", true},
+		{"null == ''", true},
+		{"null == false", true},
+		{"true == 1", true},
+		{"false == 0", true},
+		{"'' == 0", true},
+		{"'1.5e1' == 15", true},
+		{"'0x10' == 16", false},
+		{"'abc' == 0", false},
+		{"fromJSON('[]') == 0", false},
+		{"obj == 0", false},
+
+		// strings are compared case-insensitively
+		{"'Hello' == 'hello'", true},
+		{"'Hello' != 'HELLO'", false},
+		{"'a' < 'B'", true},
+		{"'B' <= 'b'", true},
+
+		// NaN in a relational comparison is always false
+		{"'abc' < 1", false},
+		{"'abc' >= 1", false},
+		{"fromJSON('{}') > 0", false},
+
+		// objects and arrays are only equal if they're the same instance
+		{"obj == obj", true},
+		{"fromJSON('{}') == fromJSON('{}')", false},
+
+		// logical operators return the operand and short-circuit
+		{"'a' && 'b'", "b"},
+		{"'' && 'b'", ""},
+		{"'a' || 'b'", "a"},
+		{"null || 'default'", "default"},
+		{"'' && fromJSON('')", ""},
+		{"'a' || fromJSON('')", "a"},
+		{"false && missing.property", false},
+		{"true || missing.property", true},
+	}
+
+	provider := &conformanceProvider{obj: map[string]interface{}{"foo": "bar"}}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result, err := expr.Evaluate(provider)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if tt.expected != result {
+				t.Errorf("Expected %v, but got %v for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+type conformanceProvider struct {
+	obj map[string]interface{}
+}
+
+func (p *conformanceProvider) GetVariable(name string) (interface{}, error) {
+	if name == "obj" {
+		return p.obj, nil
+	}
+
+	return nil, fmt.Errorf("variable %s not found", name)
+}
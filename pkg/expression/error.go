@@ -0,0 +1,116 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is an error in an expression. It keeps the input containing the expression and the offset of the error in
+// the input to show the error with its context. The position of the input in its source file is optional and set by
+// the callers knowing the source, e.g. the runner for the values in the workflow file.
+type Error struct {
+	File   string // File is the path of the source file of the input. Empty if unknown.
+	Line   int    // Line is the line of the input in the source file. Starts at 1, 0 if unknown.
+	Col    int    // Col is the column of the input in the source file. Starts at 1, 0 if unknown.
+	Input  string // Input is the string containing the expression.
+	Offset int    // Offset is the byte offset of the error in the input.
+	Err    error  // Err is the underlying error.
+}
+
+// Error returns the error message with the position prefix and the source context, e.g.
+//
+//	.github/workflows/ci.yaml:12:9: function 'foo' not supported
+//	  ${{ foo('bar') }}
+//	      ^
+func (e *Error) Error() string {
+	if pos := e.Position(); pos != "" {
+		return fmt.Sprintf("%s: %s", pos, e.Message())
+	}
+
+	return e.Message()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Message returns the error message with the source context without the position prefix.
+func (e *Error) Message() string {
+	if snippet := e.Snippet(); snippet != "" {
+		return fmt.Sprintf("%s\n%s", e.Err.Error(), snippet)
+	}
+
+	return e.Err.Error()
+}
+
+// Position returns the position of the input in the source file in `file:line:col` format. Unknown parts are omitted
+// and an empty string is returned if the position is unknown.
+func (e *Error) Position() string {
+	var parts []string
+
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+
+	if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("%d", e.Line))
+
+		if e.Col > 0 {
+			parts = append(parts, fmt.Sprintf("%d", e.Col))
+		}
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// Snippet returns the line of the input containing the error and a caret marker pointing to the error. It returns an
+// empty string if the input is empty.
+func (e *Error) Snippet() string {
+	if e.Input == "" {
+		return ""
+	}
+
+	offset := e.Offset
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset > len(e.Input) {
+		offset = len(e.Input)
+	}
+
+	// only show the line containing the error for multi-line inputs, e.g. run scripts
+	start := strings.LastIndexByte(e.Input[:offset], '\n') + 1
+
+	end := strings.IndexByte(e.Input[offset:], '\n')
+	if end < 0 {
+		end = len(e.Input)
+	} else {
+		end += offset
+	}
+
+	line := e.Input[start:end]
+
+	// keep tabs in the indentation of the caret, so the caret is aligned with the error in the line
+	indent := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+
+		return ' '
+	}, e.Input[start:offset])
+
+	return fmt.Sprintf("  %s\n  %s^", line, indent)
+}
+
+// errorAt returns the error as an Error at the given offset. Errors that are already an Error are returned as is, so
+// the position of the innermost node is kept.
+func errorAt(offset int, err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+
+	return &Error{Offset: offset, Err: err}
+}
@@ -0,0 +1,117 @@
+package expression
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestError_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *Error
+		expected string
+	}{
+		{
+			name:     "Without input",
+			err:      &Error{Err: errors.New("boom")},
+			expected: "boom",
+		},
+		{
+			name:     "With input",
+			err:      &Error{Input: "${{ foo }}", Offset: 4, Err: errors.New("boom")},
+			expected: "boom\n  ${{ foo }}\n      ^",
+		},
+		{
+			name:     "With position",
+			err:      &Error{File: "ci.yaml", Line: 3, Col: 9, Input: "${{ foo }}", Offset: 4, Err: errors.New("boom")},
+			expected: "ci.yaml:3:9: boom\n  ${{ foo }}\n      ^",
+		},
+		{
+			name:     "Line without file",
+			err:      &Error{Line: 3, Err: errors.New("boom")},
+			expected: "3: boom",
+		},
+		{
+			name:     "Multi-line input",
+			err:      &Error{Input: "echo foo\n\techo ${{ bar }}\necho baz", Offset: 19, Err: errors.New("boom")},
+			expected: "boom\n  \techo ${{ bar }}\n  \t         ^",
+		},
+		{
+			name:     "Offset out of range",
+			err:      &Error{Input: "foo", Offset: 10, Err: errors.New("boom")},
+			expected: "boom\n  foo\n     ^",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.err.Error(); result != test.expected {
+				t.Errorf("Unexpected error message. Expected: %q, Got: %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestError_Offset(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		parse  bool // parse with ParseExpressions instead of NewExpression
+		offset int
+		msg    string
+	}{
+		{name: "Parse error", input: "${{ github.ref == }}", offset: 18},
+		{name: "Parse error in condition", input: "  foo(", offset: 6},
+		{name: "Parse error in string", input: "echo ${{ 'a' + }}", parse: true, offset: 13},
+		{name: "Status function in string", input: "echo ${{ always() }}", parse: true, offset: 9, msg: "function 'always' is only allowed in if conditionals"},
+		{name: "Unsupported function", input: "${{ 'a' == foo('b') }}", offset: 11, msg: "function 'foo' not supported"},
+		{name: "Function error", input: "echo ${{ contains('a') }}", parse: true, offset: 9, msg: "contains() requires two arguments"},
+		{name: "Nested function error", input: "${{ 'a' && join(contains('b')) }}", offset: 16},
+		{name: "Variable error", input: "echo ${{ 'a' }} ${{ missing.foo }}", parse: true, offset: 20, msg: "variable missing not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var err error
+
+			if test.parse {
+				var exprs []*Expression
+
+				exprs, err = ParseExpressions(test.input)
+
+				for _, expr := range exprs {
+					if err != nil {
+						break
+					}
+
+					_, err = expr.Evaluate(&conformanceProvider{})
+				}
+			} else {
+				var expr *Expression
+
+				expr, err = NewExpression(test.input)
+				if err == nil {
+					_, err = expr.Evaluate(&conformanceProvider{})
+				}
+			}
+
+			var exprErr *Error
+
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Expected expression error, but got %v", err)
+			}
+
+			if exprErr.Input != test.input {
+				t.Errorf("Unexpected input. Expected: %q, Got: %q", test.input, exprErr.Input)
+			}
+
+			if exprErr.Offset != test.offset {
+				t.Errorf("Unexpected offset. Expected: %d, Got: %d\n%s", test.offset, exprErr.Offset, exprErr.Error())
+			}
+
+			if test.msg != "" && exprErr.Err.Error() != test.msg {
+				t.Errorf("Unexpected message. Expected: %q, Got: %q", test.msg, exprErr.Err.Error())
+			}
+		})
+	}
+}
@@ -2,14 +2,17 @@ package expression
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/rhysd/actionlint"
 )
 
-//goland:noinspection RegExpRedundantEscape // just to make it more readable
-var exprRe = regexp.MustCompile(`\$\{\{[^{}]+\}\}`)
+// exprStart and exprEnd are the markers of the expression syntax (${{ }}).
+const (
+	exprStart = "${{"
+	exprEnd   = "}}"
+)
 
 // VariableProvider is an interface to provide variable values for expression evaluation.
 type VariableProvider interface {
@@ -26,10 +29,13 @@ type Interpreter interface {
 
 // Expression represents a GitHub expression in a string with position.
 type Expression struct {
-	Value       string      // Value is a raw value of the string.
-	StartIndex  int         // StartIndex is a start index of the expression in the source string.
-	EndIndex    int         // EndIndex is an end index of the expression in the source string.
-	interpreter Interpreter // interpreter is an interpreter for the expression.
+	Value       string              // Value is a raw value of the string.
+	StartIndex  int                 // StartIndex is a start index of the expression in the source string.
+	EndIndex    int                 // EndIndex is an end index of the expression in the source string.
+	interpreter Interpreter         // interpreter is an interpreter for the expression.
+	node        actionlint.ExprNode // node is the parsed expression.
+	input       string              // input is the source string, used to show errors with their context.
+	offset      int                 // offset is the offset of the parsed expression body in the input.
 }
 
 // NewExpression parses a string and returns an Expression.
@@ -37,72 +43,206 @@ type Expression struct {
 // The method assumes that the string contains an expression. If the string omits the expression syntax (${{ }}). It
 // will be added to the string automatically and parsed.
 func NewExpression(value string) (*Expression, error) {
-	matches := exprRe.FindStringSubmatch(value)
+	input := value
 
-	// If there are no matches, then the string omits the expression syntax (${{ }}). This is valid for if conditionals.
-	// to normalize the string, and make it consistent, we add the expression syntax to the string.
+	// If the string doesn't start with the expression syntax (${{ }}), then the string omits it. This is valid for if
+	// conditionals. To normalize the string, and make it consistent, we add the expression syntax to the string.
 	//
 	// source: https://docs.github.com/en/actions/learn-github-actions/expressions#about-expressions
-	if len(matches) == 0 {
-		value = strings.TrimSpace(value)
-		value = fmt.Sprintf("${{%s}}", value)
-	}
+	trimmed := strings.TrimSpace(value)
+	leading := len(value) - len(strings.TrimLeftFunc(value, unicode.IsSpace))
 
-	lexer := actionlint.NewExprLexer(strings.TrimPrefix(value, "${{"))
+	if !strings.HasPrefix(trimmed, exprStart) {
+		value = fmt.Sprintf("%s%s%s", exprStart, trimmed, exprEnd)
 
-	parser := actionlint.NewExprParser()
+		node, end, err := scanExpression(value, 0)
+		if err != nil {
+			// the body of the added syntax starts at the first non-space character of the input
+			return nil, parseError(input, leading, err)
+		}
+
+		return newExpression(value[:end], 0, node, input, leading), nil
+	}
 
-	node, err := parser.Parse(lexer)
+	node, end, err := scanExpression(value, leading)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expression: %w", err)
+		return nil, parseError(input, leading+len(exprStart), err)
 	}
 
-	return &Expression{
-		Value:       value,
-		StartIndex:  0,
-		EndIndex:    len(value) - 1,
-		interpreter: getInterpreterFromNode(node),
-	}, nil
+	return newExpression(value[leading:end], 0, node, input, leading+len(exprStart)), nil
 }
 
 // ParseExpressions parses a string and returns a slice of Expressions with their start and end indexes in input string.
 //
 // The method strictly checks expressions syntax. If the string omits the expression syntax (${{ }}), it will be
-// considered as an regular string.
+// considered as an regular string. Status check functions are only allowed in if conditionals, so they're rejected
+// in the string.
+//
+// Expressions are found by lexing them instead of matching their markers, so `}}` in string literals, e.g.
+// ${{ format('{{0}}', 'foo') }}, doesn't end the expression.
 //
 // If the string not contains any expressions, the method will return an empty slice. If the string contains invalid
 // expressions, the method will return an error.
 func ParseExpressions(input string) ([]*Expression, error) {
 	expressions := make([]*Expression, 0)
 
-	parser := actionlint.NewExprParser()
-
-	matches := exprRe.FindAllStringIndex(input, -1)
+	for offset := 0; offset < len(input); {
+		idx := strings.Index(input[offset:], exprStart)
+		if idx < 0 {
+			break
+		}
 
-	for _, match := range matches {
-		value := input[match[0]:match[1]]
+		start := offset + idx
 
-		lexer := actionlint.NewExprLexer(strings.TrimPrefix(value, "${{"))
+		// unclosed expression syntax is kept as a regular string
+		if !strings.Contains(input[start:], exprEnd) {
+			break
+		}
 
-		node, expErr := parser.Parse(lexer)
-		if expErr != nil {
-			return nil, fmt.Errorf("failed to parse expression: %w", expErr)
+		node, end, err := scanExpression(input, start)
+		if err != nil {
+			return nil, parseError(input, start+len(exprStart), err)
 		}
 
-		expression := Expression{
-			Value:       value,
-			StartIndex:  match[0],
-			EndIndex:    match[1] - 1,
-			interpreter: getInterpreterFromNode(node),
+		if err := checkStatusFunctions(node); err != nil {
+			return nil, withInput(err, input, start+len(exprStart))
 		}
 
-		expressions = append(expressions, &expression)
+		expressions = append(expressions, newExpression(input[start:end], start, node, input, start+len(exprStart)))
+
+		offset = end
 	}
 
 	return expressions, nil
 }
 
+// scanExpression parses the expression starting with the expression syntax at the start of the input. It returns the
+// parsed node and the end of the expression in the input, which is the index after the closing }}. Offset of the
+// parse error is relative to the expression body after ${{.
+func scanExpression(input string, start int) (actionlint.ExprNode, int, *actionlint.ExprError) {
+	body := start + len(exprStart)
+
+	lexer := actionlint.NewExprLexer(input[body:])
+
+	node, err := actionlint.NewExprParser().Parse(lexer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return node, body + lexer.Offset(), nil
+}
+
+// newExpression creates an Expression from the parsed node. The value is the expression with the expression syntax
+// and the start is the index of the value in the input.
+func newExpression(value string, start int, node actionlint.ExprNode, input string, offset int) *Expression {
+	return &Expression{
+		Value:       value,
+		StartIndex:  start,
+		EndIndex:    start + len(value) - 1,
+		interpreter: getInterpreterFromNode(node),
+		node:        node,
+		input:       input,
+		offset:      offset,
+	}
+}
+
+// statusFunctions is the list of status check functions. They're only allowed in if conditionals.
+var statusFunctions = map[string]bool{"success": true, "failure": true, "cancelled": true, "always": true}
+
+// checkStatusFunctions returns an error if the expression calls a status check function.
+func checkStatusFunctions(node actionlint.ExprNode) error {
+	if call := findStatusFunction(node); call != nil {
+		return errorAt(call.Token().Offset, fmt.Errorf("function '%s' is only allowed in if conditionals", call.Callee))
+	}
+
+	return nil
+}
+
+// findStatusFunction returns the first call of a status check function in the expression, nil if there is none.
+func findStatusFunction(node actionlint.ExprNode) *actionlint.FuncCallNode {
+	var found *actionlint.FuncCallNode
+
+	actionlint.VisitExprNode(node, func(n, _ actionlint.ExprNode, entering bool) {
+		call, ok := n.(*actionlint.FuncCallNode)
+		if !ok || !entering || found != nil {
+			return
+		}
+
+		if statusFunctions[strings.ToLower(call.Callee)] {
+			found = call
+		}
+	})
+
+	return found
+}
+
+// CallsStatusFunction returns true if the expression calls a status check function, e.g. always(). Names in string
+// literals are not calls, e.g. contains(github.event.head_commit.message, 'failure()').
+func (e *Expression) CallsStatusFunction() bool {
+	return findStatusFunction(e.node) != nil
+}
+
 // Evaluate evaluates the expression and returns the result.
 func (e *Expression) Evaluate(provider VariableProvider) (interface{}, error) {
-	return e.interpreter.Evaluate(provider)
+	val, err := e.interpreter.Evaluate(provider)
+	if err != nil {
+		return nil, withInput(err, e.input, e.offset)
+	}
+
+	return val, nil
+}
+
+// Interpolate evaluates the expressions in the input and replaces each of them with its value converted to a string.
+// Values are converted with the same rules as GitHub, e.g. null is an empty string, numbers are written without
+// trailing zeros and objects are written as Object. Expressions are replaced at their positions in the input, so the
+// same expression used more than once is replaced at each position.
+func Interpolate(input string, provider VariableProvider) (string, error) {
+	exprs, err := ParseExpressions(input)
+	if err != nil {
+		return "", err
+	}
+
+	if len(exprs) == 0 {
+		return input, nil
+	}
+
+	var sb strings.Builder
+
+	last := 0
+
+	for _, expr := range exprs {
+		val, err := expr.Evaluate(provider)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(input[last:expr.StartIndex])
+		sb.WriteString(toString(val))
+
+		last = expr.EndIndex + 1
+	}
+
+	sb.WriteString(input[last:])
+
+	return sb.String(), nil
+}
+
+// parseError converts the parse error to an Error. The offset is the offset of the parsed expression body in the input.
+func parseError(input string, offset int, err *actionlint.ExprError) error {
+	return &Error{
+		Input:  input,
+		Offset: offset + err.Offset,
+		Err:    fmt.Errorf("failed to parse expression: %s", err.Message),
+	}
+}
+
+// withInput returns the error as an Error with the given input. The offset of the error is relative to the parsed
+// expression body, so it's shifted by the offset of the body in the input.
+func withInput(err error, input string, offset int) error {
+	exprErr, ok := err.(*Error)
+	if !ok {
+		return &Error{Input: input, Offset: offset, Err: err}
+	}
+
+	return &Error{Input: input, Offset: offset + exprErr.Offset, Err: exprErr.Err}
 }
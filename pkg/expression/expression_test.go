@@ -5,6 +5,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -24,6 +25,11 @@ func TestNewExpression(t *testing.T) {
 			value:    "${{foobar}}",
 			expected: &Expression{Value: "${{foobar}}", StartIndex: 0, EndIndex: 10},
 		},
+		{
+			name:     "expression with surrounding spaces",
+			value:    "  ${{ foobar }}\n",
+			expected: &Expression{Value: "${{ foobar }}", StartIndex: 0, EndIndex: 12},
+		},
 		{
 			name:     "invalid expression",
 			value:    "${{ invalid expression }",
@@ -90,6 +96,14 @@ func TestParseExpressions(t *testing.T) {
 				{Value: "${{ second }}", StartIndex: 17, EndIndex: 29},
 			},
 		},
+		{
+			name:  "braces in string literals",
+			input: "a ${{ format('{0}}', '}}') }} b ${{ c }}",
+			expected: []*Expression{
+				{Value: "${{ format('{0}}', '}}') }}", StartIndex: 2, EndIndex: 28},
+				{Value: "${{ c }}", StartIndex: 32, EndIndex: 39},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -128,12 +142,12 @@ func TestExpression_EvaluateLiterals(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"integer literal", "123", 123},
+		{"integer literal", "123", float64(123)},
 		{"float literal", "123.456", 123.456},
 		{"boolean literal true", "true", true},
 		{"boolean literal false", "false", false},
 		{"null literal", "null", nil},
-		{"hexadecimal literal", "0xff", 255},
+		{"hexadecimal literal", "0xff", float64(255)},
 		{"exponential literal", "1e3", float64(1000)},
 		{"exponential literal with negative sign", "1e-3", 0.001},
 		{"string literal", "'foobar'", "foobar"},
@@ -224,17 +238,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"true && true", "true && true", true},
 		{"true && false", "true && false", false},
 		{"true && null", "true && null", nil},
-		{"true && 1", "true && 1", 1},
-		{"true && 0", "true && 0", 0},
+		{"true && 1", "true && 1", float64(1)},
+		{"true && 0", "true && 0", float64(0)},
 		{"true && ''", "true && ''", ""},
 		{"true && 'foo'", "true && 'foo'", "foo"},
 		{"true && '0'", "true && '0'", "0"},
 		{"true && '1'", "true && '1'", "1"},
 		{"true && 'true'", "true && 'true'", "true"},
-		{"true && 10", "true && 10", 10},
+		{"true && 10", "true && 10", float64(10)},
 		{"true && 0.1", "true && 0.1", 0.1},
-		{"true && -1", "true && -1", -1},
-		{"true && 0.0", "true && 0.0", 0},
+		{"true && -1", "true && -1", float64(-1)},
+		{"true && 0.0", "true && 0.0", float64(0)},
 		{"true && Infinity", "true && Infinity", math.Inf(1)},
 		{"true && NaN", "true && NaN", math.NaN()},
 
@@ -278,17 +292,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"false || true", "false || true", true},
 		{"false || false", "false || false", false},
 		{"false || null", "false || null", nil},
-		{"false || 1", "false || 1", 1},
-		{"false || 0", "false || 0", 0},
+		{"false || 1", "false || 1", float64(1)},
+		{"false || 0", "false || 0", float64(0)},
 		{"false || ''", "false || ''", ""},
 		{"false || 'foo'", "false || 'foo'", "foo"},
 		{"false || '0'", "false || '0'", "0"},
 		{"false || '1'", "false || '1'", "1"},
 		{"false || 'true'", "false || 'true'", "true"},
-		{"false || 10", "false || 10", 10},
+		{"false || 10", "false || 10", float64(10)},
 		{"false || 0.1", "false || 0.1", 0.1},
-		{"false || -1", "false || -1", -1},
-		{"false || 0.0", "false || 0.0", 0},
+		{"false || -1", "false || -1", float64(-1)},
+		{"false || 0.0", "false || 0.0", float64(0)},
 		{"false || Infinity", "false || Infinity", math.Inf(1)},
 		{"false || NaN", "false || NaN", math.NaN()},
 
@@ -314,17 +328,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"null || true", "null || true", true},
 		{"null || false", "null || false", false},
 		{"null || null", "null || null", nil},
-		{"null || 1", "null || 1", 1},
-		{"null || 0", "null || 0", 0},
+		{"null || 1", "null || 1", float64(1)},
+		{"null || 0", "null || 0", float64(0)},
 		{"null || ''", "null || ''", ""},
 		{"null || 'foo'", "null || 'foo'", "foo"},
 		{"null || '0'", "null || '0'", "0"},
 		{"null || '1'", "null || '1'", "1"},
 		{"null || 'true'", "null || 'true'", "true"},
-		{"null || 10", "null || 10", 10},
+		{"null || 10", "null || 10", float64(10)},
 		{"null || 0.1", "null || 0.1", 0.1},
-		{"null || -1", "null || -1", -1},
-		{"null || 0.0", "null || 0.0", 0},
+		{"null || -1", "null || -1", float64(-1)},
+		{"null || 0.0", "null || 0.0", float64(0)},
 		{"null || Infinity", "null || Infinity", math.Inf(1)},
 		{"null || NaN", "null || NaN", math.NaN()},
 
@@ -333,50 +347,50 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"1 && true", "1 && true", true},
 		{"1 && false", "1 && false", false},
 		{"1 && null", "1 && null", nil},
-		{"1 && 1", "1 && 1", 1},
-		{"1 && 0", "1 && 0", 0},
+		{"1 && 1", "1 && 1", float64(1)},
+		{"1 && 0", "1 && 0", float64(0)},
 		{"1 && ''", "1 && ''", ""},
 		{"1 && 'foo'", "1 && 'foo'", "foo"},
 		{"1 && '0'", "1 && '0'", "0"},
 		{"1 && '1'", "1 && '1'", "1"},
 		{"1 && 'true'", "1 && 'true'", "true"},
-		{"1 && 10", "1 && 10", 10},
+		{"1 && 10", "1 && 10", float64(10)},
 		{"1 && 0.1", "1 && 0.1", 0.1},
-		{"1 && -1", "1 && -1", -1},
-		{"1 && 0.0", "1 && 0.0", 0},
+		{"1 && -1", "1 && -1", float64(-1)},
+		{"1 && 0.0", "1 && 0.0", float64(0)},
 		{"1 && Infinity", "1 && Infinity", math.Inf(1)},
 		{"1 && NaN", "1 && NaN", math.NaN()},
 
 		// Logical OR - numeric
-		{"1 || true", "1 || true", 1},
-		{"1 || false", "1 || false", 1},
-		{"1 || null", "1 || null", 1},
-		{"1 || 1", "1 || 1", 1},
-		{"1 || 0", "1 || 0", 1},
-		{"1 || ''", "1 || ''", 1},
-		{"1 || 'foo'", "1 || 'foo'", 1},
-		{"1 || '0'", "1 || '0'", 1},
-		{"1 || '1'", "1 || '1'", 1},
-		{"1 || 'true'", "1 || 'true'", 1},
-		{"1 || 10", "1 || 10", 1},
-		{"1 || 0.1", "1 || 0.1", 1},
-		{"1 || -1", "1 || -1", 1},
+		{"1 || true", "1 || true", float64(1)},
+		{"1 || false", "1 || false", float64(1)},
+		{"1 || null", "1 || null", float64(1)},
+		{"1 || 1", "1 || 1", float64(1)},
+		{"1 || 0", "1 || 0", float64(1)},
+		{"1 || ''", "1 || ''", float64(1)},
+		{"1 || 'foo'", "1 || 'foo'", float64(1)},
+		{"1 || '0'", "1 || '0'", float64(1)},
+		{"1 || '1'", "1 || '1'", float64(1)},
+		{"1 || 'true'", "1 || 'true'", float64(1)},
+		{"1 || 10", "1 || 10", float64(1)},
+		{"1 || 0.1", "1 || 0.1", float64(1)},
+		{"1 || -1", "1 || -1", float64(1)},
 
 		// Logical AND - string
 		{"'foo' && true", "'foo' && true", true},
 		{"'foo' && false", "'foo' && false", false},
 		{"'foo' && null", "'foo' && null", nil},
-		{"'foo' && 1", "'foo' && 1", 1},
-		{"'foo' && 0", "'foo' && 0", 0},
+		{"'foo' && 1", "'foo' && 1", float64(1)},
+		{"'foo' && 0", "'foo' && 0", float64(0)},
 		{"'foo' && ''", "'foo' && ''", ""},
 		{"'foo' && 'foo'", "'foo' && 'foo'", "foo"},
 		{"'foo' && '0'", "'foo' && '0'", "0"},
 		{"'foo' && '1'", "'foo' && '1'", "1"},
 		{"'foo' && 'true'", "'foo' && 'true'", "true"},
-		{"'foo' && 10", "'foo' && 10", 10},
+		{"'foo' && 10", "'foo' && 10", float64(10)},
 		{"'foo' && 0.1", "'foo' && 0.1", 0.1},
-		{"'foo' && -1", "'foo' && -1", -1},
-		{"'foo' && 0.0", "'foo' && 0.0", 0},
+		{"'foo' && -1", "'foo' && -1", float64(-1)},
+		{"'foo' && 0.0", "'foo' && 0.0", float64(0)},
 		{"'foo' && Infinity", "'foo' && Infinity", math.Inf(1)},
 		{"'foo' && NaN", "'foo' && NaN", math.NaN()},
 
@@ -403,17 +417,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"0.1 && true", "0.1 && true", true},
 		{"0.1 && false", "0.1 && false", false},
 		{"0.1 && null", "0.1 && null", nil},
-		{"0.1 && 1", "0.1 && 1", 1},
-		{"0.1 && 0", "0.1 && 0", 0},
+		{"0.1 && 1", "0.1 && 1", float64(1)},
+		{"0.1 && 0", "0.1 && 0", float64(0)},
 		{"0.1 && ''", "0.1 && ''", ""},
 		{"0.1 && 'foo'", "0.1 && 'foo'", "foo"},
 		{"0.1 && '0'", "0.1 && '0'", "0"},
 		{"0.1 && '1'", "0.1 && '1'", "1"},
 		{"0.1 && 'true'", "0.1 && 'true'", "true"},
-		{"0.1 && 10", "0.1 && 10", 10},
+		{"0.1 && 10", "0.1 && 10", float64(10)},
 		{"0.1 && 0.1", "0.1 && 0.1", 0.1},
-		{"0.1 && -1", "0.1 && -1", -1},
-		{"0.1 && 0.0", "0.1 && 0.0", 0},
+		{"0.1 && -1", "0.1 && -1", float64(-1)},
+		{"0.1 && 0.0", "0.1 && 0.0", float64(0)},
 		{"0.1 && Infinity", "0.1 && Infinity", math.Inf(1)},
 		{"0.1 && NaN", "0.1 && NaN", math.NaN()},
 
@@ -441,17 +455,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"Infinity && true", "Infinity && true", true},
 		{"Infinity && false", "Infinity && false", false},
 		{"Infinity && null", "Infinity && null", nil},
-		{"Infinity && 1", "Infinity && 1", 1},
-		{"Infinity && 0", "Infinity && 0", 0},
+		{"Infinity && 1", "Infinity && 1", float64(1)},
+		{"Infinity && 0", "Infinity && 0", float64(0)},
 		{"Infinity && ''", "Infinity && ''", ""},
 		{"Infinity && 'foo'", "Infinity && 'foo'", "foo"},
 		{"Infinity && '0'", "Infinity && '0'", "0"},
 		{"Infinity && '1'", "Infinity && '1'", "1"},
 		{"Infinity && 'true'", "Infinity && 'true'", "true"},
-		{"Infinity && 10", "Infinity && 10", 10},
+		{"Infinity && 10", "Infinity && 10", float64(10)},
 		{"Infinity && 0.1", "Infinity && 0.1", 0.1},
-		{"Infinity && -1", "Infinity && -1", -1},
-		{"Infinity && 0.0", "Infinity && 0.0", 0},
+		{"Infinity && -1", "Infinity && -1", float64(-1)},
+		{"Infinity && 0.0", "Infinity && 0.0", float64(0)},
 		{"Infinity && Infinity", "Infinity && Infinity", math.Inf(1)},
 		{"Infinity && NaN", "Infinity && NaN", math.NaN()},
 
@@ -498,16 +512,16 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"NaN || true", "NaN || true", true},
 		{"NaN || false", "NaN || false", false},
 		{"NaN || null", "NaN || null", nil},
-		{"NaN || 1", "NaN || 1", 1},
-		{"NaN || 0", "NaN || 0", 0},
+		{"NaN || 1", "NaN || 1", float64(1)},
+		{"NaN || 0", "NaN || 0", float64(0)},
 		{"NaN || ''", "NaN || ''", ""},
 		{"NaN || 'foo'", "NaN || 'foo'", "foo"},
 		{"NaN || '0'", "NaN || '0'", "0"},
 		{"NaN || '1'", "NaN || '1'", "1"},
-		{"NaN || 10", "NaN || 10", 10},
+		{"NaN || 10", "NaN || 10", float64(10)},
 		{"NaN || 0.1", "NaN || 0.1", 0.1},
-		{"NaN || -1", "NaN || -1", -1},
-		{"NaN || 0.0", "NaN || 0.0", 0},
+		{"NaN || -1", "NaN || -1", float64(-1)},
+		{"NaN || 0.0", "NaN || 0.0", float64(0)},
 		{"NaN || Infinity", "NaN || Infinity", math.Inf(1)},
 		{"NaN || NaN", "NaN || NaN", math.NaN()},
 
@@ -535,17 +549,17 @@ func TestExpression_EvaluateLogicalOp(t *testing.T) {
 		{"'' || true", "'' || true", true},
 		{"'' || false", "'' || false", false},
 		{"'' || null", "'' || null", nil},
-		{"'' || 1", "'' || 1", 1},
-		{"'' || 0", "'' || 0", 0},
+		{"'' || 1", "'' || 1", float64(1)},
+		{"'' || 0", "'' || 0", float64(0)},
 		{"'' || ''", "'' || ''", ""},
 		{"'' || 'foo'", "'' || 'foo'", "foo"},
 		{"'' || '0'", "'' || '0'", "0"},
 		{"'' || '1'", "'' || '1'", "1"},
 		{"'' || 'true'", "'' || 'true'", "true"},
-		{"'' || 10", "'' || 10", 10},
+		{"'' || 10", "'' || 10", float64(10)},
 		{"'' || 0.1", "'' || 0.1", 0.1},
-		{"'' || -1", "'' || -1", -1},
-		{"'' || 0.0", "'' || 0.0", 0},
+		{"'' || -1", "'' || -1", float64(-1)},
+		{"'' || 0.0", "'' || 0.0", float64(0)},
 		{"'' || Infinity", "'' || Infinity", math.Inf(1)},
 		{"'' || NaN", "'' || NaN", math.NaN()},
 	}
@@ -586,6 +600,14 @@ func TestExpression_EvaluateContexts(t *testing.T) {
 		{"foo.nested.slice[2].foo", "foo.nested.slice[2].foo", "qux"},
 		{"foo.nested.slice[3].foo", "foo.nested.slice[3].foo", nil},
 		{"foo.nested.slice[0].bar", "foo.nested.slice[0].bar", nil},
+		{"case insensitive property", "FOO.Nested.SOME", "value"},
+		{"case insensitive index", "foo['NESTED']['Some']", "value"},
+		{"property of array without filter", "foo.nested.slice.foo", nil},
+		{"filter property", "join(foo.nested.slice.*.foo, ',')", "bar,baz,qux"},
+		{"nested filters", "join(foo.nested.*.*.foo, ',')", "bar,baz,qux"},
+		{"filter index", "join(foo.nested.slice.*['foo'], ',')", "bar,baz,qux"},
+		{"filter of object values", "toJson(foo.nested.slice[0].*)", "[\n  \"bar\"\n]"},
+		{"filter of scalar", "toJson(foo.bar.*)", "[]"},
 	}
 
 	for _, tt := range tests {
@@ -631,8 +653,21 @@ func TestExpression_EvaluateFunctions(t *testing.T) {
 		{"toJson string", "toJson('foo')", "\"foo\""},
 		{"toJson number", "toJson(1)", "1"},
 		{"toJson boolean", "toJson(true)", "true"},
-		{"toJson slice", "toJson(foo.nested.slice.*.foo)", "[\"bar\",\"baz\",\"qux\"]"},
-		{"toJson map", "toJson(foo.nested)", "{\"slice\":[{\"foo\":\"bar\"},{\"foo\":\"baz\"},{\"foo\":\"qux\"}],\"some\":\"value\"}"},
+		{"toJson slice", "toJson(foo.nested.slice.*.foo)", "[\n  \"bar\",\n  \"baz\",\n  \"qux\"\n]"},
+		{"toJson map", "toJson(foo.nested)", `{
+  "slice": [
+    {
+      "foo": "bar"
+    },
+    {
+      "foo": "baz"
+    },
+    {
+      "foo": "qux"
+    }
+  ],
+  "some": "value"
+}`},
 		{"fromJson number", "fromJson('1')", 1.0},
 		{"fromJson boolean", "fromJson('true')", true},
 		{"always()", "always()", true},
@@ -660,6 +695,60 @@ func TestExpression_EvaluateFunctions(t *testing.T) {
 	}
 }
 
+func TestExpression_EvaluateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{name: "no placeholders", input: "format('Hello')", expected: "Hello"},
+		{name: "reused placeholder", input: "format('{0}-{0}', 'a')", expected: "a-a"},
+		{name: "unordered placeholders", input: "format('{1} {0}', 'World', 'Hello')", expected: "Hello World"},
+		{name: "unused argument", input: "format('{0}', 'a', 'b')", expected: "a"},
+		{name: "escaped braces", input: "format('{{0}} {{{0}}}', 'a')", expected: "{0} {a}"},
+		{name: "escaped closing brace", input: "format('}}{0}', 'a')", expected: "}a"},
+		{name: "bool", input: "format('{0}', true)", expected: "true"},
+		{name: "integer", input: "format('{0}', 1.0)", expected: "1"},
+		{name: "float", input: "format('{0}', 1.50)", expected: "1.5"},
+		{name: "null", input: "format('[{0}]', null)", expected: "[]"},
+		{name: "array", input: "format('{0}', fromJSON('[]'))", expected: "Array"},
+		{name: "multi-digit index", input: "format('{10}', 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)", expected: "10"},
+		{name: "out of range", input: "format('v{0}.{1}', 1)", err: "placeholder '{1}' at position 5 references argument 1"},
+		{name: "invalid index", input: "format('{a}', 1)", err: "invalid placeholder '{a}' at position 0"},
+		{name: "negative index", input: "format('{-1}', 1)", err: "invalid placeholder '{-1}'"},
+		{name: "empty placeholder", input: "format('{}', 1)", err: "invalid placeholder '{}'"},
+		{name: "unclosed placeholder", input: "format('x {0', 1)", err: "unclosed placeholder at position 2"},
+		{name: "unmatched closing brace", input: "format('x } {0}', 1)", err: "unmatched '}' at position 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result, err := expr.Evaluate(nil)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected error containing %q, but got %v for input: %s", tt.err, err, tt.input)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if tt.expected != result {
+				t.Errorf("Expected %q, but got %q for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
 func TestExpression_EvaluateHashFunc(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -674,7 +763,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of existing files matching pattern",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
 			files: []struct {
 				path    string
 				name    string
@@ -686,7 +775,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "multiple files matching",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069b86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "95cdbcdd8d42ffa23e01603f1bf83cdeefde710fc7f51bb2dfbef90bd2a1b60d",
 			files: []struct {
 				path    string
 				name    string
@@ -699,7 +788,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of nested directory",
 			input:    `hashFiles('./testdata/nested/**/file-*.txt')`,
-			expected: "14d53b936bfc2c45b26e84f74742f32f1449bab0ee83f6bb3410ef87b3a4d4beb86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "151a6655041a9373414353d553d2cd49cf54ff8449b2cf02e0c9c3c66d982451",
 			files: []struct {
 				path    string
 				name    string
@@ -710,6 +799,51 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 				{path: "nested/bar", name: "file-3.txt", content: []byte("Bar Foo!")},
 			},
 		},
+		{
+			name:     "Negated pattern excludes files",
+			input:    `hashFiles('./testdata/**/*.txt', '!./testdata/nested/**')`,
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{name: "file-1.txt", content: []byte("Hello World!")},
+				{path: "nested", name: "file-2.txt", content: []byte("Foo Bar!")},
+			},
+		},
+		{
+			name:     "Directory pattern matches all files under the directory",
+			input:    `hashFiles('./testdata/nested')`,
+			expected: "f66759cda4c359b03c4425ad0bb5b7e70daf7b8740cfb10659305b155a20ebe1",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{path: "nested", name: "file-1.txt", content: []byte("Hello World!")},
+				{path: "nested/bar", name: "file-3.txt", content: []byte("Bar Foo!")},
+				{name: "file-2.txt", content: []byte("Foo Bar!")},
+			},
+		},
+		{
+			name:     "Wildcard matches dot files",
+			input:    `hashFiles('./testdata/*')`,
+			expected: "911d9041e6f251d25743401486e2f9f250e9f5fa002c2fd866097969d97e0f87",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{name: ".hidden", content: []byte("Foo Bar!")},
+				{name: "file-1.txt", content: []byte("Hello World!")},
+			},
+		},
+		{
+			name:     "Files outside of the workspace are ignored",
+			input:    `hashFiles('../actions/*.go')`,
+			expected: "",
+		},
 		{
 			name:     "Hash of non-existing file",
 			input:    `hashFiles('./testdata/non-extant-file.txt')`,
@@ -763,17 +897,157 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 	}
 }
 
+func TestInterpolate(t *testing.T) {
+	provider := &valueProvider{variables: map[string]interface{}{
+		"value": map[string]interface{}{"name": "foo", "count": 3, "enabled": true},
+	}}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "No expressions", input: "foo ${{ bar", expected: "foo ${{ bar"},
+		{name: "String", input: "v-${{ value.name }}", expected: "v-foo"},
+		{name: "Number and bool", input: "${{ value.count }}-${{ value.enabled }}", expected: "3-true"},
+		{name: "Null", input: "[${{ value.missing }}]", expected: "[]"},
+		{name: "Object and array", input: "${{ value }} ${{ fromJSON('[1]') }}", expected: "Object Array"},
+		{name: "Same expression twice", input: "${{ value.count }}/${{ value.count }}0", expected: "3/30"},
+		{name: "Multi-line", input: "echo ${{ value.name }}\necho ${{ value.count }}", expected: "echo foo\necho 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Interpolate(tt.input, provider)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected %q, but got %q for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseExpressions_StatusFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"always in string", "${{ always() }}", true},
+		{"cancelled in nested expression", "value: ${{ !cancelled() && true }}", true},
+		{"case insensitive", "${{ Failure() }}", true},
+		{"other functions", "${{ contains('foo', 'f') }}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpressions(tt.input)
+
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error, but got nil for input: %s", tt.input)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+		})
+	}
+}
+
+func TestExpression_CallsStatusFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"status function", "always()", true},
+		{"nested status function", "${{ github.ref == 'refs/heads/main' && !cancelled() }}", true},
+		{"case insensitive", "Failure()", true},
+		{"name in string literal", "contains(github.event.head_commit.message, 'failure()')", false},
+		{"property with status name", "steps.success.outputs.value == 'true'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if result := expr.CallsStatusFunction(); result != tt.expected {
+				t.Errorf("Expected %v, but got %v for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+func TestExpression_EvaluateStatusFuncActionScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		jobStatus    string
+		actionStatus string
+		input        string
+		expected     bool
+	}{
+		{"job status without action scope", "failure", "", "failure()", true},
+		{"action status overrides job status", "failure", "success", "success()", true},
+		{"failed action in successful job", "success", "failure", "failure()", true},
+		{"always ignores status", "failure", "failure", "always()", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Errorf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			provider := &statusVariableProvider{job: tt.jobStatus, action: tt.actionStatus}
+
+			result, err := expr.Evaluate(provider)
+			if err != nil {
+				t.Errorf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if tt.expected != result {
+				t.Errorf("Expected %v, but got %v for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+type statusVariableProvider struct {
+	job    string
+	action string
+}
+
+func (p *statusVariableProvider) GetVariable(name string) (interface{}, error) {
+	switch name {
+	case "github":
+		return map[string]interface{}{"action_status": p.action}, nil
+	case "job":
+		return map[string]interface{}{"status": p.job}, nil
+	}
+
+	return nil, fmt.Errorf("variable %s not found", name)
+}
+
 // TODO: find a better way. Currently tests are relying on static values. It's not maintainable for long term.
 
 type TestVariableProvider struct{}
 
 func (p *TestVariableProvider) GetVariable(name string) (interface{}, error) {
 	switch name {
-	case "env":
+	case "github":
 		return map[string]interface{}{
-			"job": map[string]interface{}{
-				"status": "success",
-			},
+			"action_status": "",
+		}, nil
+	case "job":
+		return map[string]interface{}{
+			"status": "success",
 		}, nil
 	case "foo":
 		return map[string]interface{}{
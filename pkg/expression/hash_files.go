@@ -0,0 +1,339 @@
+package expression
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// followSymbolicLinks is the option of hashFiles to follow symbolic links to directories.
+const followSymbolicLinks = "--follow-symbolic-links"
+
+// globPattern is a single pattern of hashFiles. Patterns follow the @actions/glob syntax used by the runner:
+//   - `*`, `?` and `[...]` match within a path segment, dot files included.
+//   - `**` matches zero or more directories.
+//   - `!` negates the pattern and excludes the matching files. Later patterns override earlier ones.
+//   - A pattern matching a directory matches all files under the directory.
+//   - Lines starting with `#` are comments.
+type globPattern struct {
+	segments []string // segments is the absolute pattern split by path separator
+	negate   bool     // negate is true if the pattern excludes the matching files
+}
+
+// parseGlobPatterns parses the patterns. Each pattern can contain multiple patterns separated by newlines. Relative
+// patterns are resolved against the root directory.
+func parseGlobPatterns(root string, patterns []string) ([]globPattern, error) {
+	var result []globPattern
+
+	for _, pattern := range patterns {
+		for _, line := range strings.Split(pattern, "\n") {
+			line = strings.TrimSpace(line)
+
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			negate := false
+
+			for strings.HasPrefix(line, "!") {
+				negate = !negate
+				line = strings.TrimSpace(line[1:])
+			}
+
+			if line == "" {
+				return nil, fmt.Errorf("invalid pattern '%s', pattern can't be empty after negation", pattern)
+			}
+
+			if !path.IsAbs(line) {
+				line = path.Join(filepath.ToSlash(root), line)
+			}
+
+			result = append(result, globPattern{segments: strings.Split(path.Clean(line), "/"), negate: negate})
+		}
+	}
+
+	return result, nil
+}
+
+// searchRoot returns the longest directory of the pattern without any glob characters.
+func (p globPattern) searchRoot() string {
+	var literal []string
+
+	for _, segment := range p.segments {
+		if strings.ContainsAny(segment, "*?[") {
+			break
+		}
+
+		literal = append(literal, segment)
+	}
+
+	// for literal patterns, the root is the path itself and it can be a file or a directory
+	root := strings.Join(literal, "/")
+	if root == "" {
+		return "/"
+	}
+
+	return root
+}
+
+// match returns true if the pattern matches the file or one of its parent directories.
+func (p globPattern) match(file string) bool {
+	segments := strings.Split(file, "/")
+
+	for i := len(segments); i > 0; i-- {
+		if matchSegments(p.segments, segments[:i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSegments matches the path segments against the pattern segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// searchRoots returns the search roots of the patterns to walk in order. Roots under another root are dropped, since
+// they're walked with their ancestor.
+func searchRoots(patterns []globPattern) []string {
+	candidates := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		if !pattern.negate {
+			candidates[pattern.searchRoot()] = true
+		}
+	}
+
+	var roots []string
+
+	included := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		root := pattern.searchRoot()
+
+		if pattern.negate || included[root] {
+			continue
+		}
+
+		ancestor := false
+
+		for child, dir := root, path.Dir(root); dir != child; child, dir = dir, path.Dir(dir) {
+			if candidates[dir] {
+				ancestor = true
+				break
+			}
+		}
+
+		if !ancestor {
+			roots = append(roots, root)
+			included[root] = true
+		}
+	}
+
+	return roots
+}
+
+// matchGlob returns true if the file matches the patterns. Later patterns override earlier ones.
+func matchGlob(patterns []globPattern, file string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		if pattern.match(file) {
+			matched = !pattern.negate
+		}
+	}
+
+	return matched
+}
+
+// globFiles returns the files matching the patterns in the order @actions/glob finds them. Search roots are walked in
+// the order of the patterns, depth-first with the entries of each directory sorted by name, so a directory comes before
+// the files sharing its name as prefix, e.g. go/ before go.sum. Symbolic links to directories are followed only if
+// followLinks is true, same as the runner, and links forming a cycle are skipped.
+func globFiles(patterns []globPattern, followLinks bool) ([]string, error) {
+	var files []string
+
+	found := func(file string) {
+		if matchGlob(patterns, file) {
+			files = append(files, file)
+		}
+	}
+
+	for _, root := range searchRoots(patterns) {
+		if err := walkGlob(filepath.FromSlash(root), followLinks, nil, found); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// walkGlob walks the path depth-first and calls found for each file. Chain is the list of the real paths of the
+// directories walked so far to detect cycles while following symbolic links.
+func walkGlob(name string, followLinks bool, chain []string, found func(file string)) error {
+	info, err := os.Lstat(name)
+	if err != nil {
+		// search roots of the patterns don't have to exist
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Stat(name)
+		if err != nil {
+			// broken links are skipped
+			return nil
+		}
+
+		// links to directories are skipped unless they're followed
+		if target.IsDir() && !followLinks {
+			return nil
+		}
+
+		info = target
+	}
+
+	if !info.IsDir() {
+		found(filepath.ToSlash(name))
+		return nil
+	}
+
+	if followLinks {
+		real, err := filepath.EvalSymlinks(name)
+		if err != nil {
+			return err
+		}
+
+		for _, dir := range chain {
+			if dir == real {
+				return nil
+			}
+		}
+
+		chain = append(chain[:len(chain):len(chain)], real)
+	}
+
+	// entries are sorted by name
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := walkGlob(filepath.Join(name, entry.Name()), followLinks, chain, found); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashFiles returns a single SHA-256 digest of the files matching the patterns, same as the runner. Files are hashed
+// one by one in the order they're found and the digests are hashed again into the result. Only files under
+// GITHUB_WORKSPACE, or the working directory if it's not set, are included. It returns an empty string if no files
+// match. Symbolic links to directories are followed if the first argument is --follow-symbolic-links.
+func hashFiles(patterns ...string) (string, error) {
+	followLinks := false
+
+	if len(patterns) > 1 && patterns[0] == followSymbolicLinks {
+		followLinks, patterns = true, patterns[1:]
+	}
+
+	root := os.Getenv("GITHUB_WORKSPACE")
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+
+		root = wd
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	globs, err := parseGlobPatterns(root, patterns)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := globFiles(globs, followLinks)
+	if err != nil {
+		return "", err
+	}
+
+	result := sha256.New()
+	count := 0
+
+	prefix := strings.TrimSuffix(filepath.ToSlash(root), "/") + "/"
+
+	for _, file := range files {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+
+		digest, err := hashFile(filepath.FromSlash(file))
+		if err != nil {
+			return "", err
+		}
+
+		result.Write(digest)
+		count++
+	}
+
+	if count == 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%x", result.Sum(nil)), nil
+}
+
+// hashFile returns the SHA-256 digest of the file content.
+func hashFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
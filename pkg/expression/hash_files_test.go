@@ -0,0 +1,87 @@
+package expression
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGlobFiles(t *testing.T) {
+	root := t.TempDir()
+
+	for _, file := range []string{"go.sum", "go.mod", "go/b.txt", "go-tools/c.txt", "vendor/d.txt"} {
+		path := filepath.Join(root, file)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// link to a directory and a link forming a cycle
+	if err := os.Symlink(filepath.Join(root, "vendor"), filepath.Join(root, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(root, filepath.Join(root, "vendor", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		patterns    []string
+		followLinks bool
+		expected    []string
+	}{
+		{
+			name:     "directories are walked depth-first with sorted entries",
+			patterns: []string{"**/*", "!vendor/**"},
+			expected: []string{"go/b.txt", "go-tools/c.txt", "go.mod", "go.sum"},
+		},
+		{
+			name:     "search roots are walked in pattern order",
+			patterns: []string{"go.sum", "go/**"},
+			expected: []string{"go.sum", "go/b.txt"},
+		},
+		{
+			name:        "symbolic links to directories are followed",
+			patterns:    []string{"linked/**", "vendor/**"},
+			followLinks: true,
+			expected:    []string{"linked/d.txt", "linked/loop/go/b.txt", "linked/loop/go-tools/c.txt", "linked/loop/go.mod", "linked/loop/go.sum", "vendor/d.txt", "vendor/loop/go/b.txt", "vendor/loop/go-tools/c.txt", "vendor/loop/go.mod", "vendor/loop/go.sum"},
+		},
+		{
+			name:     "symbolic links to directories are skipped by default",
+			patterns: []string{"linked/**", "vendor/**"},
+			expected: []string{"vendor/d.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := parseGlobPatterns(root, tt.patterns)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			files, err := globFiles(patterns, tt.followLinks)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			var relative []string
+
+			for _, file := range files {
+				relative = append(relative, strings.TrimPrefix(file, filepath.ToSlash(root)+"/"))
+			}
+
+			if !reflect.DeepEqual(relative, tt.expected) {
+				t.Errorf("Expected files %v, but got %v", tt.expected, relative)
+			}
+		})
+	}
+}
@@ -1,40 +1,91 @@
 package expression
 
 import (
-	"encoding"
 	"fmt"
-	"math"
 	"reflect"
-	"strconv"
+	"sort"
 	"strings"
-
-	"github.com/rhysd/actionlint"
 )
 
+// filteredArray is the result of the object filter `*`. Property dereference and index access on a filtered array
+// are applied to each element, so filters can be chained, e.g. `github.event.commits.*.author.name`.
+type filteredArray []interface{}
+
+// filterValue applies the object filter `*` to the value. It returns the elements of an array or the values of an
+// object. Other values result in an empty array. A filtered array is flattened one level, so filters can be nested.
+func filterValue(value reflect.Value) filteredArray {
+	result := filteredArray{}
+
+	value = indirect(value)
+
+	if !value.IsValid() {
+		return result
+	}
+
+	if fa, ok := value.Interface().(filteredArray); ok {
+		for _, item := range fa {
+			result = append(result, filterValue(reflect.ValueOf(item))...)
+		}
+
+		return result
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, value.Index(i).Interface())
+		}
+	case reflect.Map:
+		// maps don't keep the order of the keys, sort them to get a stable result
+		keys := value.MapKeys()
+
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		for _, key := range keys {
+			result = append(result, value.MapIndex(key).Interface())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if _, ok := fieldName(value.Type().Field(i)); ok {
+				result = append(result, value.Field(i).Interface())
+			}
+		}
+	}
+
+	return result
+}
+
 // getPropertyValue retrieves the value of the specified property from the given value.
-// The property can be accessed from struct, map, or slice types using dot notation.
-// If the property is not found, nil is returned along with no error.
+// The property can be accessed from struct, map, or filtered array types using dot notation. Property names are
+// case-insensitive. If the property is not found, nil is returned along with no error.
 func getPropertyValue(left reflect.Value, property string) (value interface{}, err error) {
+	left = indirect(left)
+
+	if !left.IsValid() {
+		return nil, nil
+	}
+
+	if fa, ok := left.Interface().(filteredArray); ok {
+		return getPropertyValueFromFilteredArray(fa, property)
+	}
+
 	switch left.Kind() {
-	case reflect.Ptr:
-		return getPropertyValue(left.Elem(), property)
 	case reflect.Struct:
 		return getPropertyValueFromStruct(left, property)
 	case reflect.Map:
 		return getPropertyValueFromMap(left, property)
-	case reflect.Slice:
-		return getPropertyValueFromSlice(left, property)
 	}
 
 	return nil, nil
 }
 
-// getPropertyValueFromSlice retrieves the values of the specified property from the elements of the given slice.
-func getPropertyValueFromSlice(left reflect.Value, property string) (interface{}, error) {
-	var values []interface{}
+// getPropertyValueFromFilteredArray retrieves the values of the specified property from the elements of the given
+// filtered array. Elements without the property are skipped.
+func getPropertyValueFromFilteredArray(left filteredArray, property string) (interface{}, error) {
+	values := filteredArray{}
 
-	for i := 0; i < left.Len(); i++ {
-		value, err := getPropertyValue(left.Index(i), property)
+	for _, item := range left {
+		value, err := getPropertyValue(reflect.ValueOf(item), property)
 		if err != nil {
 			return nil, err
 		}
@@ -66,13 +117,14 @@ func getPropertyValueFromMap(left reflect.Value, property string) (interface{},
 	return nil, nil
 }
 
-// getPropertyValueFromStruct retrieves the value of the specified property from the given struct.
+// getPropertyValueFromStruct retrieves the value of the specified property from the given struct. Fields are looked
+// up by their json names, same as map keys.
 func getPropertyValueFromStruct(left reflect.Value, property string) (interface{}, error) {
 	leftType := left.Type()
 	fieldIndex := findFieldIndexByJSONTag(leftType, property)
 
 	if fieldIndex < 0 {
-		return nil, fmt.Errorf("property '%s' not found in struct", property)
+		return nil, nil
 	}
 
 	fieldValue := left.Field(fieldIndex)
@@ -81,23 +133,14 @@ func getPropertyValueFromStruct(left reflect.Value, property string) (interface{
 		return nil, nil
 	}
 
-	i := fieldValue.Interface()
-
-	if m, ok := i.(encoding.TextMarshaler); ok {
-		text, err := m.MarshalText()
-		if err != nil {
-			return nil, err
-		}
-		return string(text), nil
-	}
-
-	return i, nil
+	return normalize(fieldValue.Interface()), nil
 }
 
-// findFieldIndexByJSONTag finds the index of the field with the specified JSON tag in the given struct type.
+// findFieldIndexByJSONTag finds the index of the field with the specified JSON name in the given struct type. Names
+// are compared case-insensitively.
 func findFieldIndexByJSONTag(structType reflect.Type, jsonTag string) int {
 	for i := 0; i < structType.NumField(); i++ {
-		if structType.Field(i).Tag.Get("json") == jsonTag {
+		if name, ok := fieldName(structType.Field(i)); ok && strings.EqualFold(name, jsonTag) {
 			return i
 		}
 	}
@@ -105,165 +148,42 @@ func findFieldIndexByJSONTag(structType reflect.Type, jsonTag string) int {
 	return -1
 }
 
-// unwrapValue unwraps the underlying value of the given reflect.Value, de-referencing it if it is a pointer.
-func unwrapValue(value reflect.Value) (interface{}, error) {
-	if value.Kind() == reflect.Ptr {
-		value = value.Elem()
-	}
-
-	return value.Interface(), nil
-}
-
-// getSafeValue returns the value of the given reflect.Value, or nil if the value is invalid.
-func getSafeValue(value reflect.Value) interface{} {
-	// If the value is invalid, return nil
-	if !value.IsValid() {
-		return nil
-	}
-
-	// if value is float64 and is 0, return 0
-	if value.Kind() == reflect.Float64 {
-		if value.Float() == 0 {
-			return 0
-		}
+// fieldName returns the json name of the struct field. The field name is used if the field doesn't have a json tag.
+// It returns false for unexported fields and fields ignored in json.
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
 	}
 
-	// return the value interface
-	return value.Interface()
-}
-
-// isTruthy returns true if the given value is truthy, false otherwise.
-func isTruthy(input interface{}) bool {
-	value := reflect.ValueOf(input)
-	switch value.Kind() {
-	case reflect.Bool:
-		return value.Bool()
-
-	case reflect.String:
-		return value.String() != ""
-
-	case reflect.Int:
-		return value.Int() != 0
-
-	case reflect.Float64:
-		if math.IsNaN(value.Float()) {
-			return false
-		}
-
-		return value.Float() != 0
-
-	case reflect.Map, reflect.Slice:
-		return true
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
 
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
 	default:
-		return false
+		return name, true
 	}
 }
 
-// compareValues compares the given values using the specified comparison operator.
-func compareValues(leftValue reflect.Value, rightValue reflect.Value, kind actionlint.CompareOpNodeKind) (interface{}, error) {
-	if leftValue.Kind() != rightValue.Kind() {
-		if !isNumber(leftValue) {
-			leftValue = coerceToNumber(leftValue)
-		}
-		if !isNumber(rightValue) {
-			rightValue = coerceToNumber(rightValue)
-		}
-	}
-
-	switch leftValue.Kind() {
-	case reflect.Bool:
-		left := coerceToNumber(leftValue)
-		right := coerceToNumber(rightValue)
-		return compare(float64(left.Int()), float64(right.Int()), kind)
-
-	case reflect.String:
-		left := strings.ToLower(leftValue.String())
-		right := strings.ToLower(rightValue.String())
-		return compare(left, right, kind)
-
-	case reflect.Int:
-		left := float64(leftValue.Int())
-		if rightValue.Kind() == reflect.Float64 {
-			right := rightValue.Float()
-			return compare(left, right, kind)
-		}
-		right := float64(rightValue.Int())
-		return compare(left, right, kind)
-
-	case reflect.Float64:
-		left := leftValue.Float()
-		if rightValue.Kind() == reflect.Int {
-			right := float64(rightValue.Int())
-			return compare(left, right, kind)
-		}
-		right := rightValue.Float()
-		return compare(left, right, kind)
-
-	case reflect.Invalid:
-		if rightValue.Kind() == reflect.Invalid {
-			return true, nil
-		}
-		return nil, fmt.Errorf("compare params of Invalid type: left: %+v, right: %+v", leftValue.Kind(), rightValue.Kind())
-
-	default:
-		return nil, fmt.Errorf("compare not implemented for types: left: %+v, right: %+v", leftValue.Kind(), rightValue.Kind())
+// unwrapValue unwraps the underlying value of the given reflect.Value, de-referencing it if it is a pointer. Scalars
+// are converted into the value model.
+func unwrapValue(value reflect.Value) (interface{}, error) {
+	if !value.IsValid() {
+		return nil, nil
 	}
-}
 
-// isNumber returns true if the given value is a number, false otherwise.
-func isNumber(value reflect.Value) bool {
-	switch value.Kind() {
-	case reflect.Int, reflect.Float64:
-		return true
-	default:
-		return false
-	}
+	return normalize(value.Interface()), nil
 }
 
-// coerceToNumber converts the given value to a number if possible, otherwise returns NaN.
-func coerceToNumber(value reflect.Value) reflect.Value {
-	switch value.Kind() {
-	case reflect.Invalid:
-		return reflect.ValueOf(0)
-
-	case reflect.Bool:
-		switch value.Bool() {
-		case true:
-			return reflect.ValueOf(1)
-		case false:
-			return reflect.ValueOf(0)
-		}
-
-	case reflect.String:
-		if value.String() == "" {
-			return reflect.ValueOf(0)
-		}
-
-		if number, err := strconv.ParseFloat(value.String(), 64); err == nil {
-			return reflect.ValueOf(number)
-		}
+// getSafeValue returns the value of the given reflect.Value, or nil if the value is invalid.
+func getSafeValue(value reflect.Value) interface{} {
+	// If the value is invalid, return nil
+	if !value.IsValid() {
+		return nil
 	}
 
-	return reflect.ValueOf(math.NaN())
-}
-
-// compare compares the given values using the specified comparison operator.
-func compare[T string | float64](left, right T, kind actionlint.CompareOpNodeKind) (bool, error) {
-	switch kind {
-	case actionlint.CompareOpNodeKindLess:
-		return left < right, nil
-	case actionlint.CompareOpNodeKindLessEq:
-		return left <= right, nil
-	case actionlint.CompareOpNodeKindGreater:
-		return left > right, nil
-	case actionlint.CompareOpNodeKindGreaterEq:
-		return left >= right, nil
-	case actionlint.CompareOpNodeKindEq:
-		return left == right, nil
-	case actionlint.CompareOpNodeKindNotEq:
-		return left != right, nil
-	default:
-		return false, fmt.Errorf("TODO: not implemented to compare '%+v'", kind)
-	}
+	// return the value in the value model
+	return normalize(value.Interface())
 }
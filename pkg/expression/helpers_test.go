@@ -1,11 +1,8 @@
 package expression
 
 import (
-	"math"
 	"reflect"
 	"testing"
-
-	"github.com/rhysd/actionlint"
 )
 
 func TestGetPropertySlice(t *testing.T) {
@@ -19,12 +16,12 @@ func TestGetPropertySlice(t *testing.T) {
 		"key2": "value5",
 		"key3": "value6",
 	}
-	slice := []map[string]interface{}{m1, m2}
+	slice := filteredArray{m1, m2}
 	left := reflect.ValueOf(slice)
 
 	//nolint:goconst // This is a test
 	property := "key1"
-	expected := []interface{}{"value1", "value4"}
+	expected := filteredArray{"value1", "value4"}
 
 	value, err := getPropertyValue(left, property)
 	if err != nil {
@@ -146,11 +143,11 @@ func TestGetProperty(t *testing.T) {
 			"key2": "value5",
 			"key3": "value6",
 		}
-		slice := []map[string]interface{}{m1, m2}
+		slice := filteredArray{m1, m2}
 		left := reflect.ValueOf(slice)
 
 		property := "key1"
-		expected := []interface{}{"value1", "value4"}
+		expected := filteredArray{"value1", "value4"}
 
 		value, err := getPropertyValue(left, property)
 		if err != nil {
@@ -206,6 +203,34 @@ func TestGetProperty(t *testing.T) {
 		}
 	})
 
+	t.Run("StructCaseInsensitive", func(t *testing.T) {
+		type TestStruct struct {
+			Field1 string `json:"field_1"`
+			Field2 int
+		}
+		left := reflect.ValueOf(TestStruct{"value1", 42})
+
+		if value, _ := getPropertyValue(left, "FIELD_1"); value != "value1" {
+			t.Errorf("Incorrect property value. Expected: %v, Got: %v", "value1", value)
+		}
+
+		if value, _ := getPropertyValue(left, "field2"); value != float64(42) {
+			t.Errorf("Incorrect property value. Expected: %v, Got: %v", 42, value)
+		}
+
+		if value, _ := getPropertyValue(left, "missing"); value != nil {
+			t.Errorf("Incorrect property value. Expected: %v, Got: %v", nil, value)
+		}
+	})
+
+	t.Run("ArrayWithoutFilter", func(t *testing.T) {
+		left := reflect.ValueOf([]map[string]interface{}{{"key1": "value1"}})
+
+		if value, _ := getPropertyValue(left, "key1"); value != nil {
+			t.Errorf("Incorrect property value. Expected: %v, Got: %v", nil, value)
+		}
+	})
+
 	// Test case 5: Property not found
 	t.Run("NotFound", func(t *testing.T) {
 		m := map[string]interface{}{
@@ -213,11 +238,11 @@ func TestGetProperty(t *testing.T) {
 			"key2": "value2",
 			"key3": "value3",
 		}
-		slice := []map[string]interface{}{m}
+		slice := filteredArray{m}
 		left := reflect.ValueOf(slice)
 
 		property := "key4"
-		var expected []interface{}
+		expected := filteredArray{}
 
 		value, err := getPropertyValue(left, property)
 		if err != nil {
@@ -230,7 +255,40 @@ func TestGetProperty(t *testing.T) {
 	})
 }
 
+func TestFilterValue(t *testing.T) {
+	type TestStruct struct {
+		Field1 string `json:"field_1"`
+		Field2 string `json:"-"`
+		field3 string
+	}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected filteredArray
+	}{
+		{name: "Slice", value: []string{"a", "b"}, expected: filteredArray{"a", "b"}},
+		{name: "Map", value: map[string]interface{}{"b": 2, "a": 1}, expected: filteredArray{1, 2}},
+		{name: "Struct", value: TestStruct{"a", "b", "c"}, expected: filteredArray{"a"}},
+		{name: "Nested", value: filteredArray{[]int{1, 2}, map[string]int{"a": 3}, "skipped"}, expected: filteredArray{1, 2, 3}},
+		{name: "String", value: "abc", expected: filteredArray{}},
+		{name: "Null", value: nil, expected: filteredArray{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := filterValue(reflect.ValueOf(test.value))
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Incorrect result. Expected: %v, Got: %v", test.expected, result)
+			}
+		})
+	}
+}
+
 func TestGetSafeValue(t *testing.T) {
+	type NamedString string
+
 	testCases := []struct {
 		name     string
 		value    reflect.Value
@@ -249,414 +307,31 @@ func TestGetSafeValue(t *testing.T) {
 		{
 			name:     "Float64 value = 0",
 			value:    reflect.ValueOf(float64(0)),
-			expected: 0,
+			expected: float64(0),
 		},
 		{
 			name:     "Float64 value != 0",
 			value:    reflect.ValueOf(1.23),
 			expected: 1.23,
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			result := getSafeValue(tc.value)
-			if result != tc.expected {
-				t.Errorf("Incorrect result. Expected: %v, Got: %v", tc.expected, result)
-			}
-		})
-	}
-}
-
-func TestIsTruthy(t *testing.T) {
-	testCases := []struct {
-		name     string
-		input    interface{}
-		expected bool
-	}{
-		{
-			name:     "Bool true",
-			input:    true,
-			expected: true,
-		},
-		{
-			name:     "Bool false",
-			input:    false,
-			expected: false,
-		},
-		{
-			name:     "Non-empty string",
-			input:    "hello",
-			expected: true,
-		},
-		{
-			name:     "Empty string",
-			input:    "",
-			expected: false,
-		},
-		{
-			name:     "Non-zero integer",
-			input:    42,
-			expected: true,
-		},
-		{
-			name:     "Zero integer",
-			input:    0,
-			expected: false,
-		},
-		{
-			name:     "Non-zero float",
-			input:    3.14,
-			expected: true,
-		},
-		{
-			name:     "Zero float",
-			input:    0.0,
-			expected: false,
-		},
-		{
-			name:     "NaN float",
-			input:    math.NaN(),
-			expected: false,
-		},
-		{
-			name:     "Map",
-			input:    map[string]int{"a": 1},
-			expected: true,
-		},
 		{
-			name:     "Slice",
-			input:    []string{"apple", "banana"},
-			expected: true,
+			name:     "Int value",
+			value:    reflect.ValueOf(42),
+			expected: float64(42),
 		},
 		{
-			name:     "Struct",
-			input:    struct{ Name string }{Name: "John"},
-			expected: false,
+			name:     "Named string value",
+			value:    reflect.ValueOf(NamedString("named")),
+			expected: "named",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := isTruthy(tc.input)
+			result := getSafeValue(tc.value)
 			if result != tc.expected {
 				t.Errorf("Incorrect result. Expected: %v, Got: %v", tc.expected, result)
 			}
 		})
 	}
 }
-
-func TestCompareValues(t *testing.T) {
-	tests := []struct {
-		name           string
-		leftValue      interface{}
-		rightValue     interface{}
-		kind           actionlint.CompareOpNodeKind
-		expectedResult interface{}
-	}{
-		{
-			name:           "Bool_Less",
-			leftValue:      true,
-			rightValue:     false,
-			kind:           actionlint.CompareOpNodeKindLess,
-			expectedResult: false,
-		},
-		{
-			name:           "String_Eq",
-			leftValue:      "abc",
-			rightValue:     "abc",
-			kind:           actionlint.CompareOpNodeKindEq,
-			expectedResult: true,
-		},
-		{
-			name:           "Int_LessEq",
-			leftValue:      10,
-			rightValue:     20,
-			kind:           actionlint.CompareOpNodeKindLessEq,
-			expectedResult: true,
-		},
-		{
-			name:           "Float_Less",
-			leftValue:      3.14,
-			rightValue:     2.718,
-			kind:           actionlint.CompareOpNodeKindLess,
-			expectedResult: false,
-		},
-		{
-			name:           "Invalid_Invalid",
-			leftValue:      nil,
-			rightValue:     nil,
-			kind:           actionlint.CompareOpNodeKindEq,
-			expectedResult: true,
-		},
-		{
-			name:           "Invalid_String",
-			leftValue:      "abc",
-			rightValue:     nil,
-			kind:           actionlint.CompareOpNodeKindEq,
-			expectedResult: false,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			leftValue := reflect.ValueOf(test.leftValue)
-			rightValue := reflect.ValueOf(test.rightValue)
-
-			result, err := compareValues(leftValue, rightValue, test.kind)
-
-			if result != test.expectedResult {
-				t.Errorf("Unexpected result. Expected: %v, Got: %v", test.expectedResult, result)
-			}
-
-			if err != nil {
-				t.Errorf("Unexpected error: %v", err)
-			}
-		})
-	}
-}
-
-func TestIsNumber(t *testing.T) {
-	tests := []struct {
-		name     string
-		value    interface{}
-		expected bool
-	}{
-		{
-			name:     "Int",
-			value:    10,
-			expected: true,
-		},
-		{
-			name:     "Float64",
-			value:    3.14,
-			expected: true,
-		},
-		{
-			name:     "String",
-			value:    "123",
-			expected: false,
-		},
-		{
-			name:     "Bool",
-			value:    true,
-			expected: false,
-		},
-		{
-			name:     "Invalid",
-			value:    nil,
-			expected: false,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			value := reflect.ValueOf(test.value)
-			result := isNumber(value)
-
-			if result != test.expected {
-				t.Errorf("Unexpected result. Expected: %v, Got: %v", test.expected, result)
-			}
-		})
-	}
-}
-
-func TestCoerceToNumber(t *testing.T) {
-	tests := []struct {
-		name         string
-		value        interface{}
-		expectedKind reflect.Kind
-		expectedVal  interface{}
-	}{
-		{
-			name:         "Invalid",
-			value:        nil,
-			expectedKind: reflect.Int,
-			expectedVal:  0,
-		},
-		{
-			name:         "Bool_True",
-			value:        true,
-			expectedKind: reflect.Int,
-			expectedVal:  1,
-		},
-		{
-			name:         "Bool_False",
-			value:        false,
-			expectedKind: reflect.Int,
-			expectedVal:  0,
-		},
-		{
-			name:         "String_Empty",
-			value:        "",
-			expectedKind: reflect.Int,
-			expectedVal:  0,
-		},
-		{
-			name:         "String_Valid",
-			value:        "123.45",
-			expectedKind: reflect.Float64,
-			expectedVal:  123.45,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			value := coerceToNumber(reflect.ValueOf(test.value))
-
-			if value.Kind() != test.expectedKind {
-				t.Errorf("Unexpected kind. Expected: %v, Got: %v", test.expectedKind, value.Kind())
-			}
-
-			var val interface{}
-			if value.Kind() == reflect.Float64 {
-				val = value.Float()
-			} else {
-				val = int(value.Int())
-			}
-
-			if val != test.expectedVal {
-				t.Errorf("Unexpected value. Expected: %v, Got: %v", test.expectedVal, val)
-			}
-		})
-	}
-
-	t.Run("String_Invalid", func(t *testing.T) {
-		value := coerceToNumber(reflect.ValueOf("hello"))
-		if !math.IsNaN(value.Float()) {
-			t.Errorf("Expected NaN value, Got: %v", value.Float())
-		}
-	})
-}
-
-func TestCompare(t *testing.T) {
-	tests := []struct {
-		name     string
-		left     interface{}
-		right    interface{}
-		kind     actionlint.CompareOpNodeKind
-		expected bool
-	}{
-		{
-			name:     "Less_Int",
-			left:     float64(10),
-			right:    float64(20),
-			kind:     actionlint.CompareOpNodeKindLess,
-			expected: true,
-		},
-		{
-			name:     "LessEq_Int",
-			left:     float64(10),
-			right:    float64(20),
-			kind:     actionlint.CompareOpNodeKindLessEq,
-			expected: true,
-		},
-		{
-			name:     "Greater_Int",
-			left:     float64(20),
-			right:    float64(10),
-			kind:     actionlint.CompareOpNodeKindGreater,
-			expected: true,
-		},
-		{
-			name:     "GreaterEq_Int",
-			left:     float64(20),
-			right:    float64(10),
-			kind:     actionlint.CompareOpNodeKindGreaterEq,
-			expected: true,
-		},
-		{
-			name:     "Eq_Int",
-			left:     float64(10),
-			right:    float64(10),
-			kind:     actionlint.CompareOpNodeKindEq,
-			expected: true,
-		},
-		{
-			name:     "NotEq_Int",
-			left:     float64(10),
-			right:    float64(20),
-			kind:     actionlint.CompareOpNodeKindNotEq,
-			expected: true,
-		},
-		{
-			name:     "Less_String",
-			left:     "abc",
-			right:    "def",
-			kind:     actionlint.CompareOpNodeKindLess,
-			expected: true,
-		},
-		{
-			name:     "LessEq_String",
-			left:     "abc",
-			right:    "def",
-			kind:     actionlint.CompareOpNodeKindLessEq,
-			expected: true,
-		},
-		{
-			name:     "Greater_String",
-			left:     "def",
-			right:    "abc",
-			kind:     actionlint.CompareOpNodeKindGreater,
-			expected: true,
-		},
-		{
-			name:     "GreaterEq_String",
-			left:     "def",
-			right:    "abc",
-			kind:     actionlint.CompareOpNodeKindGreaterEq,
-			expected: true,
-		},
-		{
-			name:     "Eq_String",
-			left:     "abc",
-			right:    "abc",
-			kind:     actionlint.CompareOpNodeKindEq,
-			expected: true,
-		},
-		{
-			name:     "NotEq_String",
-			left:     "abc",
-			right:    "def",
-			kind:     actionlint.CompareOpNodeKindNotEq,
-			expected: true,
-		},
-		{
-			name:     "False_Int",
-			left:     float64(20),
-			right:    float64(10),
-			kind:     actionlint.CompareOpNodeKindLess,
-			expected: false,
-		},
-		{
-			name:     "False_String",
-			left:     "def",
-			right:    "abc",
-			kind:     actionlint.CompareOpNodeKindLess,
-			expected: false,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			var result bool
-			var err error
-
-			if _, ok := test.left.(string); ok {
-				result, err = compare(test.left.(string), test.right.(string), test.kind)
-				if err != nil {
-					t.Errorf("Error comparing values: %v", err)
-				}
-			} else {
-				result, err = compare(test.left.(float64), test.right.(float64), test.kind)
-				if err != nil {
-					t.Errorf("Error comparing values: %v", err)
-				}
-			}
-
-			if result != test.expected {
-				t.Errorf("Comparison result mismatch. Expected: %v, Got: %v", test.expected, result)
-			}
-		})
-	}
-}
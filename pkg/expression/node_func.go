@@ -1,12 +1,10 @@
 package expression
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/rhysd/actionlint"
@@ -25,12 +23,27 @@ func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
 	for _, arg := range n.Args {
 		val, err := getInterpreterFromNode(arg).Evaluate(provider)
 		if err != nil {
-			return nil, err
+			return nil, errorAt(n.offset(), err)
 		}
 
 		args = append(args, reflect.ValueOf(val))
 	}
 
+	val, err := n.call(provider, callee, args)
+	if err != nil {
+		return nil, errorAt(n.offset(), err)
+	}
+
+	return val, nil
+}
+
+// offset returns the offset of the function call in the expression.
+func (n FuncCallNode) offset() int {
+	return (*actionlint.FuncCallNode)(&n).Token().Offset
+}
+
+// call calls the function with the evaluated arguments.
+func (n FuncCallNode) call(provider VariableProvider, callee string, args []reflect.Value) (interface{}, error) {
 	switch callee {
 	case "contains":
 		return contains(args...)
@@ -50,7 +63,17 @@ func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
 	case "fromjson":
 		return fromJSON(args...)
 	case "hashfiles":
-		return hashFiles(args...)
+		if len(args) < 1 {
+			return nil, fmt.Errorf("hashFiles() requires at least one argument")
+		}
+
+		patterns := make([]string, 0, len(args))
+
+		for _, arg := range args {
+			patterns = append(patterns, toString(getSafeValue(arg)))
+		}
+
+		return hashFiles(patterns...)
 	case "success":
 		return success(provider)
 	case "failure":
@@ -64,28 +87,29 @@ func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
 	return nil, fmt.Errorf("function '%s' not supported", n.Callee)
 }
 
+// contains returns true if the search array contains the item or the search string contains the item as a substring.
+// Array elements are compared with the loose equality of the == operator, strings are compared case-insensitively.
 func contains(args ...reflect.Value) (bool, error) {
 	if len(args) != 2 {
 		return false, fmt.Errorf("contains() requires two arguments")
 	}
 
-	searchValue := args[0]
-	itemValue := args[1]
+	search, item := indirect(args[0]), args[1]
 
-	if searchValue.Kind() == reflect.String {
-		searchString := searchValue.String()
-		if itemValue.Kind() == reflect.String {
-			return strings.Contains(searchString, itemValue.String()), nil
-		}
-	} else if searchValue.Kind() == reflect.Slice || searchValue.Kind() == reflect.Array {
-		for i := 0; i < searchValue.Len(); i++ {
-			if reflect.DeepEqual(searchValue.Index(i).Interface(), itemValue.Interface()) {
+	if kindOf(search) == kindArray {
+		for i := 0; i < search.Len(); i++ {
+			if looseEqual(search.Index(i), item) {
 				return true, nil
 			}
 		}
+
+		return false, nil
 	}
 
-	return false, nil
+	searchString := strings.ToLower(toString(getSafeValue(search)))
+	itemString := strings.ToLower(toString(getSafeValue(item)))
+
+	return strings.Contains(searchString, itemString), nil
 }
 
 func startsWith(args ...reflect.Value) (bool, error) {
@@ -93,8 +117,8 @@ func startsWith(args ...reflect.Value) (bool, error) {
 		return false, fmt.Errorf("startsWith() requires two arguments")
 	}
 
-	searchString := args[0].String()
-	searchValue := args[1].String()
+	searchString := strings.ToLower(toString(getSafeValue(args[0])))
+	searchValue := strings.ToLower(toString(getSafeValue(args[1])))
 
 	return strings.HasPrefix(searchString, searchValue), nil
 }
@@ -104,83 +128,125 @@ func endsWith(args ...reflect.Value) (bool, error) {
 		return false, fmt.Errorf("endsWith() requires two arguments")
 	}
 
-	searchString := args[0].String()
-	searchValue := args[1].String()
+	searchString := strings.ToLower(toString(getSafeValue(args[0])))
+	searchValue := strings.ToLower(toString(getSafeValue(args[1])))
 
 	return strings.HasSuffix(searchString, searchValue), nil
 }
 
+// format replaces the placeholders {N} in the format string with the string form of the N-th argument. Braces are
+// escaped by doubling them, e.g. `{{0}}` results in `{0}`. It returns an error for malformed placeholders, unmatched
+// braces and placeholders referencing arguments that aren't supplied.
 func format(args ...reflect.Value) (string, error) {
-	if len(args) < 2 {
-		return "", fmt.Errorf("format() requires at least two arguments")
+	if len(args) < 1 {
+		return "", fmt.Errorf("format() requires at least one argument")
 	}
 
-	result := args[0].String()
+	str := toString(getSafeValue(args[0]))
 
-	var values []interface{}
-	if len(args) >= 2 {
-		for i := 1; i < len(args); i++ {
-			values = append(values, args[i].Interface())
-		}
+	values := make([]string, 0, len(args)-1)
+
+	for _, arg := range args[1:] {
+		values = append(values, toString(getSafeValue(arg)))
 	}
 
-	// Replace placeholders {N} with corresponding values
-	for i := 0; i < len(values); i++ {
-		placeholder := fmt.Sprintf("{%d}", i)
-		result = strings.ReplaceAll(result, placeholder, fmt.Sprintf("%v", values[i]))
+	var sb strings.Builder
+
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			// escaped brace
+			if i+1 < len(str) && str[i+1] == '{' {
+				sb.WriteByte('{')
+				i++
+				continue
+			}
+
+			end := strings.IndexByte(str[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("format(): unclosed placeholder at position %d in format string '%s'", i, str)
+			}
+
+			placeholder := str[i : i+end+1]
+
+			index, err := parsePlaceholderIndex(placeholder)
+			if err != nil {
+				return "", fmt.Errorf("format(): invalid placeholder '%s' at position %d in format string '%s'", placeholder, i, str)
+			}
+
+			if index >= len(values) {
+				return "", fmt.Errorf("format(): placeholder '%s' at position %d references argument %d, but only %d argument(s) supplied", placeholder, i, index, len(values))
+			}
+
+			sb.WriteString(values[index])
+			i += end
+		case '}':
+			// escaped brace
+			if i+1 < len(str) && str[i+1] == '}' {
+				sb.WriteByte('}')
+				i++
+				continue
+			}
+
+			return "", fmt.Errorf("format(): unmatched '}' at position %d in format string '%s', use '}}' to escape it", i, str)
+		default:
+			sb.WriteByte(str[i])
+		}
 	}
 
-	// Replace escaped placeholders {{N}} with {N}
-	result = strings.NewReplacer("{{", "{", "}}", "}").Replace(result)
+	return sb.String(), nil
+}
 
-	return result, nil
+// parsePlaceholderIndex returns the argument index of the placeholder in `{N}` form. N must only contain digits.
+func parsePlaceholderIndex(placeholder string) (int, error) {
+	digits := placeholder[1 : len(placeholder)-1]
+
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("placeholder index must be a non-negative integer")
+	}
+
+	return strconv.Atoi(digits)
 }
 
+// join concatenates the elements of the array with the separator. Elements are converted to strings. If the value
+// isn't an array, it's converted to a string.
 func join(args ...reflect.Value) (string, error) {
 	if len(args) < 1 {
 		return "", fmt.Errorf("join() requires at least one argument")
 	}
 
-	var array reflect.Value
-	var separator string
+	array := indirect(args[0])
+	separator := ","
 
-	if len(args) >= 1 {
-		array = args[0]
+	if len(args) >= 2 {
+		separator = toString(getSafeValue(args[1]))
 	}
 
-	if len(args) >= 2 {
-		separator = args[1].String()
-	} else {
-		separator = ","
+	if kindOf(array) != kindArray {
+		return toString(getSafeValue(array)), nil
 	}
 
-	var values []string
+	values := make([]string, 0, array.Len())
 
-	switch array.Kind() {
-	case reflect.String:
-		values = []string{array.String()}
-	case reflect.Slice, reflect.Array:
-		for i := 0; i < array.Len(); i++ {
-			value := array.Index(i).Interface()
-			values = append(values, fmt.Sprintf("%v", value))
-		}
+	for i := 0; i < array.Len(); i++ {
+		values = append(values, toString(getSafeValue(array.Index(i))))
 	}
 
 	return strings.Join(values, separator), nil
 }
 
+// toJSON returns the pretty-printed JSON representation of the value.
 func toJSON(args ...reflect.Value) (string, error) {
 	if len(args) != 1 {
 		return "", fmt.Errorf("toJSON() requires exactly one argument")
 	}
 
-	value := args[0].Interface()
-	jsonBytes, err := json.Marshal(value)
+	result, err := toJSONString(getSafeValue(args[0]))
 	if err != nil {
-		panic(fmt.Sprintf("Failed to convert value to JSON: %v", err))
+		return "", fmt.Errorf("failed to convert value to JSON: %w", err)
 	}
 
-	return string(jsonBytes), nil
+	return result, nil
 }
 
 func fromJSON(args ...reflect.Value) (interface{}, error) {
@@ -194,53 +260,16 @@ func fromJSON(args ...reflect.Value) (interface{}, error) {
 
 	err := json.Unmarshal([]byte(jsonString), &value)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to parse JSON: %v", err))
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
 	}
 
 	return value, nil
 }
 
-// TODO: double check this how GHA handles this. This is just a some hacky implementation to get it working
-
-func hashFiles(args ...reflect.Value) (string, error) {
-	if len(args) < 1 {
-		return "", fmt.Errorf("hashFiles() requires at least one argument")
-	}
-
-	paths := make([]string, 0, len(args))
-
-	for _, arg := range args {
-		paths = append(paths, arg.String())
-	}
-
-	var fileHashes []string
-
-	for _, path := range paths {
-		matches, err := filepath.Glob(path)
-		if err != nil {
-			return "", err
-		}
-
-		for _, match := range matches {
-			fileBytes, err := os.ReadFile(match)
-			if err != nil {
-				return "", err
-			}
-
-			hash := sha256.Sum256(fileBytes)
-			fileHashes = append(fileHashes, fmt.Sprintf("%x", hash))
-		}
-	}
-
-	return strings.Join(fileHashes, ""), nil
-}
-
 func always() bool {
 	return true
 }
 
-// TODO: double check this how GHA handles this. This is just a some hacky implementation to get it working
-
 func success(provider VariableProvider) (bool, error) {
 	return evaluateStatusFunc(provider, "success")
 }
@@ -253,20 +282,36 @@ func cancelled(provider VariableProvider) (bool, error) {
 	return evaluateStatusFunc(provider, "cancelled")
 }
 
+// evaluateStatusFunc compares the current status with the given status.
 func evaluateStatusFunc(provider VariableProvider, status string) (bool, error) {
-	expr, err := NewExpression(fmt.Sprintf("${{ env.job.status == '%s' }}", status))
+	current, err := currentStatus(provider)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("cannot evaluate expression: %s(): %w", status, err)
 	}
 
-	val, err := expr.Evaluate(provider)
-	if err != nil {
-		return false, err
-	}
+	return current == status, nil
+}
 
-	if v, ok := val.(bool); ok {
-		return v, nil
+// currentStatus returns the status checked by the status functions. Composite actions have their own scope, so the
+// status of the composite action from `github.action_status` is used if it's set, otherwise the job status from
+// `job.status` is used.
+func currentStatus(provider VariableProvider) (string, error) {
+	for _, name := range []string{"github.action_status", "job.status"} {
+		expr, err := NewExpression(name)
+		if err != nil {
+			return "", err
+		}
+
+		// evaluate the interpreter directly, errors are reported at the position of the status function
+		val, err := expr.interpreter.Evaluate(provider)
+		if err != nil {
+			return "", err
+		}
+
+		if v, ok := val.(string); ok && v != "" {
+			return v, nil
+		}
 	}
 
-	return false, fmt.Errorf("cannot evaluate expression: cancelled()")
+	return "", fmt.Errorf("job status is not available")
 }
@@ -28,7 +28,8 @@ func (n BoolNode) Evaluate(_ VariableProvider) (interface{}, error) {
 type IntNode actionlint.IntNode
 
 func (n IntNode) Evaluate(_ VariableProvider) (interface{}, error) {
-	return n.Value, nil
+	// all numbers are float64 in the value model
+	return float64(n.Value), nil
 }
 
 // FloatNode is a wrapper of actionlint.FloatNode
@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"math"
 	"reflect"
 
 	"github.com/rhysd/actionlint"
@@ -17,7 +18,12 @@ var (
 type VariableNode actionlint.VariableNode
 
 func (n VariableNode) Evaluate(p VariableProvider) (interface{}, error) {
-	return p.GetVariable(n.Name)
+	value, err := p.GetVariable(n.Name)
+	if err != nil {
+		return nil, errorAt((*actionlint.VariableNode)(&n).Token().Offset, err)
+	}
+
+	return normalize(value), nil
 }
 
 // ObjectDerefNode is a wrapper of actionlint.ObjectDerefNode
@@ -44,7 +50,7 @@ func (n ArrayDerefNode) Evaluate(provider VariableProvider) (interface{}, error)
 		return nil, err
 	}
 
-	return getSafeValue(reflect.ValueOf(left)), nil
+	return filterValue(reflect.ValueOf(left)), nil
 }
 
 // IndexAccessNode is a wrapper of actionlint.IndexAccessNode
@@ -63,25 +69,49 @@ func (n IndexAccessNode) Evaluate(provider VariableProvider) (interface{}, error
 		return nil, err
 	}
 
-	// get values from the receiver and the index
-	leftValue := reflect.ValueOf(left)
-	rightValue := reflect.ValueOf(right)
+	return getIndexValue(reflect.ValueOf(left), reflect.ValueOf(right))
+}
+
+// getIndexValue returns the value at the index of the receiver. String indexes are property names and number indexes
+// are array indexes. On a filtered array, the index is applied to each element. It returns nil for missing values.
+func getIndexValue(left, index reflect.Value) (interface{}, error) {
+	left = indirect(left)
 
-	// evaluate the index value
-	switch rightValue.Kind() {
-	case reflect.String:
-		return getPropertyValue(leftValue, rightValue.String())
-	case reflect.Int:
-		if leftValue.Kind() != reflect.Slice {
-			return nil, nil
+	if !left.IsValid() {
+		return nil, nil
+	}
+
+	if fa, ok := left.Interface().(filteredArray); ok {
+		values := filteredArray{}
+
+		for _, item := range fa {
+			value, err := getIndexValue(reflect.ValueOf(item), index)
+			if err != nil {
+				return nil, err
+			}
+
+			if value != nil {
+				values = append(values, value)
+			}
 		}
 
-		index := int(rightValue.Int())
-		length := leftValue.Len()
+		return values, nil
+	}
+
+	switch kindOf(index) {
+	case kindString:
+		return getPropertyValue(left, indirect(index).String())
+	case kindNumber:
+		if left.Kind() != reflect.Slice && left.Kind() != reflect.Array {
+			return nil, nil
+		}
 
-		if index >= 0 && index < length {
-			return leftValue.Index(index).Interface(), nil
+		number := toNumber(index)
+		if math.IsNaN(number) || number < 0 || number >= float64(left.Len()) {
+			return nil, nil
 		}
+
+		return unwrapValue(left.Index(int(number)))
 	}
 
 	// fallback to nil
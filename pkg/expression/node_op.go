@@ -23,7 +23,7 @@ func (n NotOpNode) Evaluate(provider VariableProvider) (interface{}, error) {
 		return nil, err
 	}
 
-	return !isTruthy(operand), nil
+	return !IsTruthy(operand), nil
 }
 
 // CompareOpNode is a wrapper of actionlint.CompareOpNode
@@ -58,33 +58,26 @@ func (n LogicalOpNode) Evaluate(provider VariableProvider) (interface{}, error)
 		return nil, err
 	}
 
-	leftValue := reflect.ValueOf(left)
-
-	// convert right operand to wrapper type and call Evaluate() to get the receiver value
-	right, err := getInterpreterFromNode(n.Right).Evaluate(provider)
-	if err != nil {
-		return nil, err
-	}
-
-	rightValue := reflect.ValueOf(right)
-
-	// look at the left operand first to determine the result of the logical operator. No need to evaluate the right
-	// operand if the result is already determined.
+	// look at the left operand first to determine the result of the logical operator. The right operand is evaluated
+	// only if the result isn't determined yet, so it can rely on the left one, e.g. `a && fromJSON(a).b`.
 	switch n.Kind {
 	case actionlint.LogicalOpNodeKindAnd:
-		if isTruthy(left) {
-			return getSafeValue(rightValue), nil
+		if !IsTruthy(left) {
+			return getSafeValue(reflect.ValueOf(left)), nil
 		}
-
-		return getSafeValue(leftValue), nil
-
 	case actionlint.LogicalOpNodeKindOr:
-		if isTruthy(left) {
-			return getSafeValue(leftValue), nil
+		if IsTruthy(left) {
+			return getSafeValue(reflect.ValueOf(left)), nil
 		}
+	default:
+		return nil, fmt.Errorf("invalid logical operator node")
+	}
 
-		return getSafeValue(rightValue), nil
+	// convert right operand to wrapper type and call Evaluate() to get the receiver value
+	right, err := getInterpreterFromNode(n.Right).Evaluate(provider)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("invalid logical operator node")
+	return getSafeValue(reflect.ValueOf(right)), nil
 }
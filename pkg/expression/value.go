@@ -0,0 +1,215 @@
+package expression
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Values in expressions are represented with a small set of Go types, same as the JSON data model:
+//
+//	null   -> nil
+//	bool   -> bool
+//	number -> float64
+//	string -> string
+//	array  -> []interface{} (or filteredArray, result of the object filter)
+//	object -> map[string]interface{}
+//
+// Contexts are converted into this model when they're accessed, so values behave the same regardless of their source,
+// e.g. a struct context with json tags, a map[string]string or the result of fromJSON.
+
+// toValue converts the Go value into the expression value model. Structs are converted into objects with their json
+// names, values implementing encoding.TextMarshaler into strings and all numeric types into float64.
+func toValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case bool, float64, string:
+		return val
+	case filteredArray:
+		result := make(filteredArray, 0, len(val))
+
+		for _, item := range val {
+			result = append(result, toValue(item))
+		}
+
+		return result
+	case encoding.TextMarshaler:
+		text, err := val.MarshalText()
+		if err != nil {
+			return nil
+		}
+
+		return string(text)
+	}
+
+	value := indirect(reflect.ValueOf(v))
+
+	if !value.IsValid() {
+		return nil
+	}
+
+	// pointers might implement TextMarshaler on the value receiver
+	if reflect.ValueOf(v).Kind() == reflect.Ptr && value.CanInterface() {
+		return toValue(value.Interface())
+	}
+
+	switch kindOf(value) {
+	case kindBool:
+		return value.Bool()
+	case kindNumber:
+		return toNumber(value)
+	case kindString:
+		return value.String()
+	case kindArray:
+		// []byte is a string in JSON
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes())
+		}
+
+		result := make([]interface{}, 0, value.Len())
+
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, toValue(value.Index(i).Interface()))
+		}
+
+		return result
+	}
+
+	result := make(map[string]interface{})
+
+	switch value.Kind() {
+	case reflect.Map:
+		iter := value.MapRange()
+
+		for iter.Next() {
+			result[fmt.Sprint(iter.Key().Interface())] = toValue(iter.Value().Interface())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if name, ok := fieldName(value.Type().Field(i)); ok {
+				result[name] = toValue(value.Field(i).Interface())
+			}
+		}
+	default:
+		// functions, channels etc. aren't representable in expressions
+		return nil
+	}
+
+	return result
+}
+
+// normalize converts scalar values into the value model. Arrays and objects are only de-referenced, so they keep
+// their identity and are converted lazily when their elements are accessed.
+func normalize(v interface{}) interface{} {
+	if _, ok := v.(encoding.TextMarshaler); ok {
+		return toValue(v)
+	}
+
+	value := indirect(reflect.ValueOf(v))
+
+	switch kindOf(value) {
+	case kindArray, kindObject:
+		if !value.CanInterface() || (value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8) {
+			return toValue(v)
+		}
+
+		if _, ok := value.Interface().(encoding.TextMarshaler); ok {
+			return toValue(v)
+		}
+
+		return value.Interface()
+	default:
+		return toValue(v)
+	}
+}
+
+// toString converts the value to a string, same as the runner. null is an empty string, numbers are formatted with
+// up to 15 significant digits, arrays and objects are 'Array' and 'Object'.
+func toString(v interface{}) string {
+	value := indirect(reflect.ValueOf(v))
+
+	switch kindOf(value) {
+	case kindNull:
+		return ""
+	case kindBool:
+		return strconv.FormatBool(value.Bool())
+	case kindNumber:
+		return formatNumber(toNumber(value))
+	case kindString:
+		return value.String()
+	case kindArray:
+		return "Array"
+	default:
+		return "Object"
+	}
+}
+
+// formatNumber formats the number same as the runner.
+func formatNumber(number float64) string {
+	switch {
+	case math.IsNaN(number):
+		return "NaN"
+	case math.IsInf(number, 1):
+		return "Infinity"
+	case math.IsInf(number, -1):
+		return "-Infinity"
+	case number == 0:
+		// avoid -0
+		return "0"
+	default:
+		return strconv.FormatFloat(number, 'G', 15, 64)
+	}
+}
+
+// toJSONString converts the value to pretty-printed JSON with two spaces indentation, same as the runner.
+func toJSONString(v interface{}) (string, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(normalizeJSON(toValue(v))); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// normalizeJSON replaces the numbers JSON can't represent with their string forms, so encoding never fails.
+func normalizeJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return formatNumber(val)
+		}
+
+		return val
+	case filteredArray:
+		return normalizeJSON([]interface{}(val))
+	case []interface{}:
+		result := make([]interface{}, 0, len(val))
+
+		for _, item := range val {
+			result = append(result, normalizeJSON(item))
+		}
+
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+
+		for k, item := range val {
+			result[k] = normalizeJSON(item)
+		}
+
+		return result
+	default:
+		return val
+	}
+}
@@ -0,0 +1,259 @@
+package expression
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testTextMarshaler struct{ value string }
+
+func (m testTextMarshaler) MarshalText() ([]byte, error) {
+	return []byte(m.value), nil
+}
+
+func TestToValue(t *testing.T) {
+	type Nested struct {
+		Name string `json:"name"`
+	}
+
+	type TestStruct struct {
+		Field1  string            `json:"field_1"`
+		Field2  int               `json:"field_2"`
+		Field3  *Nested           `json:"field_3"`
+		Field4  []string          `json:"field_4"`
+		Field5  map[string]string `json:"field_5"`
+		Ignored string            `json:"-"`
+		private string
+	}
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected interface{}
+	}{
+		{name: "Null", input: nil, expected: nil},
+		{name: "Nil pointer", input: (*Nested)(nil), expected: nil},
+		{name: "Bool", input: true, expected: true},
+		{name: "Int", input: 42, expected: float64(42)},
+		{name: "Uint8", input: uint8(8), expected: float64(8)},
+		{name: "Float32", input: float32(1.5), expected: float64(1.5)},
+		{name: "String", input: "foo", expected: "foo"},
+		{name: "Bytes", input: []byte("foo"), expected: "foo"},
+		{name: "TextMarshaler", input: testTextMarshaler{value: "text"}, expected: "text"},
+		{name: "TextMarshaler pointer", input: &testTextMarshaler{value: "text"}, expected: "text"},
+		{name: "Slice", input: []int{1, 2}, expected: []interface{}{float64(1), float64(2)}},
+		{name: "Filtered array", input: filteredArray{1, "a"}, expected: filteredArray{float64(1), "a"}},
+		{name: "Map", input: map[string]int{"a": 1}, expected: map[string]interface{}{"a": float64(1)}},
+		{
+			name: "Struct",
+			input: &TestStruct{
+				Field1:  "value",
+				Field2:  1,
+				Field3:  &Nested{Name: "nested"},
+				Field4:  []string{"a"},
+				Field5:  map[string]string{"k": "v"},
+				Ignored: "ignored",
+				private: "private",
+			},
+			expected: map[string]interface{}{
+				"field_1": "value",
+				"field_2": float64(1),
+				"field_3": map[string]interface{}{"name": "nested"},
+				"field_4": []interface{}{"a"},
+				"field_5": map[string]interface{}{"k": "v"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := toValue(test.input)
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Unexpected value. Expected: %#v, Got: %#v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestToString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{name: "Null", input: nil, expected: ""},
+		{name: "Bool true", input: true, expected: "true"},
+		{name: "Bool false", input: false, expected: "false"},
+		{name: "Int", input: 42, expected: "42"},
+		{name: "Float", input: 3.14, expected: "3.14"},
+		{name: "Float integer", input: float64(10), expected: "10"},
+		{name: "Negative zero", input: math.Copysign(0, -1), expected: "0"},
+		{name: "Large number", input: 1e21, expected: "1E+21"},
+		{name: "NaN", input: math.NaN(), expected: "NaN"},
+		{name: "Infinity", input: math.Inf(1), expected: "Infinity"},
+		{name: "Negative infinity", input: math.Inf(-1), expected: "-Infinity"},
+		{name: "String", input: "foo", expected: "foo"},
+		{name: "Array", input: []interface{}{"a"}, expected: "Array"},
+		{name: "Object", input: map[string]interface{}{}, expected: "Object"},
+		{name: "Struct", input: struct{}{}, expected: "Object"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := toString(test.input)
+
+			if result != test.expected {
+				t.Errorf("Unexpected value. Expected: %q, Got: %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestToJSONString(t *testing.T) {
+	type TestStruct struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{name: "Null", input: nil, expected: "null"},
+		{name: "Number", input: 1, expected: "1"},
+		{name: "String", input: "<foo>", expected: `"<foo>"`},
+		{name: "Empty array", input: []interface{}{}, expected: "[]"},
+		{name: "Empty object", input: map[string]interface{}{}, expected: "{}"},
+		{name: "NaN", input: math.NaN(), expected: `"NaN"`},
+		{
+			name:     "Struct",
+			input:    TestStruct{Name: "foo", Count: 2},
+			expected: "{\n  \"count\": 2,\n  \"name\": \"foo\"\n}",
+		},
+		{
+			name:     "Map",
+			input:    map[string]string{"name": "foo", "count": "2"},
+			expected: "{\n  \"count\": \"2\",\n  \"name\": \"foo\"\n}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := toJSONString(test.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != test.expected {
+				t.Errorf("Unexpected value. Expected: %q, Got: %q", test.expected, result)
+			}
+		})
+	}
+}
+
+// TestExpression_ValueConsistency checks that functions behave the same regardless of the source of the value.
+func TestExpression_ValueConsistency(t *testing.T) {
+	provider := &valueProvider{
+		variables: map[string]interface{}{
+			"structs": []struct {
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			}{{Name: "Foo", Count: 1}, {Name: "Bar", Count: 2}},
+			"maps": []map[string]string{{"name": "Foo", "count": "1"}, {"name": "Bar", "count": "2"}},
+			"json": []interface{}{
+				map[string]interface{}{"name": "Foo", "count": float64(1)},
+				map[string]interface{}{"name": "Bar", "count": float64(2)},
+			},
+		},
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"join(%s.*.name, ', ')", "Foo, Bar"},
+		{"join(%s.*.count)", "1,2"},
+		{"contains(%s.*.name, 'foo')", true},
+		{"contains(%s.*.count, 2)", true},
+		{"contains(%s.*.count, '2')", true},
+		{"contains(join(%s.*.name), 'OO,B')", true},
+		{"format('{0}-{1}', %s[0].name, %s[1].count)", "Foo-2"},
+		{"%s[0].count == 1", true},
+		{"%s[1].NAME == 'bar'", true},
+	}
+
+	for _, tt := range tests {
+		for _, name := range []string{"structs", "maps", "json"} {
+			input := strings.ReplaceAll(tt.input, "%s", name)
+
+			t.Run(input, func(t *testing.T) {
+				expr, err := NewExpression(input)
+				if err != nil {
+					t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), input)
+				}
+
+				result, err := expr.Evaluate(provider)
+				if err != nil {
+					t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), input)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %v, but got %v for input: %s", tt.expected, result, input)
+				}
+			})
+		}
+	}
+}
+
+func TestExpression_StringFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"contains('Hello World', 'WORLD')", true},
+		{"contains('abc', '')", true},
+		{"contains(fromJSON('[1, 2]'), 1)", true},
+		{"contains(fromJSON('[\"A\"]'), 'a')", true},
+		{"contains(fromJSON('[[1]]'), 1)", false},
+		{"contains(123, 2)", true},
+		{"startsWith('Hello', 'HE')", true},
+		{"startsWith(true, 't')", true},
+		{"endsWith('Hello', 'LO')", true},
+		{"endsWith(1.5, '.5')", true},
+		{"format('{0} {1} {2} {3}', null, true, 1.50, fromJSON('{}'))", " true 1.5 Object"},
+		{"join(fromJSON('[1, null, true, [1]]'), '-')", "1--true-Array"},
+		{"join('foo', '-')", "foo"},
+		{"join(1)", "1"},
+		{"toJSON(fromJSON('{\"a\": [1, 2.5]}'))", "{\n  \"a\": [\n    1,\n    2.5\n  ]\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result, err := expr.Evaluate(nil)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected %q, but got %q for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+type valueProvider struct {
+	variables map[string]interface{}
+}
+
+func (p *valueProvider) GetVariable(name string) (interface{}, error) {
+	return p.variables[name], nil
+}
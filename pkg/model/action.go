@@ -1,12 +1,20 @@
 package model
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -40,7 +48,7 @@ type Action struct {
 	Inputs map[string]ActionInput `yaml:"inputs"`
 
 	// Outputs is a map of output names to their definitions.
-	Outputs map[string]ActionInput `yaml:"outputs"`
+	Outputs map[string]ActionOutput `yaml:"outputs"`
 
 	// Runs is the definition of how the action is run.
 	Runs ActionRuns `yaml:"runs"`
@@ -50,6 +58,13 @@ type Action struct {
 
 	// Directory is the directory where source files for the action are located.
 	Directory *dagger.Directory `yaml:"-"`
+
+	// Commit is the commit SHA the action ref resolved to. It's empty for local actions.
+	Commit string `yaml:"-"`
+
+	// Replacement is the source the action is loaded from instead of the original source. It's empty if the action
+	// source is not replaced.
+	Replacement string `yaml:"-"`
 }
 
 // ActionInput represents an input for a GitHub Action.
@@ -67,12 +82,118 @@ type ActionInput struct {
 	DeprecationMessage string `yaml:"deprecationMessage"`
 }
 
+// InputEnvName returns the name of the environment variable for the given input. The name is normalized the same
+// way the runner does, spaces are replaced with `_` and the name is converted to uppercase.
+func InputEnvName(name string) string {
+	return fmt.Sprintf("INPUT_%s", strings.ToUpper(strings.ReplaceAll(name, " ", "_")))
+}
+
+// LookupInput returns the value of the input from the given `with` values. Input names are case-insensitive.
+func LookupInput(with map[string]string, name string) (string, bool) {
+	if v, ok := with[name]; ok {
+		return v, true
+	}
+
+	for k, v := range with {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// ValidateInputs validates the given `with` values against the inputs declared by the action. Missing required inputs
+// without default values are returned as error. Unexpected and deprecated inputs are returned as warnings with the same
+// messages as the runner.
+func (a *Action) ValidateInputs(with map[string]string) ([]string, error) {
+	var (
+		warnings   []string
+		missing    []string
+		unexpected []string
+		valid      []string
+	)
+
+	for name, input := range a.Inputs {
+		valid = append(valid, name)
+
+		_, ok := LookupInput(with, name)
+
+		if ok && input.DeprecationMessage != "" {
+			warnings = append(warnings, fmt.Sprintf("Input '%s' has been deprecated with message: %s", name, input.DeprecationMessage))
+		}
+
+		if !ok && input.Required && input.Default == "" {
+			missing = append(missing, name)
+		}
+	}
+
+	for name := range with {
+		if _, ok := LookupInput(a.inputNames(), name); !ok {
+			unexpected = append(unexpected, name)
+		}
+	}
+
+	sort.Strings(warnings)
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	sort.Strings(valid)
+
+	if len(unexpected) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Unexpected input(s) %s, valid inputs are [%s]", quoteJoin(unexpected), quoteJoin(valid)))
+	}
+
+	if len(missing) > 0 {
+		return warnings, fmt.Errorf("Input required and not supplied: %s", strings.Join(missing, ", ")) //nolint:revive,stylecheck // keep the message same as the toolkit
+	}
+
+	return warnings, nil
+}
+
+// UndeclaredOutputs returns the names of the given outputs that are not declared by the action in sorted order.
+func (a *Action) UndeclaredOutputs(outputs map[string]string) []string {
+	var undeclared []string
+
+	for name := range outputs {
+		if _, ok := a.Outputs[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+
+	sort.Strings(undeclared)
+
+	return undeclared
+}
+
+// inputNames returns the declared input names as a map to look up case-insensitively.
+func (a *Action) inputNames() map[string]string {
+	names := make(map[string]string, len(a.Inputs))
+
+	for name := range a.Inputs {
+		names[name] = name
+	}
+
+	return names
+}
+
+// quoteJoin joins the values as comma separated single-quoted list.
+func quoteJoin(values []string) string {
+	quoted := make([]string, 0, len(values))
+
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("'%s'", v))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
 // ActionOutput represents an output for a GitHub Action.
 type ActionOutput struct {
 	// Description is the description of the output.
 	Description string `yaml:"description"`
 
-	// Value is the value of the output.
+	// Value is the value of the output. This is only used by composite actions and can contain expressions evaluated
+	// against the steps context of the composite action.
 	Value string `yaml:"value"`
 }
 
@@ -128,6 +249,9 @@ var (
 	// ActionRunsUsingDocker is the value for ActionRunsUsing when the action is a docker action.
 	ActionRunsUsingDocker ActionRunsUsing = "docker"
 
+	// ActionRunsUsingNode20 is the value for ActionRunsUsing when the action is a javascript action using node 20.
+	ActionRunsUsingNode20 ActionRunsUsing = "node20"
+
 	// ActionRunsUsingNode16 is the value for ActionRunsUsing when the action is a javascript action using node 16.
 	ActionRunsUsingNode16 ActionRunsUsing = "node16"
 
@@ -149,7 +273,7 @@ func (a *ActionRunsUsing) UnmarshalYAML(unmarshal func(interface{}) error) error
 
 	// unmarshal all unsupported values as invalid
 	switch using {
-	case ActionRunsUsingComposite, ActionRunsUsingDocker, ActionRunsUsingNode16, ActionRunsUsingNode12:
+	case ActionRunsUsingComposite, ActionRunsUsingDocker, ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
 		*a = using
 	default:
 		return fmt.Errorf("invalid value for using: %s", using)
@@ -158,6 +282,16 @@ func (a *ActionRunsUsing) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return nil
 }
 
+// IsNode returns true if the action is a javascript action running on node.
+func (a ActionRunsUsing) IsNode() bool {
+	switch a {
+	case ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
+		return true
+	default:
+		return false
+	}
+}
+
 // Branding represents the branding information for a GitHub Action.
 type Branding struct {
 	// Color is the color of the action.
@@ -167,15 +301,159 @@ type Branding struct {
 	Icon string `yaml:"icon"`
 }
 
+const (
+	defaultServerURL  = "https://github.com" // URL of the public GitHub server
+	defaultServerHost = "github.com"         // host of the public GitHub server
+)
+
+// ActionSourceOptions represents the options to resolve remote action sources.
+type ActionSourceOptions struct {
+	// ServerURL is the URL of the GitHub server to resolve actions from, e.g. https://github.example.com. If the server
+	// is not github.com, actions are looked up in the server first and then in github.com, same as the runner.
+	ServerURL string
+
+	// Token is the token to access private action repositories on ServerURL. The token is never sent to github.com
+	// when ServerURL is an enterprise server.
+	Token string
+
+	// Replacements is the map of action sources to the sources to load instead, similar to replace directives of
+	// go.mod. Keys are either exact sources, e.g. actions/checkout@v3, or sources without ref, e.g. actions/checkout,
+	// to replace all refs. Values are local paths or remote sources.
+	Replacements map[string]string
+}
+
+// Replace returns the source to load for the given source after applying replacements. Exact matches take precedence
+// over matches without ref. Replacements are not transitive, the result is never replaced again.
+func (o ActionSourceOptions) Replace(src string) (string, bool) {
+	if replacement, ok := o.Replacements[src]; ok {
+		return replacement, true
+	}
+
+	if i := strings.LastIndex(src, "@"); i > 0 {
+		if replacement, ok := o.Replacements[src[:i]]; ok {
+			return replacement, true
+		}
+	}
+
+	return src, false
+}
+
+// ValidateActionReplacement validates the replace directive from source to replacement.
+func ValidateActionReplacement(src, replacement string) error {
+	if IsLocalActionSource(src) {
+		return fmt.Errorf("invalid replace %s: local actions can't be replaced", src)
+	}
+
+	// source without ref replaces all refs of the action
+	if !strings.Contains(src, "@") {
+		if _, _, _, err := parseRepoRef(src + "@ref"); err != nil {
+			return fmt.Errorf("invalid replace %s: %v", src, err)
+		}
+	} else if _, _, _, err := parseRepoRef(src); err != nil {
+		return fmt.Errorf("invalid replace %s: %v", src, err)
+	}
+
+	if IsLocalActionSource(replacement) {
+		return nil
+	}
+
+	if _, _, _, err := parseRepoRef(replacement); err != nil {
+		return fmt.Errorf("invalid replacement %s for %s, it must be a local path starting with ./ or a remote action with ref: %v", replacement, src, err)
+	}
+
+	return nil
+}
+
+// gitRemote is a remote git repository to fetch actions from.
+type gitRemote struct {
+	URL  string // URL is the URL of the repository without credentials.
+	Host string // Host is the host of the server the repository is on.
+	Auth bool   // Auth is true if the repository is accessed with the token of the options.
+}
+
+// gitRemotes returns the git remotes to try in order to fetch the given repository. URLs never contain the token, it's
+// passed to git as a secret, since URLs end up in dagger queries, cache keys and progress logs.
+func (o ActionSourceOptions) gitRemotes(repo string) ([]gitRemote, error) {
+	serverURL := strings.TrimSuffix(o.ServerURL, "/")
+	if serverURL == "" {
+		serverURL = defaultServerURL
+	}
+
+	server, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server url %s: %v", o.ServerURL, err)
+	}
+
+	if server.Scheme == "" || server.Host == "" {
+		return nil, fmt.Errorf("invalid server url %s: scheme and host are required", o.ServerURL)
+	}
+
+	primary := *server
+	primary.Path = path.Join(primary.Path, repo)
+
+	remotes := []gitRemote{{URL: primary.String(), Host: strings.ToLower(server.Host), Auth: o.Token != ""}}
+
+	// fallback to github.com for enterprise servers
+	if !strings.EqualFold(server.Host, defaultServerHost) {
+		remotes = append(remotes, gitRemote{URL: fmt.Sprintf("%s/%s", defaultServerURL, repo), Host: defaultServerHost})
+	}
+
+	return remotes, nil
+}
+
+// Hosts returns the hosts of the servers actions are looked up in order.
+func (o ActionSourceOptions) Hosts() ([]string, error) {
+	remotes, err := o.gitRemotes("")
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0, len(remotes))
+
+	for _, remote := range remotes {
+		hosts = append(hosts, remote.Host)
+	}
+
+	return hosts, nil
+}
+
+// redact masks the token in the given error, since git errors may contain the url of the repository.
+func (o ActionSourceOptions) redact(err error) error {
+	if o.Token == "" || !strings.Contains(err.Error(), o.Token) {
+		return err
+	}
+
+	return errors.New(strings.ReplaceAll(err.Error(), o.Token, "***"))
+}
+
 // LoadActionFromSource loads an action from given source. Source can be a local directory or a remote repository.
-func LoadActionFromSource(ctx context.Context, client *dagger.Client, src string) (*Action, error) {
-	var dir *dagger.Directory
+//
+// If the source is replaced in the options, the action is loaded from the replacement instead.
+func LoadActionFromSource(ctx context.Context, client *dagger.Client, src string, opts ActionSourceOptions) (*Action, error) {
+	target, replaced := opts.Replace(src)
 
-	dir, dirErr := getActionDirectory(client, src)
+	dir, _, commit, dirErr := ResolveActionDirectory(ctx, client, target, opts)
 	if dirErr != nil {
 		return nil, dirErr
 	}
 
+	action, err := LoadActionFromDirectory(ctx, dir, target)
+	if err != nil {
+		return nil, err
+	}
+
+	action.Commit = commit
+
+	if replaced {
+		action.Replacement = target
+	}
+
+	return action, nil
+}
+
+// LoadActionFromDirectory loads the action metadata from the given action directory. Source is only used for error
+// messages.
+func LoadActionFromDirectory(ctx context.Context, dir *dagger.Directory, src string) (*Action, error) {
 	file, findActionErr := findActionMetadataFileName(ctx, dir)
 	if findActionErr != nil {
 		return nil, findActionErr
@@ -197,29 +475,301 @@ func LoadActionFromSource(ctx context.Context, client *dagger.Client, src string
 	return &action, nil
 }
 
-// getActionDirectory returns the directory of the action from given source.
-func getActionDirectory(client *dagger.Client, src string) (*dagger.Directory, error) {
+// IsLocalActionSource returns true if the source is a path on the host instead of a remote repository.
+func IsLocalActionSource(src string) bool {
+	return strings.HasPrefix(src, "./") || filepath.IsAbs(src) || strings.HasPrefix(src, "/")
+}
+
+// ParseActionSource parses a remote action source in the format "{owner}/{repo}/{path}@{ref}". If {path} is not
+// present, "." is returned as the path to point the root of the repository.
+func ParseActionSource(src string) (repo, path, ref string, err error) {
+	repo, path, ref, err = parseRepoRef(src)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse repo ref %s: %v", src, err)
+	}
+
+	if path == "" {
+		path = "."
+	}
+
+	return repo, path, ref, nil
+}
+
+// ResolveActionDirectory returns the directory of the action, the host of the server the action is fetched from and
+// the commit SHA the ref resolved to from given source. For local actions, the host and the commit are empty. Remote
+// repositories and refs are verified before returning, so the next server is tried only for missing actions.
+// Replacements are not applied, callers should resolve the source with ActionSourceOptions.Replace first.
+func ResolveActionDirectory(ctx context.Context, client *dagger.Client, src string, opts ActionSourceOptions) (dir *dagger.Directory, host, commit string, err error) {
 	// if path is relative, use the host to resolve the path
-	if strings.HasPrefix(src, "./") || filepath.IsAbs(src) || strings.HasPrefix(src, "/") {
-		return client.Host().Directory(src), nil
+	if IsLocalActionSource(src) {
+		return client.Host().Directory(src), "", "", nil
 	}
 
 	// if path is not a relative path, it must be a remote repository in the format "{owner}/{repo}/{path}@{ref}"
-	// if {path} is not present in the input string, an empty string is returned for the path component.
+	actionRepo, actionPath, actionRef, err := ParseActionSource(src)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	remotes, err := opts.gitRemotes(actionRepo)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	// try servers in order and return the first one resolves the ref
+	var errs []error
+
+	for _, remote := range remotes {
+		tree, commit, err := fetchGitRemote(ctx, client, remote, src, actionRef, opts.Token)
+		if err == nil {
+			return tree.Directory(actionPath), remote.Host, commit, nil
+		}
+
+		errs = append(errs, opts.redact(err))
+
+		// only missing actions fallback to the next server, other failures like network or authentication errors
+		// would hide the actual problem
+		if !isActionNotFound(err) {
+			break
+		}
+	}
+
+	return nil, "", "", errors.Join(errs...)
+}
+
+// fetchGitRemote returns the tree of the given ref of the remote and the commit SHA the ref resolved to. The
+// repository and the ref are verified before returning, so missing actions are reported here as actionNotFoundError
+// instead of failing later when the directory is used.
+func fetchGitRemote(ctx context.Context, client *dagger.Client, remote gitRemote, src, ref, token string) (*dagger.Directory, string, error) {
+	refs, err := probeGitRemote(ctx, remote, src, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if remote.Auth {
+		return fetchAuthenticatedGitRemote(ctx, client, remote.URL, src, ref, token)
+	}
+
+	// the commit SHA is resolved from the refs advertised by the server, the same refs git ls-remote lists, since the
+	// digest of a dagger git ref is a content digest instead of the commit SHA
+	commit := ref
+
+	if !isFullCommitSHA(ref) {
+		if commit, err = lookupRemoteRef(refs, src, ref); err != nil {
+			return nil, "", err
+		}
+	}
+
+	tree := client.Git(remote.URL).Commit(commit).Tree()
+
+	// fetch the commit eagerly, the repository is already probed, so failing to fetch it means it doesn't exist
+	if _, err := tree.Entries(ctx); err != nil {
+		return nil, "", &actionNotFoundError{msg: fmt.Sprintf("unable to resolve action `%s`, unable to find version `%s`: %v", src, ref, err)}
+	}
+
+	return tree, commit, nil
+}
+
+// probeGitRemote checks the repository exists on the remote with the smart HTTP discovery request git sends before
+// fetching and returns the map of ref name to SHA advertised by the server. Servers answer 404 for missing repositories
+// and 401 to anonymous requests for repositories that are missing or private, both are reported as
+// actionNotFoundError.
+func probeGitRemote(ctx context.Context, remote gitRemote, src, token string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, remote.URL+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if remote.Auth {
+		req.SetBasicAuth("x-access-token", token)
+	}
 
-	actionRepo, actionPath, actionRef, err := parseRepoRef(src)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse repo ref %s: %v", src, err)
+		return nil, fmt.Errorf("failed to access repository of %s: %v", src, err)
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		refs, err := parseRefAdvertisement(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list refs of %s on %s: %v", src, remote.Host, err)
+		}
+
+		return refs, nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnauthorized && !remote.Auth:
+		return nil, &actionNotFoundError{msg: fmt.Sprintf("unable to resolve action `%s`, repository not found on %s", src, remote.Host)}
+	default:
+		return nil, fmt.Errorf("failed to access repository of %s on %s: %s", src, remote.Host, resp.Status)
 	}
+}
+
+// parseRefAdvertisement parses the refs from the pkt-line response of the smart HTTP discovery request. Lines other
+// than refs, like the service header and flush packets, are skipped and capabilities after the first ref are dropped.
+// Annotated tags are advertised with the peeled `<tag>^{}` ref pointing to the commit, same as git ls-remote.
+//
+// See more: https://git-scm.com/docs/http-protocol#_smart_clients
+func parseRefAdvertisement(r io.Reader) (map[string]string, error) {
+	refs := make(map[string]string)
+
+	reader := bufio.NewReader(r)
 
-	// if path is empty, use the root of the repo as the action directory
-	if actionPath == "" {
-		actionPath = "."
+	for {
+		var size [4]byte
+
+		if _, err := io.ReadFull(reader, size[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return refs, nil
+			}
+
+			return nil, err
+		}
+
+		n, err := strconv.ParseUint(string(size[:]), 16, 16)
+		if err != nil || n > 0 && n < 4 {
+			return nil, fmt.Errorf("invalid pkt-line length %q", string(size[:]))
+		}
+
+		// flush packet
+		if n == 0 {
+			continue
+		}
+
+		line := make([]byte, n-4)
+
+		if _, err := io.ReadFull(reader, line); err != nil {
+			return nil, err
+		}
+
+		ref, _, _ := strings.Cut(strings.TrimSuffix(string(line), "\n"), "\x00")
+
+		if sha, name, ok := strings.Cut(ref, " "); ok && isFullCommitSHA(sha) {
+			refs[name] = sha
+		}
+	}
+}
+
+// gitImage is the image to run git with credentials. Dagger git API doesn't support authentication over https, so
+// private repositories are fetched with git in a container instead.
+const gitImage = "alpine:3.18"
+
+// gitAuthScript configures git to send the token from $GHX_ACTION_TOKEN as basic auth header, same as actions/checkout.
+const gitAuthScript = `git config --global http.extraHeader "Authorization: basic $(printf 'x-access-token:%s' "$GHX_ACTION_TOKEN" | base64 | tr -d '\n')"`
+
+// fetchAuthenticatedGitRemote fetches the given ref of the remote with git in a container. The token is passed as a
+// dagger secret, so it doesn't leak into dagger queries, cache keys and logs.
+func fetchAuthenticatedGitRemote(ctx context.Context, client *dagger.Client, url, src, ref, token string) (*dagger.Directory, string, error) {
+	git := client.Container().
+		From(gitImage).
+		WithExec([]string{"apk", "add", "--no-cache", "git"}).
+		WithSecretVariable("GHX_ACTION_TOKEN", client.SetSecret("ghx-action-token", token)).
+		WithEnvVariable("GHX_GIT_URL", url)
+
+	commit := ref
+
+	if !isFullCommitSHA(ref) {
+		// refs are mutable, so listing them must not be cached
+		out, err := git.
+			WithEnvVariable("GHX_CACHE_BUSTER", time.Now().String()).
+			WithExec([]string{"sh", "-c", gitAuthScript + ` && git ls-remote "$GHX_GIT_URL"`}).
+			Stdout(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list refs for %s: %v", src, err)
+		}
+
+		commit, err = findRemoteRef(out, src, ref)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// fetch the commit eagerly and verify it, a full commit SHA is never listed by ls-remote and missing commits must be
+	// reported here instead of when the directory is used
+	script := gitAuthScript + ` && git init -q /src && cd /src && { git fetch -q --depth 1 "$GHX_GIT_URL" "$0" || true; } && ` +
+		`if git cat-file -e "$0^{commit}" 2>/dev/null; then git checkout -q "$0" && rm -rf .git; else echo missing; fi`
+
+	fetched := git.WithExec([]string{"sh", "-c", script, commit})
+
+	out, err := fetched.Stdout(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %v", src, err)
+	}
+
+	if strings.TrimSpace(out) == "missing" {
+		return nil, "", &actionNotFoundError{msg: fmt.Sprintf("unable to resolve action `%s`, unable to find version `%s`", src, ref)}
+	}
+
+	return fetched.Directory("/src"), commit, nil
+}
+
+// findRemoteRef returns the commit SHA of the ref from `git ls-remote` output.
+func findRemoteRef(out, src, ref string) (string, error) {
+	refs := make(map[string]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		if sha, name, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			refs[name] = sha
+		}
+	}
+
+	return lookupRemoteRef(refs, src, ref)
+}
+
+// lookupRemoteRef returns the commit SHA of the ref from the map of ref name to SHA. The ref is looked up in tags and
+// then in branches, the same order git uses to resolve ambiguous refs. Annotated tags are resolved to the commit they
+// point to. Short commit SHAs are not supported by GitHub, so they are rejected with the same error.
+func lookupRemoteRef(refs map[string]string, src, ref string) (string, error) {
+	for _, name := range []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref} {
+		if sha, ok := refs[name]; ok {
+			return sha, nil
+		}
+	}
+
+	if isShortCommitSHA(ref) {
+		return "", fmt.Errorf("unable to resolve action `%s`, the provided ref `%s` is the shortened version of a commit SHA, which is not supported. Please use the full commit SHA instead", src, ref)
+	}
+
+	return "", &actionNotFoundError{msg: fmt.Sprintf("unable to resolve action `%s`, unable to find version `%s`", src, ref)}
+}
+
+// actionNotFoundError is returned when the ref of the action doesn't exist in the repository.
+type actionNotFoundError struct {
+	msg string
+}
+
+func (e *actionNotFoundError) Error() string {
+	return e.msg
+}
+
+// isActionNotFound returns true if the error means the repository or the ref of the action doesn't exist on the
+// server.
+func isActionNotFound(err error) bool {
+	var notFound *actionNotFoundError
+
+	return errors.As(err, &notFound)
+}
+
+// isFullCommitSHA returns true if the ref is a full commit SHA-1 or SHA-256 hash.
+func isFullCommitSHA(ref string) bool {
+	return (len(ref) == 40 || len(ref) == 64) && isHex(ref)
+}
+
+// isShortCommitSHA returns true if the ref looks like an abbreviated commit SHA.
+func isShortCommitSHA(ref string) bool {
+	return len(ref) >= 7 && len(ref) < 40 && isHex(ref)
+}
+
+// isHex returns true if the string only contains hexadecimal characters.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
 	}
 
-	// TODO: handle enterprise github instances as well
-	// TODO: handle ref type (branch, tag, commit) currently only tags are supported
-	return client.Git(path.Join("github.com", actionRepo)).Tag(actionRef).Tree().Directory(actionPath), nil
+	return s != ""
 }
 
 // findActionMetadataFileName finds the action.yml or action.yaml file in the root of the action directory.
@@ -0,0 +1,533 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestActionRunsUsing_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected ActionRunsUsing
+		isNode   bool
+		wantErr  bool
+	}{
+		{name: "node20", input: "node20", expected: ActionRunsUsingNode20, isNode: true},
+		{name: "node16", input: "node16", expected: ActionRunsUsingNode16, isNode: true},
+		{name: "node12", input: "node12", expected: ActionRunsUsingNode12, isNode: true},
+		{name: "uppercase", input: "Node20", expected: ActionRunsUsingNode20, isNode: true},
+		{name: "composite", input: "composite", expected: ActionRunsUsingComposite},
+		{name: "docker", input: "docker", expected: ActionRunsUsingDocker},
+		{name: "unsupported", input: "node8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var using ActionRunsUsing
+
+			err := yaml.Unmarshal([]byte(tt.input), &using)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, but got nil for input: %s", tt.input)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if using != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, using)
+			}
+
+			if using.IsNode() != tt.isNode {
+				t.Errorf("Expected IsNode %v, but got %v", tt.isNode, using.IsNode())
+			}
+		})
+	}
+}
+
+func TestCommitSHADetection(t *testing.T) {
+	tests := []struct {
+		name  string
+		ref   string
+		full  bool
+		short bool
+	}{
+		{name: "full sha", ref: "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", full: true},
+		{name: "full sha uppercase", ref: "8E5E7E5AB8B370D6C329EC480221332ADA57F0AB", full: true},
+		{name: "short sha", ref: "8e5e7e5", short: true},
+		{name: "too short", ref: "8e5e7e"},
+		{name: "tag", ref: "v3"},
+		{name: "branch", ref: "main"},
+		{name: "hex looking branch", ref: "deadbeefcafe", short: true},
+		{name: "non hex with sha length", ref: "zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFullCommitSHA(tt.ref); got != tt.full {
+				t.Errorf("Expected isFullCommitSHA %v, but got %v for ref: %s", tt.full, got, tt.ref)
+			}
+
+			if got := isShortCommitSHA(tt.ref); got != tt.short {
+				t.Errorf("Expected isShortCommitSHA %v, but got %v for ref: %s", tt.short, got, tt.ref)
+			}
+		})
+	}
+}
+
+func TestActionSourceOptions_GitRemotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ActionSourceOptions
+		expected []gitRemote
+		wantErr  bool
+	}{
+		{
+			name:     "default server",
+			opts:     ActionSourceOptions{},
+			expected: []gitRemote{{URL: "https://github.com/actions/checkout", Host: "github.com"}},
+		},
+		{
+			name:     "github.com with token",
+			opts:     ActionSourceOptions{ServerURL: "https://github.com/", Token: "secret"},
+			expected: []gitRemote{{URL: "https://github.com/actions/checkout", Host: "github.com", Auth: true}},
+		},
+		{
+			name: "enterprise server falls back to github.com without token",
+			opts: ActionSourceOptions{ServerURL: "https://ghes.example.com", Token: "secret"},
+			expected: []gitRemote{
+				{URL: "https://ghes.example.com/actions/checkout", Host: "ghes.example.com", Auth: true},
+				{URL: "https://github.com/actions/checkout", Host: "github.com"},
+			},
+		},
+		{
+			name:    "invalid server url",
+			opts:    ActionSourceOptions{ServerURL: "ghes.example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remotes, err := tt.opts.gitRemotes("actions/checkout")
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, but got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Expected no error, but got %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(remotes, tt.expected) {
+				t.Errorf("Expected %v, but got %v", tt.expected, remotes)
+			}
+
+			for _, remote := range remotes {
+				if strings.Contains(remote.URL, "secret") {
+					t.Errorf("Expected token not to be in the url, but got %s", remote.URL)
+				}
+			}
+		})
+	}
+}
+
+func TestFindRemoteRef(t *testing.T) {
+	out := strings.Join([]string{
+		"1111111111111111111111111111111111111111\tHEAD",
+		"2222222222222222222222222222222222222222\trefs/heads/main",
+		"3333333333333333333333333333333333333333\trefs/heads/v1",
+		"4444444444444444444444444444444444444444\trefs/tags/v1",
+		"5555555555555555555555555555555555555555\trefs/tags/v2",
+		"6666666666666666666666666666666666666666\trefs/tags/v2^{}",
+	}, "\n")
+
+	tests := []struct {
+		ref      string
+		expected string
+		notFound bool
+	}{
+		{ref: "main", expected: "2222222222222222222222222222222222222222"},
+		{ref: "v1", expected: "4444444444444444444444444444444444444444"},
+		{ref: "v2", expected: "6666666666666666666666666666666666666666"},
+		{ref: "v3", notFound: true},
+		{ref: "abcdef1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			commit, err := findRemoteRef(out, "actions/checkout@"+tt.ref, tt.ref)
+
+			if tt.expected == "" {
+				if err == nil {
+					t.Fatalf("Expected error, but got nil")
+				}
+
+				if isActionNotFound(err) != tt.notFound {
+					t.Errorf("Expected not found %v, but got %v: %s", tt.notFound, !tt.notFound, err.Error())
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			if commit != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, commit)
+			}
+		})
+	}
+}
+
+func TestIsActionNotFound(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: fmt.Errorf("failed to list tags: %w", &actionNotFoundError{msg: "unable to find version"}), expected: true},
+		{err: errors.New("fatal: repository 'https://ghes.example.com/actions/checkout/' not found")},
+		{err: errors.New("action.yml or action.yaml not found in the root of the action directory")},
+		{err: errors.New("fatal: Authentication failed for 'https://ghes.example.com/actions/checkout/'")},
+		{err: errors.New("dial tcp: lookup ghes.example.com: connection refused")},
+	}
+
+	for _, tt := range tests {
+		if result := isActionNotFound(tt.err); result != tt.expected {
+			t.Errorf("Expected %v, but got %v for error: %s", tt.expected, result, tt.err.Error())
+		}
+	}
+}
+
+func TestProbeGitRemote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != "git-upload-pack" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/actions/checkout/info/refs":
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, checkoutRefAdvertisement)
+		case "/private/action/info/refs":
+			if _, password, ok := r.BasicAuth(); ok && password == "secret" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/broken/action/info/refs":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		repo     string
+		auth     bool
+		token    string
+		wantErr  bool
+		notFound bool
+	}{
+		{name: "exists", repo: "actions/checkout"},
+		{name: "missing", repo: "actions/missing", wantErr: true, notFound: true},
+		{name: "private anonymous", repo: "private/action", wantErr: true, notFound: true},
+		{name: "private with token", repo: "private/action", auth: true, token: "secret"},
+		{name: "private with bad token", repo: "private/action", auth: true, token: "bad", wantErr: true},
+		{name: "server error", repo: "broken/action", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := gitRemote{URL: server.URL + "/" + tt.repo, Host: "ghes.example.com", Auth: tt.auth}
+
+			refs, err := probeGitRemote(context.Background(), remote, tt.repo+"@v1", tt.token)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tt.wantErr, err)
+			}
+
+			if err != nil && isActionNotFound(err) != tt.notFound {
+				t.Errorf("Expected not found %v, but got %v: %s", tt.notFound, !tt.notFound, err.Error())
+			}
+
+			if tt.repo == "actions/checkout" && refs["refs/tags/v3.5.3^{}"] != checkoutV353 {
+				t.Errorf("Expected refs advertised by the server, but got %v", refs)
+			}
+		})
+	}
+}
+
+// pktLine encodes the line in pkt-line format of the git protocol.
+func pktLine(line string) string {
+	return fmt.Sprintf("%04x%s", len(line)+4, line)
+}
+
+// checkoutV353 is the commit actions/checkout@v3.5.3 points to.
+const checkoutV353 = "c85c95e3d7251135ab7dc9ce3241c5835cc595a9"
+
+// checkoutRefAdvertisement is the smart HTTP discovery response of actions/checkout with v3.5.3 as an annotated tag.
+var checkoutRefAdvertisement = pktLine("# service=git-upload-pack\n") + "0000" +
+	pktLine("2222222222222222222222222222222222222222 HEAD\x00multi_ack thin-pack side-band symref=HEAD:refs/heads/main\n") +
+	pktLine("2222222222222222222222222222222222222222 refs/heads/main\n") +
+	pktLine("3333333333333333333333333333333333333333 refs/heads/v3.5.3\n") +
+	pktLine("4444444444444444444444444444444444444444 refs/tags/v3\n") +
+	pktLine("5555555555555555555555555555555555555555 refs/tags/v3.5.3\n") +
+	pktLine(checkoutV353+" refs/tags/v3.5.3^{}\n") +
+	"0000"
+
+func TestParseRefAdvertisement(t *testing.T) {
+	refs, err := parseRefAdvertisement(strings.NewReader(checkoutRefAdvertisement))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	expected := map[string]string{
+		"HEAD":                "2222222222222222222222222222222222222222",
+		"refs/heads/main":     "2222222222222222222222222222222222222222",
+		"refs/heads/v3.5.3":   "3333333333333333333333333333333333333333",
+		"refs/tags/v3":        "4444444444444444444444444444444444444444",
+		"refs/tags/v3.5.3":    "5555555555555555555555555555555555555555",
+		"refs/tags/v3.5.3^{}": checkoutV353,
+	}
+
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Expected refs %v, but got %v", expected, refs)
+	}
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		// annotated tags resolve to the commit, not to the tag object, and tags win over branches with the same name
+		{ref: "v3.5.3", expected: checkoutV353},
+		{ref: "v3", expected: "4444444444444444444444444444444444444444"},
+		{ref: "main", expected: "2222222222222222222222222222222222222222"},
+	}
+
+	for _, tt := range tests {
+		commit, err := lookupRemoteRef(refs, "actions/checkout@"+tt.ref, tt.ref)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %s", err.Error())
+		}
+
+		if commit != tt.expected {
+			t.Errorf("Expected %s to resolve to %s, but got %s", tt.ref, tt.expected, commit)
+		}
+	}
+
+	if _, err := lookupRemoteRef(refs, "actions/checkout@v4", "v4"); !isActionNotFound(err) {
+		t.Errorf("Expected not found error, but got %v", err)
+	}
+
+	for _, invalid := range []string{"00", "zzzz", "0003", "0010short"} {
+		if _, err := parseRefAdvertisement(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for %q, but got nil", invalid)
+		}
+	}
+}
+
+func TestActionSourceOptions_Replace(t *testing.T) {
+	opts := ActionSourceOptions{
+		Replacements: map[string]string{
+			"actions/checkout@v3":       "./local/checkout",
+			"actions/checkout":          "myfork/checkout@fix",
+			"github/codeql-action/init": "./local/init",
+		},
+	}
+
+	tests := []struct {
+		src      string
+		expected string
+		replaced bool
+	}{
+		{src: "actions/checkout@v3", expected: "./local/checkout", replaced: true},
+		{src: "actions/checkout@v4", expected: "myfork/checkout@fix", replaced: true},
+		{src: "github/codeql-action/init@v2", expected: "./local/init", replaced: true},
+		{src: "github/codeql-action/analyze@v2", expected: "github/codeql-action/analyze@v2"},
+		{src: "actions/setup-go@v4", expected: "actions/setup-go@v4"},
+		{src: "./local/checkout", expected: "./local/checkout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, replaced := opts.Replace(tt.src)
+
+			if got != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, got)
+			}
+
+			if replaced != tt.replaced {
+				t.Errorf("Expected replaced %v, but got %v", tt.replaced, replaced)
+			}
+		})
+	}
+}
+
+func TestValidateActionReplacement(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		replacement string
+		wantErr     bool
+	}{
+		{name: "local replacement", src: "actions/checkout@v3", replacement: "./local/checkout"},
+		{name: "remote replacement", src: "actions/checkout@v3", replacement: "myfork/checkout@fix"},
+		{name: "source without ref", src: "actions/checkout", replacement: "myfork/checkout@fix"},
+		{name: "source with path", src: "github/codeql-action/init", replacement: "./init"},
+		{name: "remote replacement without ref", src: "actions/checkout@v3", replacement: "myfork/checkout", wantErr: true},
+		{name: "local source", src: "./local/checkout", replacement: "./other", wantErr: true},
+		{name: "invalid source", src: "checkout", replacement: "./local/checkout", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateActionReplacement(tt.src, tt.replacement)
+
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, but got %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestInputEnvName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "token", expected: "INPUT_TOKEN"},
+		{name: "fetch-depth", expected: "INPUT_FETCH-DEPTH"},
+		{name: "my input", expected: "INPUT_MY_INPUT"},
+		{name: "Mixed Case", expected: "INPUT_MIXED_CASE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InputEnvName(tt.name); got != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAction_ValidateInputs(t *testing.T) {
+	action := &Action{
+		Inputs: map[string]ActionInput{
+			"token":   {Required: true},
+			"ref":     {Required: true, Default: "main"},
+			"path":    {},
+			"old-arg": {DeprecationMessage: "use path instead"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		with     map[string]string
+		warnings []string
+		wantErr  bool
+	}{
+		{
+			name: "valid inputs",
+			with: map[string]string{"token": "secret", "path": "src"},
+		},
+		{
+			name: "case-insensitive input names",
+			with: map[string]string{"TOKEN": "secret"},
+		},
+		{
+			name:    "missing required input",
+			with:    map[string]string{"path": "src"},
+			wantErr: true,
+		},
+		{
+			name:     "unexpected inputs",
+			with:     map[string]string{"token": "secret", "foo": "bar", "baz": "qux"},
+			warnings: []string{"Unexpected input(s) 'baz', 'foo', valid inputs are ['old-arg', 'path', 'ref', 'token']"},
+		},
+		{
+			name:     "deprecated input",
+			with:     map[string]string{"token": "secret", "old-arg": "src"},
+			warnings: []string{"Input 'old-arg' has been deprecated with message: use path instead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := action.ValidateInputs(tt.with)
+
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, but got %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("Expected warnings %v, but got %v", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestAction_Outputs(t *testing.T) {
+	content := `
+name: composite
+outputs:
+  random-number:
+    description: Random number
+    value: ${{ steps.random-number-generator.outputs.random-number }}
+runs:
+  using: composite
+  steps:
+    - id: random-number-generator
+      run: echo "random-number=$RANDOM" >> $GITHUB_OUTPUT
+      shell: bash
+`
+
+	var action Action
+
+	if err := yaml.Unmarshal([]byte(content), &action); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	expected := "${{ steps.random-number-generator.outputs.random-number }}"
+
+	if got := action.Outputs["random-number"].Value; got != expected {
+		t.Errorf("Expected output value %s, but got %s", expected, got)
+	}
+
+	undeclared := action.UndeclaredOutputs(map[string]string{"random-number": "1", "foo": "bar", "baz": "qux"})
+
+	if !reflect.DeepEqual(undeclared, []string{"baz", "foo"}) {
+		t.Errorf("Expected undeclared outputs [baz foo], but got %v", undeclared)
+	}
+}
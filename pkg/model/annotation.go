@@ -0,0 +1,73 @@
+package model
+
+import "strconv"
+
+// AnnotationLevel represents the severity of an annotation.
+type AnnotationLevel string
+
+const (
+	AnnotationLevelError   AnnotationLevel = "error"
+	AnnotationLevelWarning AnnotationLevel = "warning"
+	AnnotationLevelNotice  AnnotationLevel = "notice"
+)
+
+// Annotation represents a message created by `error`, `warning` or `notice` workflow commands. The message can be
+// associated with a file and a location in the file.
+//
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-a-notice-message
+type Annotation struct {
+	Level   AnnotationLevel `json:"level"`             // Level is the severity of the annotation.
+	File    string          `json:"file,omitempty"`    // File is the path of the file the annotation is associated with.
+	Line    int             `json:"line,omitempty"`    // Line is the start line of the annotation. Starts at 1.
+	Col     int             `json:"col,omitempty"`     // Col is the start column of the annotation. Starts at 1.
+	EndLine int             `json:"endLine,omitempty"` // EndLine is the end line of the annotation.
+	EndCol  int             `json:"endCol,omitempty"`  // EndCol is the end column of the annotation.
+	Title   string          `json:"title,omitempty"`   // Title is the custom title of the annotation.
+	Message string          `json:"message"`           // Message is the message of the annotation.
+	StepID  string          `json:"stepId,omitempty"`  // StepID is the id of the step that created the annotation.
+}
+
+// NewAnnotationFromCommand creates a new annotation from the given workflow command. If the command is not one of the
+// annotation commands, it returns false.
+func NewAnnotationFromCommand(cmd *Command, stepID string) (*Annotation, bool) {
+	var level AnnotationLevel
+
+	switch cmd.Name {
+	case CommandError:
+		level = AnnotationLevelError
+	case CommandWarning:
+		level = AnnotationLevelWarning
+	case CommandNotice:
+		level = AnnotationLevelNotice
+	default:
+		return nil, false
+	}
+
+	return &Annotation{
+		Level:   level,
+		File:    cmd.Parameters["file"],
+		Line:    parseAnnotationPosition(cmd.Parameters["line"]),
+		Col:     parseAnnotationPosition(cmd.Parameters["col"]),
+		EndLine: parseAnnotationPosition(cmd.Parameters["endLine"]),
+		EndCol:  parseAnnotationPosition(cmd.Parameters["endColumn"], cmd.Parameters["endCol"]),
+		Title:   cmd.Parameters["title"],
+		Message: cmd.Value,
+		StepID:  stepID,
+	}, true
+}
+
+// parseAnnotationPosition returns the first valid positive integer from the given values. Invalid values are ignored
+// the same way runner does, and 0 returned if there is no valid value.
+func parseAnnotationPosition(values ...string) int {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		if pos, err := strconv.Atoi(value); err == nil && pos > 0 {
+			return pos
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewAnnotationFromCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      *Annotation
+		expectedMatch bool
+	}{
+		{
+			name:  "error with position",
+			input: "::error file=app.js,line=1,col=5,endColumn=7,title=Oops::Missing semicolon",
+			expected: &Annotation{
+				Level:   AnnotationLevelError,
+				File:    "app.js",
+				Line:    1,
+				Col:     5,
+				EndCol:  7,
+				Title:   "Oops",
+				Message: "Missing semicolon",
+				StepID:  "lint",
+			},
+			expectedMatch: true,
+		},
+		{
+			name:          "warning without parameters",
+			input:         "::warning::Something happened",
+			expected:      &Annotation{Level: AnnotationLevelWarning, Message: "Something happened", StepID: "lint"},
+			expectedMatch: true,
+		},
+		{
+			name:          "notice with invalid line",
+			input:         "::notice line=abc,endLine=3::Hello",
+			expected:      &Annotation{Level: AnnotationLevelNotice, EndLine: 3, Message: "Hello", StepID: "lint"},
+			expectedMatch: true,
+		},
+		{
+			name:          "not an annotation",
+			input:         "::debug::Hello",
+			expected:      nil,
+			expectedMatch: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cmd := ParseCommand(tt.input)
+
+			annotation, match := NewAnnotationFromCommand(cmd, "lint")
+
+			if match != tt.expectedMatch {
+				t.Errorf("Expected match %v, but got %v", tt.expectedMatch, match)
+			}
+
+			if !reflect.DeepEqual(annotation, tt.expected) {
+				t.Errorf("Expected result %+v, but got %+v", tt.expected, annotation)
+			}
+		})
+	}
+}
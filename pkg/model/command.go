@@ -10,7 +10,35 @@ var (
 	commandReHash  = regexp.MustCompile(`##\[(\S+)([^]]*)](.*)?$`) //
 )
 
-// TODO: add possible command names as constants
+// Names of the workflow commands supported by the runner.
+const (
+	CommandGroup         = "group"
+	CommandEndGroup      = "endgroup"
+	CommandDebug         = "debug"
+	CommandError         = "error"
+	CommandWarning       = "warning"
+	CommandNotice        = "notice"
+	CommandSetEnv        = "set-env"
+	CommandSetOutput     = "set-output"
+	CommandSaveState     = "save-state"
+	CommandAddMask       = "add-mask"
+	CommandAddMatcher    = "add-matcher"
+	CommandRemoveMatcher = "remove-matcher"
+	CommandAddPath       = "add-path"
+	CommandStopCommands  = "stop-commands"
+	CommandEcho          = "echo"
+)
+
+var (
+	// escapeData and unescapeData are the replacements used for command values.
+	escapeData   = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	unescapeData = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n")
+
+	// escapeProperty and unescapeProperty are the replacements used for command properties. In addition to the
+	// data replacements, properties escape `:` and `,` since they are used as separators.
+	escapeProperty   = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	unescapeProperty = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",")
+)
 
 // Command represents a Workflow command to communicate with Runner.
 // More info about the commands: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
@@ -22,18 +50,20 @@ type Command struct {
 
 // ParseCommand parses a Workflow command string and returns a Command object. If the string is not a valid Workflow
 // command, it returns false.
+//
+// Values and parameters of the `::` format are unescaped as described in the runner documentation.
 func ParseCommand(str string) (bool, *Command) {
 	var command Command
 
 	if matches := commandReColon.FindStringSubmatch(str); matches != nil {
 		// Extract the command keyword
 		command.Name = matches[1]
-		command.Parameters = parseParameters(matches[2], ",")
-		command.Value = matches[3]
+		command.Parameters = parseParameters(matches[2], ",", unescapeProperty)
+		command.Value = unescapeData.Replace(matches[3])
 	} else if matches := commandReHash.FindStringSubmatch(str); matches != nil {
 		// Extract the command keyword
 		command.Name = matches[1]
-		command.Parameters = parseParameters(matches[2], ";")
+		command.Parameters = parseParameters(matches[2], ";", nil)
 		command.Value = matches[3]
 	} else {
 		return false, nil
@@ -42,7 +72,7 @@ func ParseCommand(str string) (bool, *Command) {
 	return true, &command
 }
 
-func parseParameters(parametersStr string, separator string) map[string]string {
+func parseParameters(parametersStr string, separator string, unescape *strings.Replacer) map[string]string {
 	parameters := make(map[string]string)
 
 	if parametersStr == "" {
@@ -52,7 +82,13 @@ func parseParameters(parametersStr string, separator string) map[string]string {
 	for _, parameter := range strings.Split(parametersStr, separator) {
 		parts := strings.SplitN(parameter, "=", 2)
 		if len(parts) == 2 {
-			parameters[parts[0]] = parts[1]
+			value := parts[1]
+
+			if unescape != nil {
+				value = unescape.Replace(value)
+			}
+
+			parameters[parts[0]] = value
 		}
 	}
 
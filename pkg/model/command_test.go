@@ -67,6 +67,39 @@ func TestParseCommand(t *testing.T) {
 			},
 			expectedMatch: true,
 		},
+		{
+			name:  "Command with escaped value",
+			input: "::warning::100%25 done%0Anext line%0D",
+			expectedResult: &Command{
+				Name:       "warning",
+				Parameters: map[string]string{},
+				Value:      "100% done\nnext line\r",
+			},
+			expectedMatch: true,
+		},
+		{
+			name:  "Command with escaped parameters",
+			input: "::error file=a%3Ab%2Cc.go,title=50%25%0A::message with : and ,",
+			expectedResult: &Command{
+				Name: "error",
+				Parameters: map[string]string{
+					"file":  "a:b,c.go",
+					"title": "50%\n",
+				},
+				Value: "message with : and ,",
+			},
+			expectedMatch: true,
+		},
+		{
+			name:  "Escaped percent is unescaped once",
+			input: "::debug::%250A",
+			expectedResult: &Command{
+				Name:       "debug",
+				Parameters: map[string]string{},
+				Value:      "%0A",
+			},
+			expectedMatch: true,
+		},
 		{
 			name:           "Invalid command format",
 			input:          "This is not a valid command",
@@ -89,3 +122,38 @@ func TestParseCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		data     string
+		property string
+	}{
+		{"plain", "foo", "foo", "foo"},
+		{"percent", "100%", "100%25", "100%25"},
+		{"new lines", "a\r\nb", "a%0D%0Ab", "a%0D%0Ab"},
+		{"separators", "a:b,c", "a:b,c", "a%3Ab%2Cc"},
+		{"escaped sequence", "%0A", "%250A", "%250A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeData.Replace(tt.input); got != tt.data {
+				t.Errorf("Expected escaped data %q, but got %q", tt.data, got)
+			}
+
+			if got := escapeProperty.Replace(tt.input); got != tt.property {
+				t.Errorf("Expected escaped property %q, but got %q", tt.property, got)
+			}
+
+			if got := unescapeData.Replace(tt.data); got != tt.input {
+				t.Errorf("Expected unescaped data %q, but got %q", tt.input, got)
+			}
+
+			if got := unescapeProperty.Replace(tt.property); got != tt.input {
+				t.Errorf("Expected unescaped property %q, but got %q", tt.input, got)
+			}
+		})
+	}
+}
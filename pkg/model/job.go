@@ -1,14 +1,252 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Jobs represents a map of jobs
 // For more information about workflows, see: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobs
 type Jobs map[string]*Job
 
 // Job represents a single job in a GitHub Actions workflow
 // For more information about workflows, see: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_id
+//
+// Fields accepting expressions in the workflow syntax are kept as strings and evaluated when they're used.
+//
+// The schema follows the job types of actionlint, e.g. runs-on is labels, a group or an expression and permissions are
+// read-all/write-all or scopes, with a few differences:
+//   - Values are plain strings instead of typed values with positions, booleans and numbers like continue-on-error
+//     and timeout-minutes are converted when they're evaluated.
+//   - Values are not validated while parsing, e.g. unknown permission scopes and runner labels are kept as they are.
+//     `ghx check` type checks the expressions of the steps with actionlint.
+//   - Workflow call jobs with uses, with and secrets are not supported.
 type Job struct {
-	Name        string            `yaml:"name"`  // Name is the name of the job
-	Environment map[string]string `yaml:"env"`   // Environment is the environment variables used in the workflow
-	Steps       Steps             `yaml:"steps"` // Steps is a list of steps
-	// TBD -- we'll add more fields here as we need them.
+	Name                  string                 `yaml:"name"`              // Name is the name of the job
+	Needs                 StringList             `yaml:"needs"`             // Needs is the list of jobs that must complete successfully before this job
+	RunsOn                *RunsOn                `yaml:"runs-on"`           // RunsOn is the type of machine to run the job on
+	Permissions           *Permissions           `yaml:"permissions"`       // Permissions is the permissions granted to the GITHUB_TOKEN
+	DeploymentEnvironment *DeploymentEnvironment `yaml:"environment"`       // DeploymentEnvironment is the environment the job references
+	Concurrency           *Concurrency           `yaml:"concurrency"`       // Concurrency is the concurrency group of the job
+	Outputs               map[string]string      `yaml:"outputs"`           // Outputs is the map of outputs of the job
+	Environment           map[string]string      `yaml:"env"`               // Environment is the environment variables used in the workflow
+	Defaults              *Defaults              `yaml:"defaults"`          // Defaults is the default settings for all steps in the job
+	If                    string                 `yaml:"if"`                // If is the condition to run the job
+	Steps                 Steps                  `yaml:"steps"`             // Steps is a list of steps
+	TimeoutMinutes        string                 `yaml:"timeout-minutes"`   // TimeoutMinutes is the maximum number of minutes to run the job
+	Strategy              *Strategy              `yaml:"strategy"`          // Strategy is the matrix strategy of the job
+	ContinueOnError       string                 `yaml:"continue-on-error"` // ContinueOnError prevents the workflow run from failing when the job fails
+	Container             *Container             `yaml:"container"`         // Container is the container to run the steps of the job in
+	Services              map[string]*Container  `yaml:"services"`          // Services is the map of service containers for the job
+}
+
+// StringList represents a list of strings that can be written as a single string in the workflow syntax.
+type StringList []string
+
+// UnmarshalYAML unmarshal a single string or a list of strings.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*l = StringList{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+
+		*l = list
+
+		return nil
+	default:
+		return fmt.Errorf("line %d: expected string or list of strings", node.Line)
+	}
+}
+
+// RunsOn represents the runner labels and the runner group of a job.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idruns-on
+type RunsOn struct {
+	Group      string     `yaml:"group"`  // Group is the runner group to run the job on
+	Labels     StringList `yaml:"labels"` // Labels is the list of runner labels to run the job on
+	Expression string     `yaml:"-"`      // Expression is the expression generating the labels, e.g. ${{ matrix.os }}
+}
+
+// UnmarshalYAML unmarshal runs-on from a label, a list of labels, an expression or a group and labels mapping.
+func (r *RunsOn) UnmarshalYAML(node *yaml.Node) error {
+	switch {
+	case node.Kind == yaml.MappingNode:
+		type plain RunsOn
+
+		return node.Decode((*plain)(r))
+	case node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${{"):
+		// same as actionlint, a single expression may generate a label or a list of labels
+		r.Expression = node.Value
+		return nil
+	default:
+		return node.Decode(&r.Labels)
+	}
+}
+
+// Permissions represents the permissions granted to the GITHUB_TOKEN.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idpermissions
+type Permissions struct {
+	All    string            // All is the permission for all scopes, read-all or write-all. Empty if scopes are set.
+	Scopes map[string]string // Scopes is the map of scope to permission, e.g. contents: read
+}
+
+// UnmarshalYAML unmarshal permissions from read-all, write-all or a scope mapping.
+func (p *Permissions) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		p.All = node.Value
+		return nil
+	case yaml.MappingNode:
+		// empty mapping `{}` disables all scopes, so scopes are never nil for the mapping form
+		p.Scopes = make(map[string]string)
+
+		return node.Decode(&p.Scopes)
+	default:
+		return fmt.Errorf("line %d: expected read-all, write-all or permission mapping", node.Line)
+	}
+}
+
+// DeploymentEnvironment represents the deployment environment a job references.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idenvironment
+type DeploymentEnvironment struct {
+	Name string `yaml:"name"` // Name is the name of the environment
+	URL  string `yaml:"url"`  // URL is the URL of the deployment
+}
+
+// UnmarshalYAML unmarshal environment from a name or a name and url mapping.
+func (e *DeploymentEnvironment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Name = node.Value
+		return nil
+	}
+
+	type plain DeploymentEnvironment
+
+	return node.Decode((*plain)(e))
+}
+
+// Concurrency represents the concurrency group of a job.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idconcurrency
+type Concurrency struct {
+	Group            string `yaml:"group"`              // Group is the name of the concurrency group
+	CancelInProgress string `yaml:"cancel-in-progress"` // CancelInProgress cancels running jobs in the same group
+}
+
+// UnmarshalYAML unmarshal concurrency from a group name or a group and cancel-in-progress mapping.
+func (c *Concurrency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Group = node.Value
+		return nil
+	}
+
+	type plain Concurrency
+
+	return node.Decode((*plain)(c))
+}
+
+// Defaults represents the default settings for all steps in the job.
+type Defaults struct {
+	Run *RunDefaults `yaml:"run"` // Run is the default settings for run steps
+}
+
+// RunDefaults represents the default shell and working directory for run steps.
+type RunDefaults struct {
+	Shell            string `yaml:"shell"`             // Shell is the default shell for run steps
+	WorkingDirectory string `yaml:"working-directory"` // WorkingDirectory is the default working directory for run steps
+}
+
+// Strategy represents the matrix strategy of a job.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idstrategy
+type Strategy struct {
+	Matrix      *Matrix `yaml:"matrix"`       // Matrix is the matrix of job configurations
+	FailFast    string  `yaml:"fail-fast"`    // FailFast cancels in-progress jobs if any matrix job fails
+	MaxParallel string  `yaml:"max-parallel"` // MaxParallel is the maximum number of jobs to run simultaneously
+}
+
+// Matrix represents the matrix of job configurations.
+type Matrix struct {
+	Expression string                   // Expression is the expression the whole matrix is generated from, e.g. ${{ fromJSON(...) }}
+	Rows       map[string][]interface{} // Rows is the map of matrix variables to their values
+	Include    []map[string]interface{} // Include is the list of extra combinations
+	Exclude    []map[string]interface{} // Exclude is the list of excluded combinations
+}
+
+// UnmarshalYAML unmarshal the matrix from an expression or a mapping of variables with include and exclude.
+func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		m.Expression = node.Value
+		return nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected expression or matrix mapping", node.Line)
+	}
+
+	m.Rows = make(map[string][]interface{})
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+
+		var err error
+
+		switch key {
+		case "include":
+			err = value.Decode(&m.Include)
+		case "exclude":
+			err = value.Decode(&m.Exclude)
+		default:
+			var row []interface{}
+
+			// a row can be an expression generating the values as well
+			if value.Kind == yaml.ScalarNode {
+				row = []interface{}{value.Value}
+			} else {
+				err = value.Decode(&row)
+			}
+
+			m.Rows[key] = row
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Container represents a job container or a service container.
+// See more: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idcontainer
+type Container struct {
+	Image       string            `yaml:"image"`       // Image is the docker image to use as the container
+	Credentials *Credentials      `yaml:"credentials"` // Credentials is the credentials of the container registry
+	Environment map[string]string `yaml:"env"`         // Environment is the environment variables of the container
+	Ports       []string          `yaml:"ports"`       // Ports is the list of ports to expose on the container
+	Volumes     []string          `yaml:"volumes"`     // Volumes is the list of volumes for the container to use
+	Options     string            `yaml:"options"`     // Options is the additional docker create options
+}
+
+// UnmarshalYAML unmarshal the container from an image name or a container mapping.
+func (c *Container) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Image = node.Value
+		return nil
+	}
+
+	type plain Container
+
+	return node.Decode((*plain)(c))
+}
+
+// Credentials represents the credentials of a container registry.
+type Credentials struct {
+	Username string `yaml:"username"` // Username is the username of the registry
+	Password string `yaml:"password"` // Password is the password of the registry
 }
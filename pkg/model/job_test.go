@@ -0,0 +1,238 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestJob_UnmarshalYAML(t *testing.T) {
+	content := `
+name: test
+needs: build
+runs-on: [self-hosted, linux]
+permissions: read-all
+environment: production
+concurrency: ci-${{ github.ref }}
+timeout-minutes: 30
+continue-on-error: ${{ matrix.experimental }}
+if: github.event_name == 'push'
+outputs:
+  version: ${{ steps.version.outputs.version }}
+defaults:
+  run:
+    shell: bash
+    working-directory: scripts
+strategy:
+  fail-fast: false
+  max-parallel: 2
+  matrix:
+    go: [1.19, "1.20"]
+    os: ${{ fromJSON(needs.setup.outputs.os) }}
+    include:
+      - go: "1.21"
+        experimental: true
+    exclude:
+      - go: 1.19
+container: node:18
+services:
+  redis:
+    image: redis
+    ports:
+      - 6379:6379
+      - 8080
+    options: --health-cmd "redis-cli ping"
+  postgres:
+    image: postgres
+    credentials:
+      username: user
+      password: ${{ secrets.PASSWORD }}
+    env:
+      POSTGRES_PASSWORD: postgres
+steps:
+  - run: echo hello
+`
+
+	var job Job
+
+	if err := yaml.Unmarshal([]byte(content), &job); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	tests := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{name: "needs", got: job.Needs, expected: StringList{"build"}},
+		{name: "runs-on", got: job.RunsOn.Labels, expected: StringList{"self-hosted", "linux"}},
+		{name: "permissions", got: job.Permissions.All, expected: "read-all"},
+		{name: "environment", got: job.DeploymentEnvironment.Name, expected: "production"},
+		{name: "concurrency", got: job.Concurrency.Group, expected: "ci-${{ github.ref }}"},
+		{name: "timeout-minutes", got: job.TimeoutMinutes, expected: "30"},
+		{name: "continue-on-error", got: job.ContinueOnError, expected: "${{ matrix.experimental }}"},
+		{name: "if", got: job.If, expected: "github.event_name == 'push'"},
+		{name: "outputs", got: job.Outputs["version"], expected: "${{ steps.version.outputs.version }}"},
+		{name: "defaults", got: *job.Defaults.Run, expected: RunDefaults{Shell: "bash", WorkingDirectory: "scripts"}},
+		{name: "fail-fast", got: job.Strategy.FailFast, expected: "false"},
+		{name: "max-parallel", got: job.Strategy.MaxParallel, expected: "2"},
+		{name: "matrix row", got: job.Strategy.Matrix.Rows["go"], expected: []interface{}{1.19, "1.20"}},
+		{name: "matrix row expression", got: job.Strategy.Matrix.Rows["os"], expected: []interface{}{"${{ fromJSON(needs.setup.outputs.os) }}"}},
+		{name: "matrix include", got: job.Strategy.Matrix.Include, expected: []map[string]interface{}{{"go": "1.21", "experimental": true}}},
+		{name: "matrix exclude", got: job.Strategy.Matrix.Exclude, expected: []map[string]interface{}{{"go": 1.19}}},
+		{name: "container", got: job.Container.Image, expected: "node:18"},
+		{name: "service ports", got: job.Services["redis"].Ports, expected: []string{"6379:6379", "8080"}},
+		{name: "service options", got: job.Services["redis"].Options, expected: `--health-cmd "redis-cli ping"`},
+		{name: "service credentials", got: *job.Services["postgres"].Credentials, expected: Credentials{Username: "user", Password: "${{ secrets.PASSWORD }}"}},
+		{name: "service env", got: job.Services["postgres"].Environment, expected: map[string]string{"POSTGRES_PASSWORD": "postgres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.expected) {
+				t.Errorf("Expected %#v, but got %#v", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestJob_UnmarshalYAMLLongForms(t *testing.T) {
+	content := `
+needs: [build, test]
+runs-on:
+  group: large-runners
+  labels: ubuntu-latest
+permissions:
+  contents: read
+  pull-requests: write
+environment:
+  name: production
+  url: ${{ steps.deploy.outputs.url }}
+concurrency:
+  group: deploy
+  cancel-in-progress: true
+strategy:
+  matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+container:
+  image: node:18
+  volumes:
+    - /data:/data
+`
+
+	var job Job
+
+	if err := yaml.Unmarshal([]byte(content), &job); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	tests := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{name: "needs", got: job.Needs, expected: StringList{"build", "test"}},
+		{name: "runs-on", got: *job.RunsOn, expected: RunsOn{Group: "large-runners", Labels: StringList{"ubuntu-latest"}}},
+		{name: "permissions", got: job.Permissions.Scopes, expected: map[string]string{"contents": "read", "pull-requests": "write"}},
+		{name: "environment", got: *job.DeploymentEnvironment, expected: DeploymentEnvironment{Name: "production", URL: "${{ steps.deploy.outputs.url }}"}},
+		{name: "concurrency", got: *job.Concurrency, expected: Concurrency{Group: "deploy", CancelInProgress: "true"}},
+		{name: "matrix expression", got: job.Strategy.Matrix.Expression, expected: "${{ fromJSON(needs.setup.outputs.matrix) }}"},
+		{name: "container volumes", got: job.Container.Volumes, expected: []string{"/data:/data"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.expected) {
+				t.Errorf("Expected %#v, but got %#v", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestJob_UnmarshalYAMLForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		got      func(job *Job) interface{}
+		expected interface{}
+	}{
+		{
+			name:     "runs-on label",
+			content:  "runs-on: ubuntu-latest",
+			got:      func(job *Job) interface{} { return *job.RunsOn },
+			expected: RunsOn{Labels: StringList{"ubuntu-latest"}},
+		},
+		{
+			name:     "runs-on expression",
+			content:  "runs-on: ${{ matrix.os }}",
+			got:      func(job *Job) interface{} { return *job.RunsOn },
+			expected: RunsOn{Expression: "${{ matrix.os }}"},
+		},
+		{
+			name:     "runs-on labels with expression",
+			content:  "runs-on: [self-hosted, '${{ matrix.arch }}']",
+			got:      func(job *Job) interface{} { return *job.RunsOn },
+			expected: RunsOn{Labels: StringList{"self-hosted", "${{ matrix.arch }}"}},
+		},
+		{
+			name:     "runs-on group",
+			content:  "runs-on:\n  group: large-runners",
+			got:      func(job *Job) interface{} { return *job.RunsOn },
+			expected: RunsOn{Group: "large-runners"},
+		},
+		{
+			name:     "runs-on group and labels",
+			content:  "runs-on:\n  group: large-runners\n  labels: [linux, x64]",
+			got:      func(job *Job) interface{} { return *job.RunsOn },
+			expected: RunsOn{Group: "large-runners", Labels: StringList{"linux", "x64"}},
+		},
+		{
+			name:     "permissions write-all",
+			content:  "permissions: write-all",
+			got:      func(job *Job) interface{} { return *job.Permissions },
+			expected: Permissions{All: "write-all"},
+		},
+		{
+			name:     "permissions none",
+			content:  "permissions: {}",
+			got:      func(job *Job) interface{} { return *job.Permissions },
+			expected: Permissions{Scopes: map[string]string{}},
+		},
+		{
+			name:     "permissions scopes",
+			content:  "permissions:\n  id-token: write\n  contents: none",
+			got:      func(job *Job) interface{} { return *job.Permissions },
+			expected: Permissions{Scopes: map[string]string{"id-token": "write", "contents": "none"}},
+		},
+		{
+			name:     "not set",
+			content:  "name: test",
+			got:      func(job *Job) interface{} { return []interface{}{job.RunsOn, job.Permissions} },
+			expected: []interface{}{(*RunsOn)(nil), (*Permissions)(nil)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var job Job
+
+			if err := yaml.Unmarshal([]byte(tt.content), &job); err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			if got := tt.got(&job); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %#v, but got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJob_UnmarshalYAMLInvalidForms(t *testing.T) {
+	for _, content := range []string{"permissions: [read-all]", "needs: {build: true}"} {
+		var job Job
+
+		if err := yaml.Unmarshal([]byte(content), &job); err == nil {
+			t.Errorf("Expected error for %q, but got nil", content)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Steps represents a list of steps
@@ -17,6 +19,9 @@ type Step struct {
 	// Name is the name of the step.
 	Name string `yaml:"name"`
 
+	// If is the condition to run the step. Steps run only if the previous steps succeeded when it's empty.
+	If string `yaml:"if,omitempty"`
+
 	// Uses is the action to run for the step.
 	Uses string `yaml:"uses,omitempty"`
 
@@ -31,6 +36,133 @@ type Step struct {
 
 	// Shell is the shell to use for the step.
 	Shell string `yaml:"shell,omitempty"`
+
+	// WorkingDirectory is the working directory of the run step. It overrides defaults.run.working-directory of the job.
+	WorkingDirectory string `yaml:"working-directory,omitempty"`
+
+	// ContinueOnError prevents the job or the composite action from failing when the step fails.
+	ContinueOnError string `yaml:"continue-on-error,omitempty"`
+
+	// Positions maps the keys of the step to the positions of their values in the workflow file. Keys of nested
+	// mappings are joined with a dot, e.g. `if`, `run` or `with.token`. Empty if the step isn't loaded from a file.
+	Positions map[string]Position `yaml:"-"`
+}
+
+// Position represents the position of a value in the workflow file.
+type Position struct {
+	Line int `json:"line"` // Line is the line of the value. Starts at 1.
+	Col  int `json:"col"`  // Col is the column of the value. Starts at 1.
+
+	// Block is true for literal and folded block scalars, e.g. `run: |`. Line and Col point to the block indicator and
+	// the content starts on the next line.
+	Block bool `json:"block,omitempty"`
+
+	// Indent is the indentation of the content of the block scalar. Zero for other scalars.
+	Indent int `json:"indent,omitempty"`
+}
+
+// At returns the position of the given byte offset in the value at the position. Offsets in block scalars are mapped
+// to the line and the column in the content of the block. Lines of folded scalars are counted in the value, so a
+// position after a folded line break points to an earlier line. Other scalars return the position as it is.
+func (p Position) At(value string, offset int) Position {
+	if !p.Block || p.Line == 0 {
+		return p
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset > len(value) {
+		offset = len(value)
+	}
+
+	start := strings.LastIndexByte(value[:offset], '\n') + 1
+
+	return Position{
+		Line: p.Line + 1 + strings.Count(value[:start], "\n"),
+		Col:  p.Indent + offset - start + 1,
+	}
+}
+
+// UnmarshalYAML unmarshal the step and records the positions of its values.
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	type plain Step
+
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+
+	s.Positions = make(map[string]Position)
+
+	recordPositions(s.Positions, "", node)
+
+	return nil
+}
+
+// Position returns the position of the value with the given key in the workflow file. It returns the zero value if
+// the position is unknown.
+func (s *Step) Position(key string) Position {
+	return s.Positions[key]
+}
+
+// ResolveBlockIndents sets the indentation of the block scalars of the step from the lines of the workflow file. The
+// indentation is the indentation of the first non-empty line of the content, same as YAML detects it. Without the
+// source, the content is assumed to be indented two spaces more than its key.
+func (s *Step) ResolveBlockIndents(lines []string) {
+	for key, pos := range s.Positions {
+		if !pos.Block {
+			continue
+		}
+
+		// the content must be indented more than the key, less indented lines belong to the next keys
+		keyIndent := pos.Indent - 2
+
+		if pos.Line >= len(lines) {
+			continue
+		}
+
+		for _, line := range lines[pos.Line:] {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			if indent := len(line) - len(strings.TrimLeft(line, " ")); indent > keyIndent {
+				pos.Indent = indent
+			}
+
+			break
+		}
+
+		s.Positions[key] = pos
+	}
+}
+
+// recordPositions records the positions of the scalar values in the mapping node with their keys.
+func recordPositions(positions map[string]Position, prefix string, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, value := node.Content[i], node.Content[i+1]
+
+		key := prefix + keyNode.Value
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			pos := Position{Line: value.Line, Col: value.Column}
+
+			if value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+				pos.Block = true
+				pos.Indent = keyNode.Column + 1
+			}
+
+			positions[key] = pos
+		case yaml.MappingNode:
+			recordPositions(positions, key+".", value)
+		}
+	}
 }
 
 func (s *Step) LogMessage(stage ActionStage) string {
@@ -93,7 +225,7 @@ const (
 )
 
 type StepResult struct {
-	Outputs    map[string]string
-	Conclusion StepStatus
-	Outcome    StepStatus
+	Outputs    map[string]string `json:"outputs"`
+	Conclusion StepStatus        `json:"conclusion"`
+	Outcome    StepStatus        `json:"outcome"`
 }
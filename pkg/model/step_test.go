@@ -0,0 +1,111 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStep_UnmarshalYAML(t *testing.T) {
+	content := `
+id: release
+if: github.ref == 'refs/heads/main'
+uses: actions/checkout@v3
+with:
+  token: ${{ secrets.TOKEN }}
+  fetch-depth: 0
+env:
+  FOO: bar
+`
+
+	var step Step
+
+	if err := yaml.Unmarshal([]byte(content), &step); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if step.ID != "release" || step.Uses != "actions/checkout@v3" || step.With["token"] != "${{ secrets.TOKEN }}" {
+		t.Errorf("Unexpected step %+v", step)
+	}
+
+	expected := map[string]Position{
+		"id":               {Line: 2, Col: 5},
+		"if":               {Line: 3, Col: 5},
+		"uses":             {Line: 4, Col: 7},
+		"with.token":       {Line: 6, Col: 10},
+		"with.fetch-depth": {Line: 7, Col: 16},
+		"env.FOO":          {Line: 9, Col: 8},
+	}
+
+	if !reflect.DeepEqual(step.Positions, expected) {
+		t.Errorf("Expected positions %v, but got %v", expected, step.Positions)
+	}
+
+	if pos := step.Position("run"); pos != (Position{}) {
+		t.Errorf("Expected zero position for missing key, but got %v", pos)
+	}
+}
+
+func TestStep_BlockScalarPositions(t *testing.T) {
+	content := `steps:
+  - id: build
+    run: |
+      echo start
+      echo ${{ github.sha }}
+      echo ${{ foo( }}
+    env:
+      SCRIPT: >
+          first
+          second ${{ bar }}
+  - id: empty
+    run: |
+    shell: bash
+`
+
+	var workflow struct {
+		Steps Steps `yaml:"steps"`
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	build := workflow.Steps[0]
+
+	// without the source, the content is assumed to be indented two spaces more than the key
+	if pos := build.Position("run"); pos != (Position{Line: 3, Col: 10, Block: true, Indent: 6}) {
+		t.Errorf("Unexpected position of run %+v", pos)
+	}
+
+	lines := strings.Split(content, "\n")
+
+	for _, step := range workflow.Steps {
+		step.ResolveBlockIndents(lines)
+	}
+
+	tests := []struct {
+		key      string
+		value    string
+		offset   int
+		expected Position
+	}{
+		{key: "run", value: build.Run, offset: 0, expected: Position{Line: 4, Col: 7}},
+		{key: "run", value: build.Run, offset: strings.Index(build.Run, "foo("), expected: Position{Line: 6, Col: 16}},
+		{key: "run", value: build.Run, offset: len(build.Run) + 10, expected: Position{Line: 7, Col: 7}},
+		{key: "env.SCRIPT", value: build.Environment["SCRIPT"], offset: strings.Index(build.Environment["SCRIPT"], "second"), expected: Position{Line: 9, Col: 17}},
+		{key: "id", value: build.ID, offset: 3, expected: Position{Line: 2, Col: 9}},
+	}
+
+	for _, tt := range tests {
+		if pos := build.Position(tt.key).At(tt.value, tt.offset); pos != tt.expected {
+			t.Errorf("Expected position %+v for offset %d of %s, but got %+v", tt.expected, tt.offset, tt.key, pos)
+		}
+	}
+
+	// empty block scalar keeps the default indentation, the next line belongs to the next key
+	if pos := workflow.Steps[1].Position("run"); pos.Indent != 6 {
+		t.Errorf("Expected default indentation for empty block scalar, but got %+v", pos)
+	}
+}
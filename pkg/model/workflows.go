@@ -1,6 +1,10 @@
 package model
 
-import "dagger.io/dagger"
+import (
+	"strings"
+
+	"dagger.io/dagger"
+)
 
 // Workflows represents a collection of workflow.
 type Workflows map[string]*Workflow
@@ -16,3 +20,19 @@ type Workflow struct {
 
 	// TBD -- we'll add more fields here as we need them.
 }
+
+// ResolveBlockIndents sets the indentation of the block scalars in the steps of the workflow from the content of the
+// workflow file, so expression errors in block scalars point to the line and the column of the error.
+func (w *Workflow) ResolveBlockIndents(content string) {
+	lines := strings.Split(content, "\n")
+
+	for _, job := range w.Jobs {
+		if job == nil {
+			continue
+		}
+
+		for _, step := range job.Steps {
+			step.ResolveBlockIndents(lines)
+		}
+	}
+}
@@ -59,6 +59,8 @@ func loadWorkflow(ctx context.Context, path string, file *dagger.File) (*model.W
 	workflow.Path = path
 	workflow.File = file
 
+	workflow.ResolveBlockIndents(content)
+
 	// if the workflow name is not provided, use the relative path to the workflow file.
 	if workflow.Name == "" {
 		workflow.Name = path
@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// knownCommands is the set of workflow commands processed by the runner.
+var knownCommands = map[string]struct{}{
+	model.CommandGroup:         {},
+	model.CommandEndGroup:      {},
+	model.CommandDebug:         {},
+	model.CommandError:         {},
+	model.CommandWarning:       {},
+	model.CommandNotice:        {},
+	model.CommandSetEnv:        {},
+	model.CommandSetOutput:     {},
+	model.CommandSaveState:     {},
+	model.CommandAddMask:       {},
+	model.CommandAddMatcher:    {},
+	model.CommandRemoveMatcher: {},
+	model.CommandAddPath:       {},
+	model.CommandStopCommands:  {},
+	model.CommandEcho:          {},
+}
+
+// workflowCommandProcessor processes workflow commands printed to stdout by a single step execution. Stop-commands and
+// echo mode are scoped to the execution the same way runner does.
+type workflowCommandProcessor struct {
+	state  *statepkg.State
+	ss     *statepkg.StepState
+	logger *log.Logger
+	policy *commandPolicy
+
+	stopToken string // stopToken is the token to resume command processing. Empty if commands are not stopped.
+	echo      bool   // echo is true if commands should be echoed to the log.
+	err       error  // err is the first error occurred while processing commands. It fails the step.
+}
+
+// newWorkflowCommandProcessor creates a new workflow command processor for the given step.
+func newWorkflowCommandProcessor(state *statepkg.State, ss *statepkg.StepState, logger *log.Logger) *workflowCommandProcessor {
+	return &workflowCommandProcessor{state: state, ss: ss, logger: logger, policy: newCommandPolicy(state, ss)}
+}
+
+// Err returns the first error occurred while processing commands. Runner marks the step as failed if any command
+// fails, so the step should fail when this returns non-nil.
+func (p *workflowCommandProcessor) Err() error {
+	return p.err
+}
+
+// process processes a single line of the step output. If the line is not a workflow command or commands are stopped,
+// it returns false and the line should be treated as regular output.
+func (p *workflowCommandProcessor) process(line string) (bool, *model.Command, error) {
+	// while commands are stopped, only the resume token is accepted. This prevents untrusted output from injecting
+	// commands.
+	if p.stopToken != "" {
+		if strings.TrimSpace(line) != fmt.Sprintf("::%s::", p.stopToken) {
+			return false, nil, nil
+		}
+
+		token := p.stopToken
+
+		p.stopToken = ""
+
+		return true, &model.Command{Name: token, Parameters: map[string]string{}}, nil
+	}
+
+	isCommand, cmd := model.ParseCommand(line)
+	if !isCommand {
+		return false, nil, nil
+	}
+
+	// unknown commands are printed as regular output, same as runner
+	if _, ok := knownCommands[cmd.Name]; !ok {
+		p.logger.Debug(fmt.Sprintf("Unknown workflow command '%s' is ignored", cmd.Name))
+
+		return false, nil, nil
+	}
+
+	if p.echo {
+		p.logger.Info(line)
+	}
+
+	if err := p.policy.check(cmd); err != nil {
+		return true, cmd, p.fail(err)
+	}
+
+	if err := p.processCommand(cmd); err != nil {
+		return true, cmd, p.fail(fmt.Errorf("unable to process command '%s' successfully: %w", line, err))
+	}
+
+	return true, cmd, nil
+}
+
+// fail reports the error as an error annotation and keeps the first error to fail the step.
+func (p *workflowCommandProcessor) fail(err error) error {
+	p.logger.Error(err.Error())
+
+	p.state.AddAnnotation(&model.Annotation{Level: model.AnnotationLevelError, Message: err.Error(), StepID: p.ss.Step.ID})
+
+	if p.err == nil {
+		p.err = err
+	}
+
+	return err
+}
+
+func (p *workflowCommandProcessor) processCommand(cmd *model.Command) error {
+	// keep annotations in the job state, so they can be exported after the run
+	if annotation, ok := model.NewAnnotationFromCommand(cmd, p.ss.Step.ID); ok {
+		p.state.AddAnnotation(annotation)
+	}
+
+	switch cmd.Name {
+	case model.CommandGroup:
+		p.logger.Info(cmd.Value)
+		p.logger.StartGroup()
+	case model.CommandEndGroup:
+		p.logger.EndGroup()
+	case model.CommandDebug:
+		p.logger.Debug(cmd.Value)
+	case model.CommandError:
+		p.logger.Errorf(cmd.Value, annotationKeyVals(cmd)...)
+	case model.CommandWarning:
+		p.logger.Warnf(cmd.Value, annotationKeyVals(cmd)...)
+	case model.CommandNotice:
+		p.logger.Noticef(cmd.Value, annotationKeyVals(cmd)...)
+	case model.CommandSetEnv:
+		if err := os.Setenv(cmd.Parameters["name"], cmd.Value); err != nil {
+			return err
+		}
+	case model.CommandSetOutput:
+		p.ss.Result.Outputs[cmd.Parameters["name"]] = cmd.Value
+	case model.CommandSaveState:
+		p.ss.State[cmd.Parameters["name"]] = cmd.Value
+	case model.CommandAddMask:
+		p.logger.Info(fmt.Sprintf("[add-mask] %s", cmd.Value))
+	case model.CommandAddMatcher:
+		p.logger.Info(fmt.Sprintf("[add-matcher] %s", cmd.Value))
+	case model.CommandRemoveMatcher:
+		p.logger.Info(fmt.Sprintf("[remove-matcher] %s", cmd.Parameters["owner"]))
+	case model.CommandAddPath:
+		path := os.Getenv("PATH")
+		path = fmt.Sprintf("%s:%s", path, cmd.Value)
+		if err := os.Setenv("PATH", path); err != nil {
+			return err
+		}
+	case model.CommandStopCommands:
+		if cmd.Value == "" {
+			return fmt.Errorf("invalid stop-commands: token is required")
+		}
+
+		p.stopToken = cmd.Value
+	case model.CommandEcho:
+		switch strings.ToLower(strings.TrimSpace(cmd.Value)) {
+		case "on":
+			p.echo = true
+		case "off":
+			p.echo = false
+		default:
+			return fmt.Errorf("invalid echo command value: %s. Valid values are 'on' and 'off'", cmd.Value)
+		}
+	}
+
+	return nil
+}
+
+// annotationKeyVals returns annotation properties of the command as key value pairs for logging.
+func annotationKeyVals(cmd *model.Command) []interface{} {
+	return []interface{}{
+		"file", cmd.Parameters["file"],
+		"line", cmd.Parameters["line"],
+		"col", cmd.Parameters["col"],
+		"endLine", cmd.Parameters["endLine"],
+		"endCol", cmd.Parameters["endCol"],
+		"title", cmd.Parameters["title"],
+	}
+}
@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// newTestCommandProcessor returns a command processor for a step without any environment.
+func newTestCommandProcessor() *workflowCommandProcessor {
+	ss := statepkg.NewStepState(&model.Step{ID: "build"})
+
+	return newWorkflowCommandProcessor(&statepkg.State{}, ss, log.NewLogger())
+}
+
+// captureStdout returns the output written to stdout by fn, since the logger writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	return string(out)
+}
+
+func TestWorkflowCommandProcessor_StopCommands(t *testing.T) {
+	p := newTestCommandProcessor()
+
+	lines := []struct {
+		line      string
+		isCommand bool
+	}{
+		{line: "::set-output name=before::1", isCommand: true},
+		{line: "::stop-commands::tok", isCommand: true},
+		{line: "::set-output name=injected::1", isCommand: false},
+		{line: "::save-state name=injected::1", isCommand: false},
+		{line: "::wrong::", isCommand: false},
+		{line: "::tok-suffix::", isCommand: false},
+		{line: "::error::injected", isCommand: false},
+		{line: "::tok::", isCommand: true},
+		{line: "::set-output name=after::1", isCommand: true},
+	}
+
+	for _, l := range lines {
+		isCommand, _, err := p.process(l.line)
+		if err != nil {
+			t.Fatalf("Expected no error for %q, but got %s", l.line, err.Error())
+		}
+
+		if isCommand != l.isCommand {
+			t.Errorf("Expected %q to be processed as command %v, but got %v", l.line, l.isCommand, isCommand)
+		}
+	}
+
+	outputs := p.ss.Result.Outputs
+
+	if outputs["before"] != "1" || outputs["after"] != "1" {
+		t.Errorf("Expected outputs before and after stop-commands, but got %v", outputs)
+	}
+
+	if _, ok := outputs["injected"]; ok {
+		t.Errorf("Expected commands between stop-commands and the token to be ignored, but got %v", outputs)
+	}
+
+	if len(p.ss.State) != 0 || len(p.state.Annotations) != 0 {
+		t.Errorf("Expected no state or annotations while commands are stopped, but got %v and %v", p.ss.State, p.state.Annotations)
+	}
+
+	if p.Err() != nil {
+		t.Errorf("Expected no error, but got %v", p.Err())
+	}
+}
+
+func TestWorkflowCommandProcessor_StopCommandsWithoutToken(t *testing.T) {
+	p := newTestCommandProcessor()
+
+	if _, _, err := p.process("::stop-commands::"); err == nil {
+		t.Fatalf("Expected error for stop-commands without token, but got nil")
+	}
+
+	if p.Err() == nil {
+		t.Errorf("Expected the error to fail the step, but got nil")
+	}
+
+	// commands are still processed since nothing is stopped
+	if isCommand, _, _ := p.process("::set-output name=foo::bar"); !isCommand || p.ss.Result.Outputs["foo"] != "bar" {
+		t.Errorf("Expected commands to be processed, but got outputs %v", p.ss.Result.Outputs)
+	}
+}
+
+func TestWorkflowCommandProcessor_Echo(t *testing.T) {
+	p := newTestCommandProcessor()
+
+	tests := []struct {
+		line     string
+		echo     bool
+		expected bool
+	}{
+		{line: "::debug::hidden", echo: false, expected: false},
+		{line: "::echo::on", echo: true, expected: false},
+		{line: "::debug::visible", echo: true, expected: true},
+		{line: "::echo::OFF", echo: false, expected: true},
+		{line: "::debug::hidden again", echo: false, expected: false},
+	}
+
+	for _, tt := range tests {
+		out := captureStdout(t, func() {
+			if _, _, err := p.process(tt.line); err != nil {
+				t.Fatalf("Expected no error for %q, but got %s", tt.line, err.Error())
+			}
+		})
+
+		if p.echo != tt.echo {
+			t.Errorf("Expected echo %v after %q, but got %v", tt.echo, tt.line, p.echo)
+		}
+
+		if echoed := strings.Contains(out, tt.line); echoed != tt.expected {
+			t.Errorf("Expected %q to be echoed %v, but got output %q", tt.line, tt.expected, out)
+		}
+	}
+
+	if _, _, err := p.process("::echo::maybe"); err == nil {
+		t.Errorf("Expected error for invalid echo value, but got nil")
+	}
+}
+
+func TestWorkflowCommandProcessor_RemoveMatcher(t *testing.T) {
+	p := newTestCommandProcessor()
+
+	var (
+		isCommand bool
+		cmd       *model.Command
+		err       error
+	)
+
+	out := captureStdout(t, func() {
+		isCommand, cmd, err = p.process("::remove-matcher owner=eslint::")
+	})
+
+	if err != nil || !isCommand {
+		t.Fatalf("Expected remove-matcher to be processed, but got %v, %v", isCommand, err)
+	}
+
+	if cmd.Parameters["owner"] != "eslint" {
+		t.Errorf("Expected owner eslint, but got %v", cmd.Parameters)
+	}
+
+	if !strings.Contains(out, "[remove-matcher] eslint") {
+		t.Errorf("Expected remove-matcher to be logged, but got %q", out)
+	}
+}
+
+func TestWorkflowCommandProcessor_UnknownCommand(t *testing.T) {
+	t.Setenv("RUNNER_DEBUG", "1")
+
+	p := newTestCommandProcessor()
+
+	var (
+		isCommand bool
+		err       error
+	)
+
+	out := captureStdout(t, func() {
+		isCommand, _, err = p.process("::set-secret name=foo::bar")
+	})
+
+	if err != nil || isCommand {
+		t.Errorf("Expected unknown command to be treated as output, but got %v, %v", isCommand, err)
+	}
+
+	if !strings.Contains(out, "[debug] Unknown workflow command 'set-secret' is ignored") {
+		t.Errorf("Expected debug log for the unknown command, but got %q", out)
+	}
+
+	if len(p.ss.Result.Outputs) != 0 || p.Err() != nil {
+		t.Errorf("Expected unknown command to have no effect, but got outputs %v and error %v", p.ss.Result.Outputs, p.Err())
+	}
+}
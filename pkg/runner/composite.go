@@ -0,0 +1,283 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// maxCompositeDepth is the maximum depth of nested composite actions, same as the runner.
+const maxCompositeDepth = 10
+
+// loadCompositeActions loads the actions used by the steps of the composite action recursively, so nested actions are
+// ready before running any step.
+func (r *runner) loadCompositeActions(ctx context.Context, as *statepkg.ActionState, depth int) error {
+	if as.Metadata.Runs.Using != model.ActionRunsUsingComposite {
+		return nil
+	}
+
+	if depth >= maxCompositeDepth {
+		return fmt.Errorf("composite action %s exceeds the maximum depth of %d nested composite actions", as.Source, maxCompositeDepth)
+	}
+
+	for _, step := range as.Metadata.Runs.Steps {
+		if step.Type() != model.StepTypeAction {
+			continue
+		}
+
+		if _, ok := r.state.GetActionState(step.Uses); !ok {
+			if err := r.state.AddAction(ctx, r.client, step.Uses, r.opts.Actions); err != nil {
+				return err
+			}
+		}
+
+		nested, _ := r.state.GetActionState(step.Uses)
+
+		r.logger.Debug(fmt.Sprintf("Load action '%s' used by composite action '%s'", step.Uses, as.Source))
+
+		if nested.Metadata.Runs.Using.IsNode() {
+			if _, err := r.resolveNode(ctx, nested.Metadata.Runs.Using); err != nil {
+				return err
+			}
+		}
+
+		if err := r.loadCompositeActions(ctx, nested, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execStepComposite executes the steps of the composite action in order and resolves the outputs of the action from
+// the steps context of the composite action. Composite actions only have main stage.
+func (r *runner) execStepComposite(ctx context.Context, ss *statepkg.StepState, as *statepkg.ActionState) (ExecStepStatus, error) {
+	inputs, err := r.compositeInputs(ss, as)
+	if err != nil {
+		return StatusFailed, err
+	}
+
+	// steps of the composite action use files of the action with GITHUB_ACTION_PATH
+	r.mountAction(as)
+
+	// steps context of the composite action is isolated from the job steps context
+	steps := make(map[string]*model.StepResult)
+
+	// status of the composite action, status functions of the steps check it instead of the job status
+	status := statepkg.JobStatusSuccess
+
+	var errs []error
+
+	for idx, step := range as.Metadata.Runs.Steps {
+		ac := r.compositeContext(as, inputs, steps, status)
+
+		child, localID := newCompositeChildStep(ss, as, step, idx)
+
+		childSS := statepkg.NewStepState(child)
+		childSS.Context = ac
+
+		steps[localID] = childSS.Result
+
+		// the condition is checked first, so values of skipped steps are never evaluated
+		ok, err := evalCondition(ac, step.If)
+		if err != nil {
+			status = statepkg.JobStatusFailure
+			errs = append(errs, fmt.Errorf("composite action %s failed to evaluate if of step %s: %w", as.Source, localID, err))
+
+			continue
+		}
+
+		if !ok {
+			r.logger.Debug(fmt.Sprintf("Skip step %s of composite action %s, if evaluated to false", localID, as.Source))
+
+			childSS.Result.Conclusion = model.StepStatusSkipped
+			childSS.Result.Outcome = model.StepStatusSkipped
+
+			continue
+		}
+
+		result, err := r.execCompositeChildStep(ctx, childSS, as, localID)
+		if result == StatusFailed {
+			continueOnError, cerr := evalContinueOnError(ac, child.ContinueOnError)
+			if cerr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to evaluate continue-on-error: %w", cerr))
+			}
+
+			if continueOnError {
+				r.logger.Warn(fmt.Sprintf("Step %s of composite action %s failed but continue-on-error is set, ignoring the failure: %v", localID, as.Source, err))
+
+				childSS.Result.Conclusion = model.StepStatusSuccess
+
+				continue
+			}
+
+			status = statepkg.JobStatusFailure
+			errs = append(errs, fmt.Errorf("composite action %s failed at step %s: %v", as.Source, localID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return StatusFailed, errors.Join(errs...)
+	}
+
+	// resolve outputs from the steps context of the composite action
+	ac := r.compositeContext(as, inputs, steps, status)
+
+	for name, output := range as.Metadata.Outputs {
+		value, err := actions.NewString(output.Value).Eval(ac)
+		if err != nil {
+			return StatusFailed, fmt.Errorf("failed to evaluate output %s of composite action %s: %v", name, as.Source, err)
+		}
+
+		ss.Result.Outputs[name] = value
+	}
+
+	return StatusSucceeded, nil
+}
+
+// compositeInputs returns the inputs context of the composite action. Values are evaluated against the context of the
+// step, so inputs of nested composite actions see the inputs of the parent, and default values are used for inputs
+// missing in the step config.
+func (r *runner) compositeInputs(ss *statepkg.StepState, as *statepkg.ActionState) (map[string]string, error) {
+	ac := r.state.GetStepActionsContext(ss)
+
+	inputs := make(map[string]string)
+
+	for name, value := range ss.Step.With {
+		res, err := actions.NewString(value).Eval(ac)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate input %s: %v", name, err)
+		}
+
+		inputs[name] = res
+	}
+
+	for name, input := range as.Metadata.Inputs {
+		if value, ok := model.LookupInput(inputs, name); ok {
+			// use declared name of the input, so the inputs context is not case-sensitive to the step config
+			inputs[name] = value
+			continue
+		}
+
+		res, err := actions.NewString(input.Default).Eval(ac)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate default value for input %s: %v", name, err)
+		}
+
+		inputs[name] = res
+	}
+
+	return inputs, nil
+}
+
+// compositeContext returns the expression context for the steps of the composite action. The status is the status of
+// the composite action exposed as github.action_status.
+func (r *runner) compositeContext(as *statepkg.ActionState, inputs map[string]string, steps map[string]*model.StepResult, status string) *actions.Context {
+	ac := r.state.GetActionsContext()
+
+	ac.Github.ActionStatus = status
+	ac.Inputs = inputs
+	ac.Steps = steps
+	ac.Github.ActionPath = as.Path
+
+	return ac
+}
+
+// newCompositeChildStep creates the step to execute for the step of the composite action. Values of the step are
+// copied as they are, they're evaluated once against the composite context of the step state when the step runs, since
+// the job context doesn't have inputs and steps of the composite action. It returns the step and the id of the step in
+// the steps context of the composite action.
+func newCompositeChildStep(parent *statepkg.StepState, as *statepkg.ActionState, step model.Step, idx int) (*model.Step, string) {
+	localID := step.ID
+	if localID == "" {
+		localID = "__" + strconv.Itoa(idx)
+	}
+
+	child := &model.Step{
+		ID:               filepath.Join(parent.Step.ID, localID),
+		Name:             step.Name,
+		Uses:             step.Uses,
+		Run:              step.Run,
+		Shell:            step.Shell,
+		WorkingDirectory: step.WorkingDirectory,
+		ContinueOnError:  step.ContinueOnError,
+		With:             make(map[string]string),
+		Environment:      map[string]string{"GITHUB_ACTION_PATH": as.Path},
+	}
+
+	for k, v := range step.With {
+		child.With[k] = v
+	}
+
+	for k, v := range step.Environment {
+		child.Environment[k] = v
+	}
+
+	return child, localID
+}
+
+// execCompositeChildStep evaluates the name and the script of the step of the composite action against the context of
+// the step state and executes the step. Inputs and environment variables are evaluated by the step execution.
+func (r *runner) execCompositeChildStep(ctx context.Context, ss *statepkg.StepState, as *statepkg.ActionState, localID string) (ExecStepStatus, error) {
+	eval := func(field, value string) (string, error) {
+		res, err := actions.NewString(value).Eval(ss.Context)
+		if err != nil {
+			return "", fmt.Errorf("failed to evaluate %s of step %s in composite action %s: %w", field, localID, as.Source, err)
+		}
+
+		return res, nil
+	}
+
+	fail := func(err error) (ExecStepStatus, error) {
+		ss.Result.Conclusion = model.StepStatusFailure
+		ss.Result.Outcome = model.StepStatusFailure
+
+		return StatusFailed, err
+	}
+
+	name, err := eval("name", ss.Step.Name)
+	if err != nil {
+		return fail(err)
+	}
+
+	ss.Step.Name = name
+
+	if ss.Step.Type() == model.StepTypeRun {
+		run, err := eval("run", ss.Step.Run)
+		if err != nil {
+			return fail(err)
+		}
+
+		workingDirectory, err := eval("working-directory", ss.Step.WorkingDirectory)
+		if err != nil {
+			return fail(err)
+		}
+
+		if err := r.writeScript(ss.Step.ID, run, workingDirectory, false); err != nil {
+			return fail(err)
+		}
+	}
+
+	return r.execStep(ctx, ss, model.ActionStageMain)
+}
+
+// evalContinueOnError evaluates continue-on-error of the step. Empty value means the step fails its parent.
+func evalContinueOnError(ac *actions.Context, value string) (bool, error) {
+	if strings.TrimSpace(value) == "" {
+		return false, nil
+	}
+
+	res, err := actions.NewString(value).Eval(ac)
+	if err != nil {
+		return false, err
+	}
+
+	return convertToBoolean(res), nil
+}
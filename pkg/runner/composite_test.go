@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+func TestNewCompositeChildStep(t *testing.T) {
+	parent := statepkg.NewStepState(&model.Step{ID: "build"})
+	as := &statepkg.ActionState{Source: "actions/hello@v1", Path: "/actions/hello"}
+
+	step := model.Step{
+		Name:             "Greet ${{ inputs.name }}",
+		Run:              "echo ${{ inputs.name }}",
+		WorkingDirectory: "${{ inputs.dir }}",
+		ContinueOnError:  "true",
+		With:             map[string]string{"title": "${{ inputs.title }}"},
+		Environment:      map[string]string{"TITLE": "${{ inputs.title }}"},
+	}
+
+	child, localID := newCompositeChildStep(parent, as, step, 2)
+
+	if localID != "__2" || child.ID != "build/__2" {
+		t.Errorf("Expected local id __2 and id build/__2, but got %s and %s", localID, child.ID)
+	}
+
+	// values are evaluated once when the step runs, so they're copied without evaluation
+	if child.Name != step.Name || child.Run != step.Run || child.WorkingDirectory != step.WorkingDirectory {
+		t.Errorf("Expected values to be copied as they are, but got %+v", child)
+	}
+
+	if child.ContinueOnError != "true" {
+		t.Errorf("Expected continue-on-error to be copied, but got %q", child.ContinueOnError)
+	}
+
+	if !reflect.DeepEqual(child.With, step.With) {
+		t.Errorf("Expected with %v, but got %v", step.With, child.With)
+	}
+
+	expectedEnv := map[string]string{"TITLE": "${{ inputs.title }}", "GITHUB_ACTION_PATH": "/actions/hello"}
+
+	if !reflect.DeepEqual(child.Environment, expectedEnv) {
+		t.Errorf("Expected env %v, but got %v", expectedEnv, child.Environment)
+	}
+}
+
+func TestRunner_RunScript(t *testing.T) {
+	job := &model.Job{Defaults: &model.Defaults{Run: &model.RunDefaults{WorkingDirectory: "default"}}}
+
+	r := &runner{state: &statepkg.State{Job: job}, logger: log.NewLogger()}
+
+	tests := []struct {
+		name             string
+		workingDirectory string
+		jobDefaults      bool
+		expected         string
+	}{
+		{name: "job defaults", jobDefaults: true, expected: "cd 'default'\n"},
+		{name: "step working directory", workingDirectory: "it's", jobDefaults: true, expected: `cd 'it'\''s'` + "\n"},
+		{name: "composite step without defaults", expected: ""},
+		{name: "composite step working directory", workingDirectory: "sub", expected: "cd 'sub'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := string(r.runScript("echo hello", tt.workingDirectory, tt.jobDefaults))
+
+			if expected := "#!/bin/bash\n" + tt.expected + "echo hello"; script != expected {
+				t.Errorf("Expected script %q, but got %q", expected, script)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/expression"
+)
+
+// evalCondition evaluates the condition against the given context. Conditions without a status check function are
+// evaluated as `success() && (<condition>)` and empty conditions as `success()`, same as the runner. The implicit
+// success() is evaluated separately, so expression errors point to the condition as it's written.
+func evalCondition(ac *actions.Context, condition string) (bool, error) {
+	condition = strings.TrimSpace(condition)
+
+	// strip expression syntax, so we can wrap the condition with success() if needed
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(condition, "${{"), "}}"))
+	}
+
+	if condition == "" {
+		condition = "success()"
+	}
+
+	expr, err := expression.NewExpression(condition)
+	if err != nil {
+		// evaluate the condition anyway to report the error with its position
+		return actions.NewBoolExpr(condition).Eval(ac)
+	}
+
+	if !expr.CallsStatusFunction() {
+		ok, err := actions.NewBoolExpr("success()").Eval(ac)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return actions.NewBoolExpr(condition).Eval(ac)
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+func TestEvalCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobStatus string
+		condition string
+		expected  bool
+	}{
+		{name: "empty condition on success", jobStatus: statepkg.JobStatusSuccess, condition: "", expected: true},
+		{name: "empty condition on failure", jobStatus: statepkg.JobStatusFailure, condition: "", expected: false},
+		{name: "implicit success on failure", jobStatus: statepkg.JobStatusFailure, condition: "true", expected: false},
+		{name: "status function", jobStatus: statepkg.JobStatusFailure, condition: "${{ failure() }}", expected: true},
+		{name: "status name in string literal", jobStatus: statepkg.JobStatusFailure, condition: "contains('always()', 'always')", expected: false},
+		{name: "cancelled job skips steps", jobStatus: statepkg.JobStatusCancelled, condition: "", expected: false},
+		{name: "cancelled job runs always", jobStatus: statepkg.JobStatusCancelled, condition: "always()", expected: true},
+		{name: "cancelled job runs cancelled", jobStatus: statepkg.JobStatusCancelled, condition: "cancelled()", expected: true},
+		{name: "cancelled job is not failed", jobStatus: statepkg.JobStatusCancelled, condition: "failure()", expected: false},
+		{name: "string literal", jobStatus: statepkg.JobStatusSuccess, condition: "'x'", expected: true},
+		{name: "empty string literal", jobStatus: statepkg.JobStatusSuccess, condition: "''", expected: false},
+		{name: "string context value", jobStatus: statepkg.JobStatusSuccess, condition: "env.FLAG", expected: true},
+		{name: "string context value in expression syntax", jobStatus: statepkg.JobStatusSuccess, condition: "${{ env.FLAG }}", expected: true},
+		{name: "empty string context value", jobStatus: statepkg.JobStatusSuccess, condition: "env.EMPTY", expected: false},
+		{name: "missing context value", jobStatus: statepkg.JobStatusSuccess, condition: "env.MISSING", expected: false},
+		{name: "number", jobStatus: statepkg.JobStatusSuccess, condition: "1", expected: true},
+		{name: "zero", jobStatus: statepkg.JobStatusSuccess, condition: "0", expected: false},
+		{name: "number context value", jobStatus: statepkg.JobStatusSuccess, condition: "fromJSON(env.COUNT)", expected: true},
+		{name: "and with string", jobStatus: statepkg.JobStatusSuccess, condition: "always() && env.FLAG", expected: true},
+		{name: "and with empty string", jobStatus: statepkg.JobStatusSuccess, condition: "always() && env.EMPTY", expected: false},
+		{name: "and with string on failure", jobStatus: statepkg.JobStatusFailure, condition: "always() && 'yes'", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &statepkg.State{JobStatus: tt.jobStatus, Env: map[string]string{"FLAG": "true", "EMPTY": "", "COUNT": "3"}}
+
+			ok, err := evalCondition(state.GetActionsContext(), tt.condition)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			if ok != tt.expected {
+				t.Errorf("Expected %v, but got %v for condition: %s", tt.expected, ok, tt.condition)
+			}
+		})
+	}
+}
+
+func TestRunner_StepContext(t *testing.T) {
+	r := &runner{state: &statepkg.State{JobStatus: statepkg.JobStatusSuccess}, logger: log.NewLogger()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if stepCtx := r.stepContext(ctx); stepCtx != ctx || r.state.JobStatus != statepkg.JobStatusSuccess {
+		t.Errorf("Expected job context and success status before cancel, but got status %s", r.state.JobStatus)
+	}
+
+	r.failJob()
+	cancel()
+
+	stepCtx := r.stepContext(ctx)
+
+	if stepCtx.Err() != nil {
+		t.Errorf("Expected steps after cancel to run without the job context, but got %v", stepCtx.Err())
+	}
+
+	if r.state.JobStatus != statepkg.JobStatusCancelled {
+		t.Errorf("Expected job status %s, but got %s", statepkg.JobStatusCancelled, r.state.JobStatus)
+	}
+
+	r.failJob()
+
+	if r.state.JobStatus != statepkg.JobStatusCancelled {
+		t.Errorf("Expected cancelled job to stay cancelled after a failure, but got %s", r.state.JobStatus)
+	}
+}
+
+func TestRunner_ContinueOnError(t *testing.T) {
+	tests := []struct {
+		name            string
+		continueOnError string
+		expected        bool
+	}{
+		{name: "not set", continueOnError: "", expected: false},
+		{name: "true", continueOnError: "true", expected: true},
+		{name: "false", continueOnError: "false", expected: false},
+		{name: "expression", continueOnError: "${{ env.FLAG == 'true' }}", expected: true},
+		{name: "false expression", continueOnError: "${{ env.EMPTY != '' }}", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &statepkg.State{JobStatus: statepkg.JobStatusSuccess, Env: map[string]string{"FLAG": "true", "EMPTY": ""}}
+
+			r := &runner{state: state, logger: log.NewLogger()}
+
+			ss := statepkg.NewStepState(&model.Step{ID: "build", ContinueOnError: tt.continueOnError})
+			ss.Result.Conclusion = model.StepStatusFailure
+			ss.Result.Outcome = model.StepStatusFailure
+
+			err := r.continueOnError(ss, errors.New("exit status 1"))
+
+			if ignored := err == nil; ignored != tt.expected {
+				t.Fatalf("Expected failure to be ignored %v, but got error %v", tt.expected, err)
+			}
+
+			conclusion := model.StepStatusFailure
+			if tt.expected {
+				conclusion = model.StepStatusSuccess
+			}
+
+			if ss.Result.Conclusion != conclusion || ss.Result.Outcome != model.StepStatusFailure {
+				t.Errorf("Expected conclusion %s and outcome failure, but got %s and %s", conclusion, ss.Result.Conclusion, ss.Result.Outcome)
+			}
+		})
+	}
+}
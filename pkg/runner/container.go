@@ -0,0 +1,418 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/config"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// containerExitCodeFile is the file in the job container the exit code of the last command is written to. Dagger
+// fails the pipeline when a command fails, so the command is wrapped to read its exit code and output afterwards.
+const containerExitCodeFile = "/tmp/.ghx-exit-code"
+
+// containerEnvPrefixes is the list of host environment variable prefixes inherited by the job container.
+var containerEnvPrefixes = []string{"GITHUB_", "RUNNER_", "ACTIONS_", "CI"}
+
+// jobContainer runs the steps of a container job in a dagger container.
+//
+// Directories the steps share with ghx, workspace and runner temp directory, are mounted at the same path in the
+// container and exported back to the host after each command, so file commands, scripts and the workspace work the
+// same as the steps running on the host. Commands run on top of the previous one, so changes outside the mounted
+// directories, e.g. installed packages, are kept between the steps like a long-running container.
+//
+// Syncing has costs and limits compared to a bind mount:
+//   - Mounted directories are uploaded before and exported after every command in full, large workspaces slow down
+//     every step.
+//   - Files deleted in the container are not deleted on the host, export only writes files.
+//   - Output of the command is not streamed, it's available once the command exits.
+//
+// Directories ghx only provides to the steps, like actions, are mounted read-only and never exported. Node runtimes are
+// mounted read-only at /__e/<runtime> like the runner, so they never hide directories of the image.
+type jobContainer struct {
+	client    *dagger.Client
+	container *dagger.Container
+	mounts    []string          // mounts is the list of host directories mounted at the same path and exported back
+	readOnly  []string          // readOnly is the list of host directories mounted at the same path without exporting
+	externals map[string]string // externals is the map of container paths to host directories mounted read-only
+	imageEnv  map[string]string // imageEnv is the environment of the image, restored after each command
+	hostEnv   map[string]string // hostEnv is the environment of ghx when the container is created
+}
+
+// newJobContainer creates the job container from the container configuration of the job. Services are bound to the
+// container with their ids, so steps can reach them by service name same as the runner.
+func newJobContainer(ctx context.Context, client *dagger.Client, logger *log.Logger, cfg *model.Container, ac *actions.Context, services map[string]*dagger.Container) (*jobContainer, error) {
+	container, err := newContainer(client, "job container", cfg, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := splitArgs(cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options for job container: %v", err)
+	}
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+
+		switch name {
+		case "--user", "-u", "--env", "-e", "--workdir", "-w":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("invalid options for job container: missing value for %s", name)
+				}
+
+				i++
+				value = args[i]
+			}
+		default:
+			logger.Warn(fmt.Sprintf("Option %s of job container is not supported, it's ignored", name))
+			continue
+		}
+
+		switch name {
+		case "--user", "-u":
+			container = container.WithUser(value)
+		case "--env", "-e":
+			k, v, _ := strings.Cut(value, "=")
+			container = container.WithEnvVariable(k, v)
+		case "--workdir", "-w":
+			container = container.WithWorkdir(value)
+		}
+	}
+
+	for _, volume := range cfg.Volumes {
+		parts := strings.Split(volume, ":")
+
+		switch {
+		case len(parts) == 1:
+			container = container.WithMountedTemp(parts[0])
+		case filepath.IsAbs(parts[0]):
+			container = container.WithMountedDirectory(parts[1], client.Host().Directory(parts[0]))
+		default:
+			container = container.WithMountedCache(parts[1], client.CacheVolume(parts[0]))
+		}
+	}
+
+	ids := make([]string, 0, len(services))
+
+	for id := range services {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		container = container.WithServiceBinding(id, services[id])
+	}
+
+	vars, err := container.EnvVariables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job container: %v", err)
+	}
+
+	jc := &jobContainer{
+		client:    client,
+		container: container,
+		externals: make(map[string]string),
+		imageEnv:  make(map[string]string),
+		hostEnv:   make(map[string]string),
+	}
+
+	for _, v := range vars {
+		name, err := v.Name(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := v.Value(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		jc.imageEnv[name] = value
+	}
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		jc.hostEnv[k] = v
+	}
+
+	jc.mountRunnerDirs()
+
+	return jc, nil
+}
+
+// mountRunnerDirs mounts the directories of the runner shared with the steps: the workspace, the temp directory and
+// ghx data home with the scripts, file commands and env files of the steps. Data home is mounted in addition to
+// RUNNER_TEMP, since RUNNER_TEMP isn't necessarily its parent, e.g. /home/runner/work/_temp on hosted runners.
+func (c *jobContainer) mountRunnerDirs() {
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		c.Mount(workspace)
+	}
+
+	temp := os.Getenv("RUNNER_TEMP")
+	if temp == "" {
+		temp = filepath.Dir(config.DataHome)
+	}
+
+	c.Mount(temp)
+	c.Mount(config.DataHome)
+}
+
+// ID returns the id of the container for the job context.
+func (c *jobContainer) ID(ctx context.Context) (string, error) {
+	return c.container.Hostname(ctx)
+}
+
+// Mount mounts the host directory at the same path in the container. Changes in the directory are exported back to
+// the host after each command. Directories already mounted are ignored.
+func (c *jobContainer) Mount(dir string) {
+	dir = absPath(dir)
+
+	if containsDir(c.mounts, dir) {
+		return
+	}
+
+	c.mounts = append(c.mounts, dir)
+}
+
+// MountReadOnly mounts the host directory at the same path in the container without exporting the changes back to
+// the host. Directories already mounted are ignored.
+func (c *jobContainer) MountReadOnly(dir string) {
+	dir = absPath(dir)
+
+	if containsDir(c.mounts, dir) || containsDir(c.readOnly, dir) {
+		return
+	}
+
+	c.readOnly = append(c.readOnly, dir)
+}
+
+// MountExternal mounts the host directory read-only at the given path in the container, e.g. a node runtime at
+// /__e/node20. The path must not be a directory of the image, since the mount hides it.
+func (c *jobContainer) MountExternal(dir, path string) {
+	c.externals[path] = absPath(dir)
+}
+
+// Env returns the environment the commands inherit in the container. The host environment isn't inherited except the
+// variables describing the run and the variables added by the steps with GITHUB_ENV and GITHUB_PATH.
+func (c *jobContainer) Env() []string {
+	var env []string
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+
+		if k == "PATH" {
+			// only paths added by the steps, the rest belongs to the host
+			if added := strings.TrimPrefix(v, c.hostEnv["PATH"]); added != v && added != "" {
+				env = append(env, fmt.Sprintf("PATH=%s%s", c.imageEnv["PATH"], added))
+			}
+
+			continue
+		}
+
+		if original, ok := c.hostEnv[k]; ok && original == v && !hasAnyPrefix(k, containerEnvPrefixes) {
+			continue
+		}
+
+		env = append(env, kv)
+	}
+
+	return env
+}
+
+// Exec executes the command in the container and returns stdout and stderr of the command once it exits. A command
+// exiting with a non-zero code returns an error with its output. Mounted directories are uploaded before the command
+// and exported back after it, read-only ones are only uploaded.
+func (c *jobContainer) Exec(ctx context.Context, args []string, env []string) (string, string, error) {
+	container := c.container
+
+	for _, mount := range append(c.mounts, c.readOnly...) {
+		container = container.WithMountedDirectory(mount, c.client.Host().Directory(mount))
+	}
+
+	paths := make([]string, 0, len(c.externals))
+
+	for path := range c.externals {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		container = container.WithMountedDirectory(path, c.client.Host().Directory(c.externals[path]))
+	}
+
+	keys := []string{"GHX_EXEC_AT"}
+
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+
+		container = container.WithEnvVariable(k, v)
+
+		keys = append(keys, k)
+	}
+
+	script := fmt.Sprintf(`"$@"; echo $? > %s`, containerExitCodeFile)
+
+	container = container.
+		WithEnvVariable("GHX_EXEC_AT", time.Now().String()). // avoid cached results
+		WithExec(append([]string{"sh", "-c", script, "ghx"}, args...), dagger.ContainerWithExecOpts{SkipEntrypoint: true})
+
+	stdout, err := container.Stdout(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to execute command in job container: %v", err)
+	}
+
+	stderr, err := container.Stderr(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to execute command in job container: %v", err)
+	}
+
+	contents, err := container.File(containerExitCodeFile).Contents(ctx)
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("failed to read exit code of the command: %v", err)
+	}
+
+	for _, mount := range c.mounts {
+		if _, err := container.Directory(mount).Export(ctx, mount); err != nil {
+			return stdout, stderr, fmt.Errorf("failed to export %s from job container: %v", mount, err)
+		}
+	}
+
+	// environment of the command shouldn't leak to the next one
+	for _, k := range keys {
+		if v, ok := c.imageEnv[k]; ok {
+			container = container.WithEnvVariable(k, v)
+		} else {
+			container = container.WithoutEnvVariable(k)
+		}
+	}
+
+	c.container = container
+
+	code, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("invalid exit code %q of the command", contents)
+	}
+
+	if code != 0 {
+		return stdout, stderr, fmt.Errorf("exit status %d", code)
+	}
+
+	return stdout, stderr, nil
+}
+
+// newContainer creates a container from the image, credentials and env of the container configuration. Expressions are
+// evaluated against the given context. The name is used in error messages, e.g. service redis.
+func newContainer(client *dagger.Client, name string, cfg *model.Container, ac *actions.Context) (*dagger.Container, error) {
+	eval := func(field, value string) (string, error) {
+		res, err := actions.NewString(value).Eval(ac)
+		if err != nil {
+			return "", fmt.Errorf("failed to evaluate %s of %s: %v", field, name, err)
+		}
+
+		return res, nil
+	}
+
+	image, err := eval("image", cfg.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	if image == "" {
+		return nil, fmt.Errorf("image of %s is required", name)
+	}
+
+	container := client.Container()
+
+	if cfg.Credentials != nil {
+		username, err := eval("credentials.username", cfg.Credentials.Username)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := eval("credentials.password", cfg.Credentials.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		secret := client.SetSecret(fmt.Sprintf("%s-password", strings.ReplaceAll(name, " ", "-")), password)
+
+		container = container.WithRegistryAuth(imageRegistry(image), username, secret)
+	}
+
+	container = container.From(image)
+
+	envs := make([]string, 0, len(cfg.Environment))
+
+	for k := range cfg.Environment {
+		envs = append(envs, k)
+	}
+
+	sort.Strings(envs)
+
+	for _, k := range envs {
+		v, err := eval("env "+k, cfg.Environment[k])
+		if err != nil {
+			return nil, err
+		}
+
+		container = container.WithEnvVariable(k, v)
+	}
+
+	return container, nil
+}
+
+// imageRegistry returns the registry address of the image reference, docker.io for images without a registry.
+func imageRegistry(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if !found || !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return "docker.io"
+	}
+
+	return first
+}
+
+// containsDir returns true if the directory is one of the directories or inside one of them.
+func containsDir(dirs []string, dir string) bool {
+	for _, d := range dirs {
+		if dir == d || strings.HasPrefix(dir, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// absPath returns the absolute path of the directory. Mount paths in the container must be absolute, relative ones
+// are resolved against the working directory of ghx same as the host directories.
+func absPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+
+	return dir
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
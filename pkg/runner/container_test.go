@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/config"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+func TestJobContainer_Mount(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &jobContainer{}
+
+	c.Mount("/home/runner/work/repo")
+	c.Mount("/home/runner/work/repo/sub")
+	c.MountReadOnly("/home/runner/work/repo/.github/actions/local")
+	c.MountReadOnly("/home/runner/.cache/ghx/actions/abc")
+	c.MountReadOnly("/home/runner/.cache/ghx/actions/abc/dist")
+	c.MountReadOnly("relative")
+
+	if expected := []string{"/home/runner/work/repo"}; !reflect.DeepEqual(c.mounts, expected) {
+		t.Errorf("Expected mounts %v, but got %v", expected, c.mounts)
+	}
+
+	if expected := []string{"/home/runner/.cache/ghx/actions/abc", filepath.Join(wd, "relative")}; !reflect.DeepEqual(c.readOnly, expected) {
+		t.Errorf("Expected read-only mounts %v, but got %v", expected, c.readOnly)
+	}
+}
+
+func TestJobContainer_MountRunnerDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		temp     string
+		expected []string
+	}{
+		{
+			name:     "default temp contains data home",
+			temp:     "",
+			expected: []string{"/home/runner/work/repo", filepath.Dir(config.DataHome)},
+		},
+		{
+			name:     "hosted runner temp",
+			temp:     "/home/runner/work/_temp",
+			expected: []string{"/home/runner/work/repo", "/home/runner/work/_temp", config.DataHome},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_WORKSPACE", "/home/runner/work/repo")
+			t.Setenv("RUNNER_TEMP", tt.temp)
+
+			c := &jobContainer{}
+			c.mountRunnerDirs()
+
+			if !reflect.DeepEqual(c.mounts, tt.expected) {
+				t.Errorf("Expected mounts %v, but got %v", tt.expected, c.mounts)
+			}
+
+			// scripts of the steps are written under data home, it must be reachable from the container
+			if !containsDir(c.mounts, config.GetPath("steps", "build", "run.sh")) {
+				t.Errorf("Expected data home to be mounted, but got %v", c.mounts)
+			}
+		})
+	}
+}
+
+func TestRunner_MountAction(t *testing.T) {
+	step := &model.Step{ID: "hello", Uses: "actions/hello@v1"}
+
+	state := &statepkg.State{
+		Job: &model.Job{Container: &model.Container{Image: "node:20"}, Steps: []*model.Step{step}},
+		Actions: map[string]*statepkg.ActionState{
+			step.Uses: {Source: step.Uses, Path: "/home/runner/.cache/ghx/actions/abc"},
+		},
+	}
+
+	as, _ := state.GetActionState(step.Uses)
+
+	// steps run on the host, nothing to mount
+	r := &runner{state: state}
+	r.mountAction(as)
+
+	// container job, action is outside of the workspace
+	r.container = &jobContainer{}
+	r.container.Mount("/home/runner/work/repo")
+	r.mountAction(as)
+
+	if expected := []string{as.Path}; !reflect.DeepEqual(r.container.readOnly, expected) {
+		t.Errorf("Expected action path to be mounted read-only, but got %v", r.container.readOnly)
+	}
+}
+
+func TestRunner_ResolveNodeContainerJob(t *testing.T) {
+	externals := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(externals, "node20", "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(externals, "node20", "bin", "node"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// node in PATH matches node16, but it must never be used for container jobs
+	bin := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(bin, "node"), []byte("#!/bin/sh\necho v16.20.0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", bin)
+
+	state := &statepkg.State{Job: &model.Job{Container: &model.Container{Image: "golang:1.21"}}}
+
+	r := &runner{state: state, node: newNodeResolver(nil, log.NewLogger(), externals, nil)}
+	r.container = &jobContainer{externals: make(map[string]string)}
+
+	path, err := r.resolveNode(context.Background(), model.ActionRunsUsingNode20)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if path != "/__e/node20/bin/node" {
+		t.Errorf("Expected node in /__e/node20, but got %s", path)
+	}
+
+	if expected := map[string]string{"/__e/node20": filepath.Join(externals, "node20")}; !reflect.DeepEqual(r.container.externals, expected) {
+		t.Errorf("Expected externals %v, but got %v", expected, r.container.externals)
+	}
+
+	if len(r.container.readOnly) > 0 || len(r.container.mounts) > 0 {
+		t.Errorf("Expected no host directories mounted at the same path, but got %v and %v", r.container.readOnly, r.container.mounts)
+	}
+
+	if _, err := r.resolveNode(context.Background(), model.ActionRunsUsingNode16); err == nil {
+		t.Errorf("Expected node16 from PATH not to be used for container jobs")
+	}
+}
@@ -2,22 +2,24 @@ package runner
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/aweris/ghx/internal/log"
 	"github.com/aweris/ghx/pkg/actions"
 	"github.com/aweris/ghx/pkg/config"
+	"github.com/aweris/ghx/pkg/expression"
 	"github.com/aweris/ghx/pkg/model"
 	statepkg "github.com/aweris/ghx/pkg/state"
 )
 
-// getStepEnv returns the environment variables for the step to load in cmd exec
-func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.ActionStage) ([]string, error) {
-	// getting the current environment first
-	env := os.Environ()
+// getStepEnv returns the environment variables for the step to load in cmd exec. The base is the environment the step
+// inherits, e.g. the current environment for the steps running on the host.
+func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.ActionStage, base []string) ([]string, error) {
+	// getting the base environment first
+	env := base
 
 	// adding the environment variables of the workflow and job to the environment. for duplicate keys, the last one wins
 	for k, v := range state.Env {
@@ -38,7 +40,7 @@ func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.Actio
 		}
 
 		// get context for the github actions expressions
-		ac := state.GetActionsContext()
+		ac := state.GetStepActionsContext(ss)
 
 		// add inputs to the environment
 		for k, v := range ss.Step.With {
@@ -50,15 +52,17 @@ func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.Actio
 			// evaluate the expression
 			res, err := str.Eval(ac)
 			if err != nil {
-				return nil, fmt.Errorf("failed to evaluate default value for input %s: %v", k, err)
+				annotateExpressionError(state, ss, "with."+k, err)
+
+				return nil, fmt.Errorf("failed to evaluate input %s: %w", k, err)
 			}
 
-			env = append(env, fmt.Sprintf("INPUT_%s=%s", strings.ToUpper(k), res))
+			env = append(env, fmt.Sprintf("%s=%s", model.InputEnvName(k), res))
 		}
 
 		// add default values for inputs that are not defined in the step config
 		for k, v := range as.Metadata.Inputs {
-			if _, ok := ss.Step.With[k]; ok {
+			if _, ok := model.LookupInput(ss.Step.With, k); ok {
 				continue
 			}
 
@@ -73,15 +77,42 @@ func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.Actio
 			// evaluate the expression
 			res, err := str.Eval(ac)
 			if err != nil {
-				return nil, fmt.Errorf("failed to evaluate default value for input %s: %v", k, err)
+				annotateExpressionError(state, ss, "", err)
+
+				return nil, fmt.Errorf("failed to evaluate default value for input %s: %w", k, err)
 			}
 
-			env = append(env, fmt.Sprintf("INPUT_%s=%s", strings.ToUpper(k), res))
+			env = append(env, fmt.Sprintf("%s=%s", model.InputEnvName(k), res))
 		}
 	}
 
 	for k, v := range ss.Step.Environment {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+		res, err := actions.NewString(v).Eval(state.GetStepActionsContext(ss))
+		if err != nil {
+			annotateExpressionError(state, ss, "env."+k, err)
+
+			return nil, fmt.Errorf("failed to evaluate env %s: %w", k, err)
+		}
+
+		env = append(env, fmt.Sprintf("%s=%s", k, res))
+	}
+
+	// runs.env of the action is added last, so the action environment overrides the step environment like the runner
+	if ss.Step.Type() == model.StepTypeAction {
+		as, _ := state.GetActionState(ss.Step.Uses)
+
+		ac := state.GetStepActionsContext(ss)
+
+		for k, v := range as.Metadata.Runs.Env {
+			res, err := actions.NewString(v).Eval(ac)
+			if err != nil {
+				annotateExpressionError(state, ss, "", err)
+
+				return nil, fmt.Errorf("failed to evaluate env %s of action %s: %w", k, ss.Step.Uses, err)
+			}
+
+			env = append(env, fmt.Sprintf("%s=%s", k, res))
+		}
 	}
 
 	// create directory and files for file commands and add them to the environment as well
@@ -113,6 +144,38 @@ func getStepEnv(state *statepkg.State, ss *statepkg.StepState, stage model.Actio
 	return env, nil
 }
 
+// annotateExpressionError adds an error annotation to the state for the expression error of the step. The key is the
+// key of the value in the step, e.g. `if` or `with.token`, used to set the position of the error in the workflow
+// file. Empty key means the value isn't from the workflow file. Other errors are ignored.
+func annotateExpressionError(state *statepkg.State, ss *statepkg.StepState, key string, err error) {
+	var exprErr *expression.Error
+
+	if !errors.As(err, &exprErr) {
+		return
+	}
+
+	if pos := ss.Step.Position(key); key != "" && exprErr.Line == 0 && pos.Line > 0 {
+		pos = pos.At(exprErr.Input, exprErr.Offset)
+
+		exprErr.Line = pos.Line
+		exprErr.Col = pos.Col
+	}
+
+	if exprErr.File == "" && key != "" && exprErr.Line > 0 {
+		exprErr.File = state.WorkflowPath
+	}
+
+	state.AddAnnotation(&model.Annotation{
+		Level:   model.AnnotationLevelError,
+		File:    exprErr.File,
+		Line:    exprErr.Line,
+		Col:     exprErr.Col,
+		Title:   "Expression error",
+		Message: exprErr.Message(),
+		StepID:  ss.Step.ID,
+	})
+}
+
 // appendFileCommandPathToEnv creates a file and appends the path to the environment
 func appendFileCommandPathToEnv(env []string, key string, path string) ([]string, error) {
 	// ensure the file exists
@@ -205,44 +268,6 @@ func valuesFromFile(filePath string) (map[string]string, error) {
 	return keyValues, nil
 }
 
-func processGithubWorkflowCommands(cmd *model.Command, ss *statepkg.StepState, logger *log.Logger) error {
-	switch cmd.Name {
-	case "group":
-		logger.Info(cmd.Value)
-		logger.StartGroup()
-	case "endgroup":
-		logger.EndGroup()
-	case "debug":
-		logger.Debug(cmd.Value)
-	case "error":
-		logger.Errorf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
-	case "warning":
-		logger.Warnf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
-	case "notice":
-		logger.Noticef(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
-	case "set-env":
-		if err := os.Setenv(cmd.Parameters["name"], cmd.Value); err != nil {
-			return err
-		}
-	case "set-output":
-		ss.Result.Outputs[cmd.Parameters["name"]] = cmd.Value
-	case "save-state":
-		ss.State[cmd.Parameters["name"]] = cmd.Value
-	case "add-mask":
-		logger.Info(fmt.Sprintf("[add-mask] %s", cmd.Value))
-	case "add-matcher":
-		logger.Info(fmt.Sprintf("[add-matcher] %s", cmd.Value))
-	case "add-path":
-		path := os.Getenv("PATH")
-		path = fmt.Sprintf("%s:%s", path, cmd.Value)
-		if err := os.Setenv("PATH", path); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func processFileCommands(ss *statepkg.StepState, stage model.ActionStage) error {
 	dir := config.GetPath("steps", ss.Step.ID, string(stage), "file_commands")
 
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aweris/ghx/pkg/actions"
+)
+
+// evalJobCondition evaluates the if condition of the job. Jobs without a condition always run.
+func (r *runner) evalJobCondition() (bool, error) {
+	job := r.state.Job
+
+	if job == nil || strings.TrimSpace(job.If) == "" {
+		return true, nil
+	}
+
+	ok, err := evalCondition(r.state.GetActionsContext(), job.If)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate if condition of job %s: %w", r.state.JobName, err)
+	}
+
+	return ok, nil
+}
+
+// checkJobFeatures reports the job features ghx doesn't support. Features changing how the steps are executed fail the
+// job setup instead of being silently dropped. Features without an effect on the steps are reported as warnings.
+func (r *runner) checkJobFeatures() error {
+	job := r.state.Job
+	if job == nil {
+		return nil
+	}
+
+	if job.Strategy != nil && job.Strategy.Matrix != nil {
+		return fmt.Errorf("job %s: matrix strategy is not supported", r.state.JobName)
+	}
+
+	if job.Defaults != nil && job.Defaults.Run != nil && job.Defaults.Run.Shell != "" && job.Defaults.Run.Shell != "bash" {
+		return fmt.Errorf("job %s: shell %s is not supported in defaults.run, only bash is supported", r.state.JobName, job.Defaults.Run.Shell)
+	}
+
+	if len(job.Needs) > 0 {
+		r.logger.Warn(fmt.Sprintf("Job depends on %s, needs context is empty since ghx runs a single job", strings.Join(job.Needs, ", ")))
+	}
+
+	if job.RunsOn != nil {
+		runsOn := strings.Join(job.RunsOn.Labels, ", ")
+		if job.RunsOn.Expression != "" {
+			runsOn = job.RunsOn.Expression
+		}
+
+		r.logger.Debug(fmt.Sprintf("Job runs on %s, ignored since ghx runs the job on the current host", runsOn))
+	}
+
+	if job.Permissions != nil {
+		r.logger.Warn("Job permissions are not enforced, GITHUB_TOKEN is used as it is")
+	}
+
+	if job.DeploymentEnvironment != nil {
+		r.logger.Warn(fmt.Sprintf("Environment %s is not applied, protection rules and environment secrets are ignored", job.DeploymentEnvironment.Name))
+	}
+
+	if job.Concurrency != nil {
+		r.logger.Debug(fmt.Sprintf("Concurrency group %s is ignored", job.Concurrency.Group))
+	}
+
+	return nil
+}
+
+// runScript returns the content of the script for the run step. The working directory is changed to the given working
+// directory or to the default working directory of the job if it's configured and jobDefaults is true.
+func (r *runner) runScript(run, workingDirectory string, jobDefaults bool) []byte {
+	var sb strings.Builder
+
+	sb.WriteString("#!/bin/bash\n")
+
+	if job := r.state.Job; workingDirectory == "" && jobDefaults && job != nil && job.Defaults != nil && job.Defaults.Run != nil {
+		workingDirectory = job.Defaults.Run.WorkingDirectory
+	}
+
+	if workingDirectory != "" {
+		sb.WriteString(fmt.Sprintf("cd '%s'\n", strings.ReplaceAll(workingDirectory, "'", `'\''`)))
+	}
+
+	sb.WriteString(run)
+
+	return []byte(sb.String())
+}
+
+// withJobTimeout returns a context cancelled after timeout-minutes of the job.
+func (r *runner) withJobTimeout(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	job := r.state.Job
+
+	if job == nil || strings.TrimSpace(job.TimeoutMinutes) == "" {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+
+	value, err := actions.NewString(job.TimeoutMinutes).Eval(r.state.GetActionsContext())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to evaluate timeout-minutes of job %s: %w", r.state.JobName, err)
+	}
+
+	minutes, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || minutes <= 0 {
+		return nil, nil, fmt.Errorf("invalid timeout-minutes %q for job %s", value, r.state.JobName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(minutes*float64(time.Minute)))
+
+	return ctx, cancel, nil
+}
+
+// completeJob evaluates the outputs of the job and applies continue-on-error to the result of the job. Outputs are
+// stored in the state as job outputs, ghx runs a single job so there are no dependent jobs to pass them to.
+func (r *runner) completeJob(jobErr error) error {
+	job := r.state.Job
+	if job == nil {
+		return jobErr
+	}
+
+	ac := r.state.GetActionsContext()
+
+	r.state.JobOutputs = make(map[string]string)
+
+	names := make([]string, 0, len(job.Outputs))
+
+	for name := range job.Outputs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := actions.NewString(job.Outputs[name]).Eval(ac)
+		if err != nil {
+			r.logger.Warn(fmt.Sprintf("Failed to evaluate job output %s: %v", name, err))
+			continue
+		}
+
+		r.state.JobOutputs[name] = value
+
+		r.logger.Info(fmt.Sprintf("Job output %s=%s", name, value))
+	}
+
+	if jobErr == nil || strings.TrimSpace(job.ContinueOnError) == "" {
+		return jobErr
+	}
+
+	value, err := actions.NewString(job.ContinueOnError).Eval(ac)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate continue-on-error of job %s: %w", r.state.JobName, err)
+	}
+
+	if convertToBoolean(value) {
+		r.logger.Warn(fmt.Sprintf("Job failed but continue-on-error is set, ignoring the failure: %v", jobErr))
+		return nil
+	}
+
+	return jobErr
+}
+
+// startServices starts the service containers of the job and adds them to the job context.
+func (r *runner) startServices(ctx context.Context) error {
+	job := r.state.Job
+	if job == nil || len(job.Services) == 0 {
+		return nil
+	}
+
+	services, err := r.services.Start(ctx, job.Services, r.state.GetActionsContext())
+	if err != nil {
+		return fmt.Errorf("job %s: %v", r.state.JobName, err)
+	}
+
+	r.state.Services = services
+
+	return nil
+}
+
+// startJobContainer creates the job container to run the steps in and adds it to the job context.
+func (r *runner) startJobContainer(ctx context.Context) error {
+	job := r.state.Job
+	if job == nil || job.Container == nil {
+		return nil
+	}
+
+	r.logger.Info(fmt.Sprintf("Starting job container %s", job.Container.Image))
+
+	container, err := newJobContainer(ctx, r.client, r.logger, job.Container, r.state.GetActionsContext(), r.services.Containers())
+	if err != nil {
+		return fmt.Errorf("job %s: %v", r.state.JobName, err)
+	}
+
+	id, err := container.ID(ctx)
+	if err != nil {
+		return fmt.Errorf("job %s: failed to start job container: %v", r.state.JobName, err)
+	}
+
+	r.container = container
+	r.state.Container = actions.JobContainer{ID: id, Network: serviceNetwork}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/config"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// envForceNode20 forces javascript actions to run on node20 regardless of the runtime declared in runs.using.
+const envForceNode20 = "FORCE_JAVASCRIPT_ACTIONS_TO_NODE20"
+
+// nodeImages is the map of node runtimes to the images used to fetch the node binary when the runtime is missing.
+var nodeImages = map[model.ActionRunsUsing]string{
+	model.ActionRunsUsingNode12: "node:12-buster-slim",
+	model.ActionRunsUsingNode16: "node:16-bullseye-slim",
+	model.ActionRunsUsingNode20: "node:20-bookworm-slim",
+}
+
+// nodeResolver resolves node binaries for javascript actions based on runs.using value of the action.
+//
+// Runtimes are looked up from the externals directory first using the same layout as the runner,
+// `<externals>/<runtime>/bin/node`, then node in PATH is used if its major version matches the runtime. If the runtime
+// is missing, the binary is fetched from the official node image with dagger and cached under ghx data home. Binaries
+// in the images are linked against glibc and libstdc++ of the image, so fetching only works on glibc based linux hosts
+// and binaries running on the host are checked with `node --version` before they're used.
+//
+// Runtime directories resolved with ResolveDir never come from PATH, since they're mounted into job containers and node
+// in PATH lives in a host prefix like /usr/local along with the rest of the host toolchain.
+type nodeResolver struct {
+	client       *dagger.Client
+	logger       *log.Logger
+	externalsDir string                           // externalsDir is the directory containing node runtimes.
+	forceNode20  bool                             // forceNode20 forces all javascript actions to run on node20.
+	cacheDir     string                           // cacheDir is the directory to cache the fetched runtimes.
+	resolved     map[model.ActionRunsUsing]string // resolved is the map of runtime to resolved node binary path.
+	resolvedDirs map[model.ActionRunsUsing]string // resolvedDirs is the map of runtime to resolved runtime directory.
+
+	// fetch exports the node binary of the image to the target path and checkPlatform checks if the binaries of the
+	// images run on the host. They're replaced in tests to run without dagger.
+	fetch         func(ctx context.Context, image, target string) error
+	checkPlatform func() error
+}
+
+// newNodeResolver creates a new node resolver. FORCE_JAVASCRIPT_ACTIONS_TO_NODE20 is looked up in the runner and job
+// environment, the job environment wins.
+func newNodeResolver(client *dagger.Client, logger *log.Logger, externalsDir string, env map[string]string) *nodeResolver {
+	force := os.Getenv(envForceNode20)
+
+	if v, ok := env[envForceNode20]; ok {
+		force = v
+	}
+
+	n := &nodeResolver{
+		client:        client,
+		logger:        logger,
+		externalsDir:  externalsDir,
+		forceNode20:   convertToBoolean(force),
+		cacheDir:      config.GetPath("externals"),
+		resolved:      make(map[model.ActionRunsUsing]string),
+		resolvedDirs:  make(map[model.ActionRunsUsing]string),
+		checkPlatform: checkNodeImagePlatform,
+	}
+
+	if client != nil {
+		n.fetch = n.fetchImage
+	}
+
+	return n
+}
+
+// runtime returns the node runtime to use for the given runs.using value after applying forced upgrades.
+func (n *nodeResolver) runtime(using model.ActionRunsUsing) model.ActionRunsUsing {
+	if using != model.ActionRunsUsingNode20 && n.forceNode20 {
+		return model.ActionRunsUsingNode20
+	}
+
+	return using
+}
+
+// Resolve returns the path of the node binary for the given runs.using value.
+func (n *nodeResolver) Resolve(ctx context.Context, using model.ActionRunsUsing) (string, error) {
+	return n.resolve(ctx, using, n.resolved, true)
+}
+
+// ResolveDir returns the runtime directory for the given runs.using value in `<dir>/bin/node` layout. The base name
+// of the directory is the runtime, e.g. node20.
+func (n *nodeResolver) ResolveDir(ctx context.Context, using model.ActionRunsUsing) (string, error) {
+	path, err := n.resolve(ctx, using, n.resolvedDirs, false)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(filepath.Dir(path)), nil
+}
+
+// resolve returns the path of the node binary for the given runs.using value and caches it in the given map. Host is
+// true if node runs on the host instead of a job container.
+func (n *nodeResolver) resolve(ctx context.Context, using model.ActionRunsUsing, resolved map[model.ActionRunsUsing]string, host bool) (string, error) {
+	if !using.IsNode() {
+		return "", fmt.Errorf("%s is not a node runtime", using)
+	}
+
+	runtime := n.runtime(using)
+
+	if path, ok := resolved[runtime]; ok {
+		return path, nil
+	}
+
+	if runtime != using {
+		n.logger.Warn(fmt.Sprintf("%s is set, %s actions will run on %s", envForceNode20, using, runtime))
+	}
+
+	path, err := n.lookup(ctx, runtime, host)
+	if err != nil {
+		return "", err
+	}
+
+	resolved[runtime] = path
+
+	return path, nil
+}
+
+// lookup looks up the runtime from externals directory, PATH and cache directory and fetches it if missing. PATH and
+// the host platform check are skipped unless node runs on the host.
+func (n *nodeResolver) lookup(ctx context.Context, runtime model.ActionRunsUsing, host bool) (string, error) {
+	if n.externalsDir != "" {
+		path := filepath.Join(n.externalsDir, string(runtime), "bin", "node")
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if host {
+		if path, ok := hostNode(runtime); ok {
+			n.logger.Debug(fmt.Sprintf("Use %s runtime from '%s'", runtime, path))
+
+			return path, nil
+		}
+	}
+
+	cached := filepath.Join(n.cacheDir, string(runtime), "bin", "node")
+
+	if _, err := os.Stat(cached); err == nil {
+		if host {
+			if err := verifyNode(cached); err != nil {
+				return "", n.missingRuntimeErr(runtime, fmt.Errorf("cached binary %s doesn't run on the host: %w", cached, err))
+			}
+		}
+
+		return cached, nil
+	}
+
+	image, ok := nodeImages[runtime]
+	if !ok || n.fetch == nil {
+		return "", n.missingRuntimeErr(runtime, nil)
+	}
+
+	if host {
+		if err := n.checkPlatform(); err != nil {
+			return "", n.missingRuntimeErr(runtime, err)
+		}
+	}
+
+	n.logger.Info(fmt.Sprintf("Download %s runtime from '%s'", runtime, image))
+
+	// fetch next to the cached path first, so a failed or broken fetch never leaves a binary in the cache
+	tmp := cached + ".tmp"
+
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return "", err
+	}
+
+	if err := n.fetch(ctx, image, tmp); err != nil {
+		os.Remove(tmp)
+
+		return "", n.missingRuntimeErr(runtime, err)
+	}
+
+	if err := os.Chmod(tmp, 0755); err != nil {
+		os.Remove(tmp)
+
+		return "", err
+	}
+
+	// the binary is linked against the libraries of the image, fail now instead of when the action runs
+	if host {
+		if err := verifyNode(tmp); err != nil {
+			os.Remove(tmp)
+
+			return "", n.missingRuntimeErr(runtime, fmt.Errorf("binary from %s doesn't run on the host: %w", image, err))
+		}
+	}
+
+	if err := os.Rename(tmp, cached); err != nil {
+		return "", err
+	}
+
+	return cached, nil
+}
+
+// fetchImage exports the node binary of the image to the target path. The image is pulled for the host platform
+// instead of the engine platform, since the binary runs on the host or in a job container on the same platform.
+func (n *nodeResolver) fetchImage(ctx context.Context, image, target string) error {
+	opts := dagger.ContainerOpts{Platform: dagger.Platform(fmt.Sprintf("linux/%s", goruntime.GOARCH))}
+
+	_, err := n.client.Container(opts).From(image).File("/usr/local/bin/node").Export(ctx, target)
+
+	return err
+}
+
+// verifyNode returns an error if the node binary can't run on the host, e.g. missing shared libraries.
+func verifyNode(path string) error {
+	out, err := exec.Command(path, "--version").CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// hostNode returns the path of node in PATH if its major version matches the runtime, e.g. v20.11.0 for node20.
+func hostNode(runtime model.ActionRunsUsing) (string, bool) {
+	path, err := exec.LookPath("node")
+	if err != nil {
+		return "", false
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return "", false
+	}
+
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(string(out)), "v"), ".")
+
+	if major != strings.TrimPrefix(string(runtime), "node") {
+		return "", false
+	}
+
+	return path, true
+}
+
+// glibcLoaders are the glob patterns of the glibc dynamic loader paths, e.g. /lib64/ld-linux-x86-64.so.2 on amd64
+// and /lib/ld-linux-aarch64.so.1 on arm64.
+var glibcLoaders = []string{"/lib64/ld-linux-*.so.*", "/lib/ld-linux-*.so.*", "/lib/ld-linux.so.*"}
+
+// checkNodeImagePlatform returns an error if node binaries from the official images can't run on the host. Images
+// only have linux binaries linked against glibc, so they don't run on other operating systems, musl based
+// distributions like Alpine or images without libc like distroless/static.
+func checkNodeImagePlatform() error {
+	if goruntime.GOOS != "linux" {
+		return fmt.Errorf("node binaries from the images don't run on %s, install the runtime or use --externals-dir", goruntime.GOOS)
+	}
+
+	for _, pattern := range glibcLoaders {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return nil
+		}
+	}
+
+	if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+		return fmt.Errorf("node binaries from the images require glibc but the host uses musl, install the runtime or use --externals-dir")
+	}
+
+	return fmt.Errorf("node binaries from the images require glibc but the host has no glibc loader, install the runtime or use --externals-dir")
+}
+
+// missingRuntimeErr returns a descriptive error for a runtime that couldn't be resolved.
+func (n *nodeResolver) missingRuntimeErr(runtime model.ActionRunsUsing, cause error) error {
+	msg := fmt.Sprintf("node runtime %s not found", runtime)
+
+	if n.externalsDir != "" {
+		msg = fmt.Sprintf("%s in %s", msg, filepath.Join(n.externalsDir, string(runtime), "bin", "node"))
+	}
+
+	if cause != nil {
+		msg = fmt.Sprintf("%s and failed to fetch it: %v", msg, cause)
+	}
+
+	if runtime != model.ActionRunsUsingNode20 {
+		msg = fmt.Sprintf("%s. Set %s=true to run the action on node20 instead", msg, envForceNode20)
+	}
+
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,239 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+// writeNode writes a fake node binary printing the given version to the path.
+func writeNode(t *testing.T, path, version string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho "+version+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestNodeResolver returns a resolver with a fake externals and cache directory and without node in PATH. Fetched
+// binaries print the version of the image tag, e.g. v20 for node:20-bookworm-slim.
+func newTestNodeResolver(t *testing.T, env map[string]string) (*nodeResolver, *[]string) {
+	t.Helper()
+
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv(envForceNode20, "")
+
+	n := newNodeResolver(nil, log.NewLogger(), t.TempDir(), env)
+	n.cacheDir = t.TempDir()
+	n.checkPlatform = func() error { return nil }
+
+	var fetched []string
+
+	n.fetch = func(_ context.Context, image, target string) error {
+		fetched = append(fetched, image)
+
+		version, _, _ := strings.Cut(strings.TrimPrefix(image, "node:"), "-")
+
+		writeNode(t, target, "v"+version)
+
+		return nil
+	}
+
+	return n, &fetched
+}
+
+func TestNodeResolver_Order(t *testing.T) {
+	n, fetched := newTestNodeResolver(t, nil)
+
+	externalNode := filepath.Join(n.externalsDir, "node16", "bin", "node")
+	writeNode(t, externalNode, "v16.20.0")
+
+	bin := t.TempDir()
+	writeNode(t, filepath.Join(bin, "node"), "v20.11.0")
+	t.Setenv("PATH", bin)
+
+	cachedNode := filepath.Join(n.cacheDir, "node12", "bin", "node")
+	writeNode(t, cachedNode, "v12.22.0")
+
+	tests := []struct {
+		using    model.ActionRunsUsing
+		expected string
+	}{
+		{using: model.ActionRunsUsingNode16, expected: externalNode},
+		{using: model.ActionRunsUsingNode20, expected: filepath.Join(bin, "node")},
+		{using: model.ActionRunsUsingNode12, expected: cachedNode},
+	}
+
+	for _, tt := range tests {
+		path, err := n.Resolve(context.Background(), tt.using)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, but got %s", tt.using, err.Error())
+		}
+
+		if path != tt.expected {
+			t.Errorf("Expected %s to resolve to %s, but got %s", tt.using, tt.expected, path)
+		}
+	}
+
+	if len(*fetched) != 0 {
+		t.Errorf("Expected nothing to be fetched, but got %v", *fetched)
+	}
+}
+
+func TestNodeResolver_HostNodeVersionMismatch(t *testing.T) {
+	n, fetched := newTestNodeResolver(t, nil)
+
+	bin := t.TempDir()
+	writeNode(t, filepath.Join(bin, "node"), "v18.19.0")
+	t.Setenv("PATH", bin)
+
+	path, err := n.Resolve(context.Background(), model.ActionRunsUsingNode20)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if expected := filepath.Join(n.cacheDir, "node20", "bin", "node"); path != expected {
+		t.Errorf("Expected fetched node %s, but got %s", expected, path)
+	}
+
+	if len(*fetched) != 1 || (*fetched)[0] != "node:20-bookworm-slim" {
+		t.Errorf("Expected node20 image to be fetched once, but got %v", *fetched)
+	}
+
+	// resolved runtimes are reused
+	if _, err := n.Resolve(context.Background(), model.ActionRunsUsingNode20); err != nil || len(*fetched) != 1 {
+		t.Errorf("Expected resolved runtime to be reused, but got %v and %v", err, *fetched)
+	}
+}
+
+func TestNodeResolver_FetchNotRunning(t *testing.T) {
+	n, _ := newTestNodeResolver(t, nil)
+
+	// binary linked against missing libraries fails to run
+	n.fetch = func(_ context.Context, _, target string) error {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, []byte("#!/bin/sh\necho 'error while loading shared libraries: libstdc++.so.6' >&2\nexit 127\n"), 0755)
+	}
+
+	_, err := n.Resolve(context.Background(), model.ActionRunsUsingNode20)
+	if err == nil {
+		t.Fatalf("Expected error for node binary not running on the host, but got nil")
+	}
+
+	if !strings.Contains(err.Error(), "doesn't run on the host") || !strings.Contains(err.Error(), "libstdc++.so.6") {
+		t.Errorf("Expected error explaining the binary doesn't run, but got %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(n.cacheDir, "node20", "bin", "node")); !os.IsNotExist(err) {
+		t.Errorf("Expected broken binary not to be cached, but got %v", err)
+	}
+}
+
+func TestNodeResolver_MissingRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		using    model.ActionRunsUsing
+		fetch    bool
+		platform error
+		contains []string
+	}{
+		{
+			name:     "without dagger",
+			using:    model.ActionRunsUsingNode16,
+			contains: []string{"node runtime node16 not found in", "node16/bin/node", "Set FORCE_JAVASCRIPT_ACTIONS_TO_NODE20=true"},
+		},
+		{
+			name:     "unsupported platform",
+			using:    model.ActionRunsUsingNode20,
+			fetch:    true,
+			platform: errors.New("host uses musl"),
+			contains: []string{"node runtime node20 not found", "failed to fetch it: host uses musl"},
+		},
+		{
+			name:     "not a node runtime",
+			using:    model.ActionRunsUsingComposite,
+			fetch:    true,
+			contains: []string{"composite is not a node runtime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, fetched := newTestNodeResolver(t, nil)
+
+			if !tt.fetch {
+				n.fetch = nil
+			}
+
+			n.checkPlatform = func() error { return tt.platform }
+
+			_, err := n.Resolve(context.Background(), tt.using)
+			if err == nil {
+				t.Fatalf("Expected error, but got nil")
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("Expected error to contain %q, but got %s", s, err.Error())
+				}
+			}
+
+			if strings.Contains(err.Error(), envForceNode20) && tt.using == model.ActionRunsUsingNode20 {
+				t.Errorf("Expected no node20 hint for node20, but got %s", err.Error())
+			}
+
+			if len(*fetched) != 0 {
+				t.Errorf("Expected nothing to be fetched, but got %v", *fetched)
+			}
+		})
+	}
+}
+
+func TestNodeResolver_ForceNode20(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		env      map[string]string
+		expected string
+	}{
+		{name: "not set", expected: "node16"},
+		{name: "host", host: "true", expected: "node20"},
+		{name: "job", env: map[string]string{envForceNode20: "1"}, expected: "node20"},
+		{name: "job overrides host", host: "true", env: map[string]string{envForceNode20: "false"}, expected: "node16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envForceNode20, tt.host)
+
+			n := newNodeResolver(nil, log.NewLogger(), t.TempDir(), tt.env)
+			n.cacheDir = t.TempDir()
+
+			for _, runtime := range []string{"node16", "node20"} {
+				writeNode(t, filepath.Join(n.externalsDir, runtime, "bin", "node"), "v"+strings.TrimPrefix(runtime, "node"))
+			}
+
+			dir, err := n.ResolveDir(context.Background(), model.ActionRunsUsingNode16)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s", err.Error())
+			}
+
+			if filepath.Base(dir) != tt.expected {
+				t.Errorf("Expected %s runtime, but got %s", tt.expected, dir)
+			}
+		})
+	}
+}
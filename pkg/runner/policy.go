@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+// envAllowUnsecureCommands is the environment variable to opt in deprecated workflow commands.
+const envAllowUnsecureCommands = "ACTIONS_ALLOW_UNSECURE_COMMANDS"
+
+// unsecureCommands is the set of deprecated workflow commands disabled by default.
+//
+// See more: https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/
+var unsecureCommands = map[string]struct{}{
+	model.CommandSetEnv:  {},
+	model.CommandAddPath: {},
+}
+
+// commandPolicy decides whether a workflow command is allowed to be processed for the step.
+type commandPolicy struct {
+	allowUnsecureCommands bool // allowUnsecureCommands is true if deprecated commands are enabled for the step.
+}
+
+// newCommandPolicy creates a policy for the given step. ACTIONS_ALLOW_UNSECURE_COMMANDS is looked up in the runner
+// environment, job environment and step environment in order, the last one wins. The step environment is evaluated
+// the same way as the environment of the step execution, so expressions and values of composite steps are resolved.
+// Values failing to evaluate don't opt in, the step already fails for them while preparing its environment.
+func newCommandPolicy(state *statepkg.State, ss *statepkg.StepState) *commandPolicy {
+	value := os.Getenv(envAllowUnsecureCommands)
+
+	if v, ok := state.Env[envAllowUnsecureCommands]; ok {
+		value = v
+	}
+
+	if v, ok := ss.Step.Environment[envAllowUnsecureCommands]; ok {
+		res, err := actions.NewString(v).Eval(state.GetStepActionsContext(ss))
+		if err != nil {
+			res = ""
+		}
+
+		value = res
+	}
+
+	return &commandPolicy{allowUnsecureCommands: convertToBoolean(value)}
+}
+
+// check returns an error if the command is not allowed by the policy. The error message is the same as the runner.
+func (p *commandPolicy) check(cmd *model.Command) error {
+	if _, ok := unsecureCommands[cmd.Name]; !ok || p.allowUnsecureCommands {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"The `%s` command is disabled. Please upgrade to using Environment Files or opt into unsecure command execution by setting the `%s` environment variable to `true`. For more information see: https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/", //nolint:revive,stylecheck // keep the message same as the runner
+		cmd.Name, envAllowUnsecureCommands,
+	)
+}
+
+// convertToBoolean converts the given string to boolean the same way runner does. Only `1`, `true` and `$true` are
+// considered as true, case-insensitive.
+func convertToBoolean(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "$true":
+		return true
+	default:
+		return false
+	}
+}
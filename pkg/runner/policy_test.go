@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+const disabledSetEnvMessage = "The `set-env` command is disabled. Please upgrade to using Environment Files or opt into unsecure command execution by setting the `ACTIONS_ALLOW_UNSECURE_COMMANDS` environment variable to `true`. For more information see: https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/"
+
+func TestNewCommandPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		job      map[string]string
+		step     map[string]string
+		context  *actions.Context
+		expected bool
+	}{
+		{name: "not set", expected: false},
+		{name: "host", host: "true", expected: true},
+		{name: "job", job: map[string]string{envAllowUnsecureCommands: "true"}, expected: true},
+		{name: "step", step: map[string]string{envAllowUnsecureCommands: "true"}, expected: true},
+		{name: "job overrides host", host: "true", job: map[string]string{envAllowUnsecureCommands: "false"}, expected: false},
+		{name: "step overrides job", job: map[string]string{envAllowUnsecureCommands: "true"}, step: map[string]string{envAllowUnsecureCommands: "false"}, expected: false},
+		{name: "step overrides host", host: "false", step: map[string]string{envAllowUnsecureCommands: "1"}, expected: true},
+		{name: "one", step: map[string]string{envAllowUnsecureCommands: "1"}, expected: true},
+		{name: "powershell true", step: map[string]string{envAllowUnsecureCommands: "$TRUE"}, expected: true},
+		{name: "upper case true", step: map[string]string{envAllowUnsecureCommands: " TRUE "}, expected: true},
+		{name: "yes is not true", step: map[string]string{envAllowUnsecureCommands: "yes"}, expected: false},
+		{name: "step expression", job: map[string]string{"ALLOW": "true"}, step: map[string]string{envAllowUnsecureCommands: "${{ env.ALLOW }}"}, expected: true},
+		{name: "invalid step expression", host: "true", step: map[string]string{envAllowUnsecureCommands: "${{ env. }}"}, expected: false},
+		{
+			name:     "composite step expression",
+			step:     map[string]string{envAllowUnsecureCommands: "${{ inputs.allow }}"},
+			context:  &actions.Context{Inputs: map[string]string{"allow": "true"}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.host != "" {
+				t.Setenv(envAllowUnsecureCommands, tt.host)
+			} else {
+				// unset the variable of the host running the tests, if any, restored after the test
+				t.Setenv(envAllowUnsecureCommands, "")
+				os.Unsetenv(envAllowUnsecureCommands)
+			}
+
+			ss := statepkg.NewStepState(&model.Step{ID: "build", Environment: tt.step})
+			ss.Context = tt.context
+
+			policy := newCommandPolicy(&statepkg.State{Env: tt.job}, ss)
+
+			if policy.allowUnsecureCommands != tt.expected {
+				t.Errorf("Expected unsecure commands allowed %v, but got %v", tt.expected, policy.allowUnsecureCommands)
+			}
+		})
+	}
+}
+
+func TestCommandPolicy_Check(t *testing.T) {
+	policy := &commandPolicy{}
+
+	for _, name := range []string{model.CommandSetOutput, model.CommandSaveState, model.CommandError} {
+		if err := policy.check(&model.Command{Name: name}); err != nil {
+			t.Errorf("Expected %s to be allowed, but got %v", name, err)
+		}
+	}
+
+	err := policy.check(&model.Command{Name: model.CommandSetEnv})
+	if err == nil || err.Error() != disabledSetEnvMessage {
+		t.Errorf("Expected error %q, but got %v", disabledSetEnvMessage, err)
+	}
+
+	if err := policy.check(&model.Command{Name: model.CommandAddPath}); err == nil {
+		t.Errorf("Expected add-path to be disabled, but got nil")
+	}
+
+	policy.allowUnsecureCommands = true
+
+	if err := policy.check(&model.Command{Name: model.CommandSetEnv}); err != nil {
+		t.Errorf("Expected set-env to be allowed after opt in, but got %v", err)
+	}
+}
+
+func TestWorkflowCommandProcessor_DisabledCommand(t *testing.T) {
+	t.Setenv(envAllowUnsecureCommands, "")
+	os.Unsetenv(envAllowUnsecureCommands)
+
+	state := &statepkg.State{}
+	ss := statepkg.NewStepState(&model.Step{ID: "build"})
+
+	p := newWorkflowCommandProcessor(state, ss, log.NewLogger())
+
+	captureStdout(t, func() {
+		if _, _, err := p.process("::set-env name=GHX_POLICY_TEST::injected"); err == nil {
+			t.Errorf("Expected set-env to be rejected, but got nil")
+		}
+	})
+
+	if _, ok := os.LookupEnv("GHX_POLICY_TEST"); ok {
+		t.Errorf("Expected rejected set-env to have no effect")
+	}
+
+	if p.Err() == nil || p.Err().Error() != disabledSetEnvMessage {
+		t.Errorf("Expected step error %q, but got %v", disabledSetEnvMessage, p.Err())
+	}
+
+	if len(state.Annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, but got %d", len(state.Annotations))
+	}
+
+	expected := model.Annotation{Level: model.AnnotationLevelError, Message: disabledSetEnvMessage, StepID: "build"}
+
+	if a := state.Annotations[0]; *a != expected {
+		t.Errorf("Expected annotation %+v, but got %+v", expected, *a)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"dagger.io/dagger"
 
 	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/actions"
 	"github.com/aweris/ghx/pkg/config"
 	"github.com/aweris/ghx/pkg/model"
 	statepkg "github.com/aweris/ghx/pkg/state"
@@ -34,31 +37,85 @@ type Runner interface {
 
 var _ Runner = new(runner)
 
+// Options represents the configuration of the runner
+type Options struct {
+	// ExternalsDir is the directory containing external tools like node runtimes. It uses the same layout as the
+	// runner, e.g. `<externals>/node20/bin/node`.
+	ExternalsDir string
+
+	// Actions is the options to resolve and verify remote actions.
+	Actions statepkg.ActionOptions
+}
+
 type runner struct {
-	client *dagger.Client
-	state  *statepkg.State
-	logger *log.Logger
+	client    *dagger.Client
+	state     *statepkg.State
+	logger    *log.Logger
+	node      *nodeResolver
+	services  *serviceManager
+	container *jobContainer // container is the job container, nil if the steps run on the host
+	opts      Options
 }
 
 // New creates a new runner
-func New(client *dagger.Client, state *statepkg.State) (Runner, error) {
-	return &runner{client: client, state: state, logger: log.NewLogger()}, nil
+func New(client *dagger.Client, state *statepkg.State, opts Options) (Runner, error) {
+	logger := log.NewLogger()
+
+	return &runner{
+		client:   client,
+		state:    state,
+		logger:   logger,
+		node:     newNodeResolver(client, logger, opts.ExternalsDir, state.Env),
+		services: newServiceManager(client, logger),
+		opts:     opts,
+	}, nil
 }
 
 // Execute executes the steps configured previously with WithStep()
 func (r *runner) Execute(ctx context.Context) error {
+	// the job is successful until a step fails. status functions in conditions depend on it.
+	r.state.JobStatus = statepkg.JobStatusSuccess
+
+	// the state is saved between invocations, annotations and outputs of the previous runs shouldn't be exported again
+	r.state.Annotations = nil
+	r.state.JobOutputs = nil
+
+	ok, err := r.evalJobCondition()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		r.logger.Info(fmt.Sprintf("Skip job %s, if condition evaluated to false", r.state.JobName))
+		return nil
+	}
+
+	// services might be started even if the job setup fails later
+	defer r.services.Stop()
+
 	if err := r.setupJob(ctx); err != nil {
 		return err
 	}
 
-	// TODO: check conditionals like continue-on-error, skip etc...
+	ctx, cancel, err := r.withJobTimeout(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return r.completeJob(r.runSteps(ctx))
+}
 
+// runSteps runs pre, main and post stages of the steps and returns the failures of the job.
+func (r *runner) runSteps(ctx context.Context) error {
 	// ids of the steps to run with execution order
 	ids := r.state.GetStepOrder()
 
+	// errs keeps the failures of the job to report them after post stages are completed
+	var errs []error
+
 	// Run stages
 	for _, stepID := range ids {
-		// TODO: also add conditional check here with expression evaluation
 		ss, _ := r.state.GetStepState(stepID)
 
 		// skip run steps since they don't have pre runs
@@ -72,28 +129,96 @@ func (r *runner) Execute(ctx context.Context) error {
 			continue
 		}
 
-		result, _ := r.execStep(ctx, ss, model.ActionStagePre)
+		stepCtx := r.stepContext(ctx)
+
+		ok, err := r.evalStageCondition(ss, as.Metadata.Runs.PreIf)
+		if err != nil {
+			r.failJob()
+			errs = append(errs, fmt.Errorf("step %s failed to evaluate pre-if: %w", stepID, err))
+
+			break
+		}
+
+		if !ok {
+			r.logger.Debug(fmt.Sprintf("Skip pre stage of step %s, pre-if evaluated to false", stepID))
+			continue
+		}
+
+		result, _ := r.execStep(stepCtx, ss, model.ActionStagePre)
 		if result == StatusFailed {
-			return fmt.Errorf("step %s failed at pre stage", stepID)
+			r.failJob()
+			errs = append(errs, fmt.Errorf("step %s failed at pre stage", stepID))
+
+			break
 		}
 	}
 
+	// started keeps the steps started main stage. Post stages only run for them, same as runner.
+	started := make(map[string]bool)
+
 	for _, stepID := range ids {
-		// TODO: also add conditional check here with expression evaluation
 		ss, _ := r.state.GetStepState(stepID)
 
-		result, _ := r.execStep(ctx, ss, model.ActionStageMain)
+		// job status is cancelled before evaluating the condition, so only steps checking the status run afterwards
+		stepCtx := r.stepContext(ctx)
+
+		// steps run only if the previous steps succeeded unless the condition checks the status, e.g. always()
+		ok, err := evalCondition(r.state.GetActionsContext(), ss.Step.If)
+		if err != nil {
+			annotateExpressionError(r.state, ss, "if", err)
+
+			r.failJob()
+			errs = append(errs, fmt.Errorf("step %s failed to evaluate if: %w", stepID, err))
+
+			ss.Result.Conclusion = model.StepStatusFailure
+			ss.Result.Outcome = model.StepStatusFailure
+
+			continue
+		}
+
+		if !ok {
+			r.logger.Debug(fmt.Sprintf("Skip step %s, if evaluated to false", stepID))
+
+			ss.Result.Conclusion = model.StepStatusSkipped
+			ss.Result.Outcome = model.StepStatusSkipped
+
+			continue
+		}
+
+		started[stepID] = true
+
+		// scripts are written right before running the step, so expressions in the script see the previous steps
+		if ss.Step.Type() == model.StepTypeRun {
+			if err := r.writeRunScript(ss); err != nil {
+				ss.Result.Conclusion = model.StepStatusFailure
+				ss.Result.Outcome = model.StepStatusFailure
+
+				if err := r.continueOnError(ss, err); err != nil {
+					r.failJob()
+					errs = append(errs, fmt.Errorf("step %s: %w", stepID, err))
+				}
+
+				continue
+			}
+		}
+
+		result, err := r.execStep(stepCtx, ss, model.ActionStageMain)
 		if result == StatusFailed {
-			return fmt.Errorf("step %s failed at main stage", stepID)
+			if err := r.continueOnError(ss, err); err != nil {
+				r.failJob()
+				errs = append(errs, fmt.Errorf("step %s failed at main stage: %w", stepID, err))
+			}
 		}
 	}
 
-	for _, stepID := range ids {
-		// TODO: also add conditional check here with expression evaluation
+	// post stages run in reverse order, so cleanups are done in the opposite order of setups
+	for i := len(ids) - 1; i >= 0; i-- {
+		stepID := ids[i]
+
 		ss, _ := r.state.GetStepState(stepID)
 
 		// skip run steps since they don't have pre runs
-		if ss.Step.Type() == model.StepTypeRun {
+		if ss.Step.Type() == model.StepTypeRun || !started[stepID] {
 			continue
 		}
 
@@ -103,15 +228,106 @@ func (r *runner) Execute(ctx context.Context) error {
 			continue
 		}
 
-		result, _ := r.execStep(ctx, ss, model.ActionStagePost)
+		stepCtx := r.stepContext(ctx)
+
+		ok, err := r.evalStageCondition(ss, as.Metadata.Runs.PostIf)
+		if err != nil {
+			r.failJob()
+			errs = append(errs, fmt.Errorf("step %s failed to evaluate post-if: %w", stepID, err))
+
+			continue
+		}
+
+		if !ok {
+			r.logger.Debug(fmt.Sprintf("Skip post stage of step %s, post-if evaluated to false", stepID))
+			continue
+		}
+
+		result, _ := r.execStep(stepCtx, ss, model.ActionStagePost)
 		if result == StatusFailed {
-			return fmt.Errorf("step %s failed at post stage", stepID)
+			r.failJob()
+			errs = append(errs, fmt.Errorf("step %s failed at post stage", stepID))
 		}
 	}
 
+	// the last step might be cancelled as well
+	r.stepContext(ctx)
+
+	if r.state.JobStatus == statepkg.JobStatusCancelled {
+		errs = append(errs, fmt.Errorf("job %s is cancelled: %w", r.state.JobName, ctx.Err()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// continueOnError checks continue-on-error of the failed step. If it's set, the failure is ignored like the runner
+// does, the conclusion of the step becomes success while the outcome stays failure, and nil is returned. Otherwise, the
+// failure of the step is returned to fail the job.
+func (r *runner) continueOnError(ss *statepkg.StepState, failure error) error {
+	if failure == nil {
+		failure = errors.New("step failed")
+	}
+
+	ok, err := evalContinueOnError(r.state.GetStepActionsContext(ss), ss.Step.ContinueOnError)
+	if err != nil {
+		annotateExpressionError(r.state, ss, "continue-on-error", err)
+
+		return errors.Join(failure, fmt.Errorf("failed to evaluate continue-on-error: %w", err))
+	}
+
+	if !ok {
+		return failure
+	}
+
+	r.logger.Warn(fmt.Sprintf("Step %s failed but continue-on-error is set, ignoring the failure: %v", ss.Step.ID, failure))
+
+	ss.Result.Conclusion = model.StepStatusSuccess
+	ss.Result.Outcome = model.StepStatusFailure
+
 	return nil
 }
 
+// failJob marks the job as failed. Cancellation takes precedence over failures, so a cancelled job stays cancelled.
+func (r *runner) failJob() {
+	if r.state.JobStatus != statepkg.JobStatusCancelled {
+		r.state.JobStatus = statepkg.JobStatusFailure
+	}
+}
+
+// stepContext returns the context to run the next step with. Once the job context is cancelled, by the job timeout or
+// an interrupt, the job status becomes cancelled. Steps still running afterwards, e.g. with always(), run without the
+// job context, same as the runner running them after cancellation.
+func (r *runner) stepContext(ctx context.Context) context.Context {
+	if ctx.Err() == nil {
+		return ctx
+	}
+
+	if r.state.JobStatus != statepkg.JobStatusCancelled {
+		r.logger.Warn(fmt.Sprintf("Job %s is cancelled: %v", r.state.JobName, ctx.Err()))
+
+		r.state.JobStatus = statepkg.JobStatusCancelled
+	}
+
+	return context.Background()
+}
+
+// evalStageCondition evaluates pre-if or post-if condition of the action. Conditions default to always() and they're
+// evaluated against the job context, so status functions reflect the current job status.
+func (r *runner) evalStageCondition(ss *statepkg.StepState, condition string) (bool, error) {
+	if strings.TrimSpace(condition) == "" {
+		condition = "always()"
+	}
+
+	ok, err := evalCondition(r.state.GetActionsContext(), condition)
+	if err != nil {
+		return false, err
+	}
+
+	r.logger.Debug(fmt.Sprintf("Evaluate condition '%s' for step %s: %v", condition, ss.Step.ID, ok))
+
+	return ok, nil
+}
+
 // setupJob performs the `Set up job` step from the Github Actions workflow run to prepare the job environment
 func (r *runner) setupJob(ctx context.Context) error {
 	r.logger.Info("Set up job")
@@ -127,24 +343,60 @@ func (r *runner) setupJob(ctx context.Context) error {
 
 		switch ss.Step.Type() {
 		case model.StepTypeAction:
-			err := r.state.AddAction(ctx, r.client, ss.Step.Uses)
+			err := r.state.AddAction(ctx, r.client, ss.Step.Uses, r.opts.Actions)
 			if err != nil {
 				return err
 			}
 
-			r.logger.Info(fmt.Sprintf("Download action repository '%s'", ss.Step.Uses))
-		case model.StepTypeRun:
-			path := filepath.Join("scripts", ss.Step.ID, "run.sh")
-			content := []byte(fmt.Sprintf("#!/bin/bash\n%s", ss.Step.Run))
+			as, _ := r.state.GetActionState(ss.Step.Uses)
 
-			err := config.WriteFile(path, content, 0755)
-			if err != nil {
+			msg := fmt.Sprintf("Download action repository '%s'", ss.Step.Uses)
+
+			if as.Replacement != "" {
+				msg = fmt.Sprintf("%s replaced by '%s'", msg, as.Replacement)
+			}
+
+			if as.Commit != "" {
+				msg = fmt.Sprintf("%s (SHA:%s)", msg, as.Commit)
+			}
+
+			r.logger.Info(msg)
+
+			if err := r.validateInputs(ss, as); err != nil {
+				return err
+			}
+
+			if err := r.loadCompositeActions(ctx, as, 0); err != nil {
 				return err
 			}
 
-			// make it debug level because it's not really important and it's visible in Github Actions logs
-			r.logger.Debug(fmt.Sprintf("Write script to '%s' for step '%s'", path, ss.Step.ID))
+			// resolve node runtime of the javascript actions early to fail before running any step
+			if as.Metadata.Runs.Using.IsNode() {
+				if _, err := r.resolveNode(ctx, as.Metadata.Runs.Using); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if lockfile := r.opts.Actions.Lockfile; lockfile != nil && lockfile.Changed() {
+		if err := lockfile.Save(); err != nil {
+			return err
 		}
+
+		r.logger.Info(fmt.Sprintf("Update lockfile '%s'", lockfile.Path()))
+	}
+
+	if err := r.checkJobFeatures(); err != nil {
+		return err
+	}
+
+	if err := r.startServices(ctx); err != nil {
+		return err
+	}
+
+	if err := r.startJobContainer(ctx); err != nil {
+		return err
 	}
 
 	r.logger.Info(fmt.Sprintf("Complete job name: %s", r.state.JobName))
@@ -152,6 +404,24 @@ func (r *runner) setupJob(ctx context.Context) error {
 	return nil
 }
 
+// validateInputs validates the inputs of the step against the inputs declared by the action. Warnings are reported
+// as annotations and missing required inputs fail the job setup.
+func (r *runner) validateInputs(ss *statepkg.StepState, as *statepkg.ActionState) error {
+	warnings, err := as.Metadata.ValidateInputs(ss.Step.With)
+
+	for _, warning := range warnings {
+		r.logger.Warn(warning)
+
+		r.state.AddAnnotation(&model.Annotation{Level: model.AnnotationLevelWarning, Message: warning, StepID: ss.Step.ID})
+	}
+
+	if err != nil {
+		return fmt.Errorf("step %s: %w", ss.Step.ID, err)
+	}
+
+	return nil
+}
+
 func (r *runner) execStep(ctx context.Context, ss *statepkg.StepState, stage model.ActionStage) (ExecStepStatus, error) {
 	r.logger.Info(ss.Step.LogMessage(stage))
 	r.logger.StartGroup()
@@ -173,6 +443,21 @@ func (r *runner) execStepAction(ctx context.Context, ss *statepkg.StepState, sta
 		return StatusFailed, fmt.Errorf("action '%s' not found", ss.Step.Uses)
 	}
 
+	if as.Metadata.Runs.Using == model.ActionRunsUsingComposite {
+		result, err := r.execStepComposite(ctx, ss, as)
+		if err != nil {
+			ss.Result.Conclusion = model.StepStatusFailure
+			ss.Result.Outcome = model.StepStatusFailure
+
+			return result, err
+		}
+
+		ss.Result.Conclusion = model.StepStatusSuccess
+		ss.Result.Outcome = model.StepStatusSuccess
+
+		return result, nil
+	}
+
 	var runs string
 
 	switch stage {
@@ -186,7 +471,18 @@ func (r *runner) execStepAction(ctx context.Context, ss *statepkg.StepState, sta
 		return StatusFailed, fmt.Errorf("not supported stage %s", stage)
 	}
 
-	err := r.execCmd(ctx, ss, stage, []string{"node", fmt.Sprintf("%s/%s", as.Path, runs)})
+	if !as.Metadata.Runs.Using.IsNode() {
+		return StatusFailed, fmt.Errorf("not supported action type %s", as.Metadata.Runs.Using)
+	}
+
+	node, err := r.resolveNode(ctx, as.Metadata.Runs.Using)
+	if err != nil {
+		return StatusFailed, err
+	}
+
+	r.mountAction(as)
+
+	err = r.execCmd(ctx, ss, stage, []string{node, fmt.Sprintf("%s/%s", as.Path, runs)})
 	if err != nil {
 		ss.Result.Conclusion = model.StepStatusFailure
 		ss.Result.Outcome = model.StepStatusFailure
@@ -194,18 +490,101 @@ func (r *runner) execStepAction(ctx context.Context, ss *statepkg.StepState, sta
 		return StatusFailed, err
 	}
 
+	// outputs not declared in the metadata are still exposed like the runner does, but they're likely a mistake
+	for _, name := range as.Metadata.UndeclaredOutputs(ss.Result.Outputs) {
+		r.logger.Warn(fmt.Sprintf("Action '%s' sets output '%s' which is not declared in its metadata", ss.Step.Uses, name))
+	}
+
 	ss.Result.Conclusion = model.StepStatusSuccess
 	ss.Result.Outcome = model.StepStatusSuccess
 
 	return StatusSucceeded, nil
 }
 
+// resolveNode returns the path of the node binary to run javascript actions with. Steps of container jobs run node
+// from the runtime directory mounted at /__e/<runtime> in the job container, same as the runner, instead of node in
+// PATH of the host.
+func (r *runner) resolveNode(ctx context.Context, using model.ActionRunsUsing) (string, error) {
+	if r.state.Job == nil || r.state.Job.Container == nil {
+		return r.node.Resolve(ctx, using)
+	}
+
+	dir, err := r.node.ResolveDir(ctx, using)
+	if err != nil {
+		return "", err
+	}
+
+	// the job container is not created yet during the job setup
+	if r.container == nil {
+		return filepath.Join(dir, "bin", "node"), nil
+	}
+
+	path := "/__e/" + filepath.Base(dir)
+
+	r.container.MountExternal(dir, path)
+
+	return path + "/bin/node", nil
+}
+
+// mountAction shares the directory of the action with the job container, since actions are stored outside of the
+// workspace, e.g. in the action cache. It's no-op if the steps run on the host.
+func (r *runner) mountAction(as *statepkg.ActionState) {
+	if r.container != nil {
+		r.container.MountReadOnly(as.Path)
+	}
+}
+
+// writeRunScript evaluates the expressions in the script and the working directory of the run step against the job
+// context and writes the script to execute.
+func (r *runner) writeRunScript(ss *statepkg.StepState) error {
+	ac := r.state.GetActionsContext()
+
+	run, err := actions.NewString(ss.Step.Run).Eval(ac)
+	if err != nil {
+		annotateExpressionError(r.state, ss, "run", err)
+
+		return fmt.Errorf("failed to evaluate run: %w", err)
+	}
+
+	workingDirectory, err := actions.NewString(ss.Step.WorkingDirectory).Eval(ac)
+	if err != nil {
+		annotateExpressionError(r.state, ss, "working-directory", err)
+
+		return fmt.Errorf("failed to evaluate working-directory: %w", err)
+	}
+
+	return r.writeScript(ss.Step.ID, run, workingDirectory, true)
+}
+
+// writeScript writes the script of the run step with the given id. The working directory of the step takes precedence
+// over the working directory defaults of the job, defaults are applied only if jobDefaults is true, steps of composite
+// actions don't use them.
+func (r *runner) writeScript(stepID, run, workingDirectory string, jobDefaults bool) error {
+	path := filepath.Join("scripts", stepID, "run.sh")
+
+	if err := config.WriteFile(path, r.runScript(run, workingDirectory, jobDefaults), 0755); err != nil {
+		return err
+	}
+
+	// make it debug level because it's not really important and it's visible in Github Actions logs
+	r.logger.Debug(fmt.Sprintf("Write script to '%s' for step '%s'", path, stepID))
+
+	return nil
+}
+
 func (r *runner) execStepRun(ctx context.Context, ss *statepkg.StepState, stage model.ActionStage) (ExecStepStatus, error) {
 	// path of the run.sh to execute
 	path := config.GetPath("scripts", ss.Step.ID, "run.sh")
 
+	args := []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", path}
+
+	// default shell of the container jobs is sh if the image doesn't have bash, same as the runner
+	if r.container != nil {
+		args = []string{"sh", "-c", `if command -v bash >/dev/null 2>&1; then exec bash --noprofile --norc -e -o pipefail "$0"; else exec sh -e "$0"; fi`, path}
+	}
+
 	// execute the script
-	err := r.execCmd(ctx, ss, stage, []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", path})
+	err := r.execCmd(ctx, ss, stage, args)
 	if err != nil {
 		ss.Result.Conclusion = model.StepStatusFailure
 		ss.Result.Outcome = model.StepStatusFailure
@@ -219,36 +598,28 @@ func (r *runner) execStepRun(ctx context.Context, ss *statepkg.StepState, stage
 }
 
 func (r *runner) execCmd(ctx context.Context, ss *statepkg.StepState, stage model.ActionStage, args []string) error {
+	base := os.Environ()
+	if r.container != nil {
+		base = r.container.Env()
+	}
+
 	// get the step env
-	env, err := getStepEnv(r.state, ss, stage)
+	env, err := getStepEnv(r.state, ss, stage, base)
 	if err != nil {
 		return err
 	}
 
-	//nolint:gosec // (G204) this is a command runner, we need to run arbitrary commands.
-	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
-
 	stdout := bytes.NewBuffer(nil)
 	stderr := bytes.NewBuffer(nil)
 	commandsRaw := bytes.NewBuffer(nil)
 
-	cmd.Stderr = io.MultiWriter(stderr, os.Stderr)
-	cmd.Env = env
-
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-
-	err = cmd.Start()
-	if err != nil {
-		return err
-	}
-
 	var commands []*model.Command
 
-	go func() {
-		scanner := bufio.NewScanner(stdoutPipe)
+	processor := newWorkflowCommandProcessor(r.state, ss, r.logger)
+
+	// processOutput processes stdout of the command line by line
+	processOutput := func(reader io.Reader) {
+		scanner := bufio.NewScanner(reader)
 		for scanner.Scan() {
 			output := scanner.Text()
 
@@ -256,7 +627,8 @@ func (r *runner) execCmd(ctx context.Context, ss *statepkg.StepState, stage mode
 			stdout.WriteString(output)
 			stdout.WriteString("\n") // scanner strips newlines
 
-			isCommand, command := model.ParseCommand(output)
+			// process the command. errors are already reported by the processor and fail the step at the end
+			isCommand, command, _ := processor.process(output)
 
 			// print the output if it is a regular output
 			if !isCommand {
@@ -271,15 +643,50 @@ func (r *runner) execCmd(ctx context.Context, ss *statepkg.StepState, stage mode
 			// write to commands raw so we can keep original formatting
 			commandsRaw.WriteString(output)
 			commandsRaw.WriteString("\n")
+		}
+	}
 
-			// process the command
-			if err := processGithubWorkflowCommands(command, ss, r.logger); err != nil {
-				fmt.Println(err)
-			}
+	var cmdErr error
+
+	if r.container != nil {
+		// output of the container is available once the command is completed
+		var out, errOut string
+
+		out, errOut, cmdErr = r.container.Exec(ctx, args, env)
+
+		io.MultiWriter(stderr, os.Stderr).Write([]byte(errOut)) //nolint:errcheck // same as stderr of the host commands
+
+		processOutput(strings.NewReader(out))
+	} else {
+		//nolint:gosec // (G204) this is a command runner, we need to run arbitrary commands.
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+
+		cmd.Stderr = io.MultiWriter(stderr, os.Stderr)
+		cmd.Env = env
+
+		stdoutPipe, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
 		}
-	}()
 
-	cmdErr := cmd.Wait()
+		err = cmd.Start()
+		if err != nil {
+			return err
+		}
+
+		// done is closed when all the output is processed. Wait closes the pipe, so we need to finish reading first.
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			processOutput(stdoutPipe)
+		}()
+
+		<-done
+
+		cmdErr = cmd.Wait()
+	}
 
 	if data := stdout.Bytes(); len(data) > 0 {
 		config.WriteFile(filepath.Join("steps", ss.Step.ID, "logs", string(stage), "stdout.log"), data, 0600)
@@ -302,5 +709,10 @@ func (r *runner) execCmd(ctx context.Context, ss *statepkg.StepState, stage mode
 	}
 
 	// process commands at the end of the command
-	return processFileCommands(ss, stage)
+	if err := processFileCommands(ss, stage); err != nil {
+		return err
+	}
+
+	// fail the step if any of the workflow commands failed, same as runner
+	return processor.Err()
 }
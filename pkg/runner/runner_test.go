@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/model"
+	statepkg "github.com/aweris/ghx/pkg/state"
+)
+
+func TestRunner_ExecuteResetsAnnotations(t *testing.T) {
+	state := &statepkg.State{
+		JobName:     "build",
+		Job:         &model.Job{If: "false"},
+		JobStatus:   statepkg.JobStatusFailure,
+		Annotations: []*model.Annotation{{Level: model.AnnotationLevelError, Message: "previous run", StepID: "test"}},
+		JobOutputs:  map[string]string{"version": "previous"},
+	}
+
+	r := &runner{state: state, logger: log.NewLogger()}
+
+	if err := r.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if len(state.Annotations) != 0 {
+		t.Errorf("Expected annotations of the previous run to be reset, but got %d", len(state.Annotations))
+	}
+
+	if state.JobOutputs != nil {
+		t.Errorf("Expected job outputs of the previous run to be reset, but got %v", state.JobOutputs)
+	}
+
+	if state.JobStatus != statepkg.JobStatusSuccess {
+		t.Errorf("Expected job status %s, but got %s", statepkg.JobStatusSuccess, state.JobStatus)
+	}
+}
+
+func TestRunner_CompleteJob(t *testing.T) {
+	step := &model.Step{ID: "version"}
+
+	ss := statepkg.NewStepState(step)
+	ss.Result.Outputs["version"] = "1.0.0"
+
+	state := &statepkg.State{
+		JobName: "build",
+		Job: &model.Job{
+			Outputs: map[string]string{
+				"version": "${{ steps.version.outputs.version }}",
+				"static":  "foo",
+				"invalid": "${{ steps. }}",
+			},
+		},
+		Steps:     map[string]*statepkg.StepState{step.ID: ss},
+		StepOrder: []string{step.ID},
+	}
+
+	r := &runner{state: state, logger: log.NewLogger()}
+
+	if err := r.completeJob(nil); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// outputs failing to evaluate are reported as warnings and left out
+	expected := map[string]string{"version": "1.0.0", "static": "foo"}
+
+	if !reflect.DeepEqual(state.JobOutputs, expected) {
+		t.Errorf("Expected job outputs %v, but got %v", expected, state.JobOutputs)
+	}
+}
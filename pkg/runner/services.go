@@ -0,0 +1,533 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/ghx/internal/log"
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+)
+
+const (
+	// serviceHelperImage is the image used to keep services running and to resolve their addresses.
+	serviceHelperImage = "alpine:3.18"
+
+	// serviceNetwork is the network name reported in the job context. All services share the dagger network.
+	serviceNetwork = "dagger"
+)
+
+// healthCheck represents the readiness check of a service parsed from the health options of the service.
+type healthCheck struct {
+	cmd      string        // cmd is the health command of the service. It runs in a sidecar, see serviceManager.
+	interval time.Duration // interval is the time between two checks
+	timeout  time.Duration // timeout is the maximum time for a single check
+	retries  int           // retries is the number of failed checks before the service is considered unhealthy
+}
+
+// defaultHealthCheck is used for the health options missing in the service options. Without health-cmd, the service
+// is ready once dagger sees the exposed ports listening.
+var defaultHealthCheck = healthCheck{interval: time.Second, timeout: 5 * time.Second, retries: 30}
+
+// servicePort represents a port mapping of a service.
+type servicePort struct {
+	host      int    // host is the port on localhost. Zero means a random port.
+	container int    // container is the port of the service container
+	protocol  string // protocol is the network protocol, tcp or udp
+}
+
+// service represents a running service container.
+type service struct {
+	id        string
+	container *dagger.Container
+	base      *dagger.Container // base is the service container before running the service, used for health checks
+	ip        string
+	hostname  string
+	ports     []servicePort
+	health    healthCheck
+	mapped    map[string]string // mapped is the map of container ports to host ports
+}
+
+// serviceManager starts service containers of the job as dagger services and makes them reachable from the steps.
+//
+// Services are bound to the job container with their ids as aliases, so steps of container jobs reach them by id, same
+// as GitHub. Steps running on the host can't resolve service ids, they reach mapped ports on localhost instead, same
+// as jobs running on the runner machine on GitHub. Ports are proxied to the service address on the dagger network, so
+// the host must be able to reach that network, e.g. ghx runs in a dagger container.
+//
+// Dagger stops services when nothing uses them, so a keeper container is bound to each service until the job ends.
+// Dagger waits for the exposed ports of a service before running a container bound to it. If the service has
+// health-cmd, it's ready once the command succeeds. Dagger can't run commands in a running service container, so the
+// command runs in a sidecar container from the service image instead, where the service is reachable by its id
+// rather than localhost.
+type serviceManager struct {
+	client *dagger.Client
+	logger *log.Logger
+
+	services  map[string]*service
+	cancel    context.CancelFunc
+	listeners []net.Listener
+	wg        sync.WaitGroup
+}
+
+// newServiceManager creates a new service manager.
+func newServiceManager(client *dagger.Client, logger *log.Logger) *serviceManager {
+	return &serviceManager{client: client, logger: logger, services: make(map[string]*service)}
+}
+
+// Start starts the services, waits them to be ready and returns the services context of the job.
+func (m *serviceManager) Start(ctx context.Context, services map[string]*model.Container, ac *actions.Context) (map[string]actions.JobServices, error) {
+	ids := make([]string, 0, len(services))
+
+	for id := range services {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	// services live until Stop is called, independent of the caller context
+	svcCtx, cancel := context.WithCancel(context.Background())
+
+	m.cancel = cancel
+
+	result := make(map[string]actions.JobServices)
+
+	for _, id := range ids {
+		svc, err := m.newService(id, services[id], ac)
+		if err != nil {
+			return nil, err
+		}
+
+		m.logger.Info(fmt.Sprintf("Starting service container %s", id))
+
+		m.keepAlive(svcCtx, svc)
+
+		if err := m.resolve(ctx, svc); err != nil {
+			return nil, err
+		}
+
+		if err := m.waitHealthy(ctx, svc); err != nil {
+			return nil, err
+		}
+
+		if err := m.expose(svc); err != nil {
+			return nil, err
+		}
+
+		m.services[id] = svc
+
+		result[id] = actions.JobServices{ID: svc.hostname, Network: serviceNetwork, Ports: svc.mapped}
+	}
+
+	return result, nil
+}
+
+// Containers returns the service containers by service id to bind them to other containers.
+func (m *serviceManager) Containers() map[string]*dagger.Container {
+	containers := make(map[string]*dagger.Container, len(m.services))
+
+	for id, svc := range m.services {
+		containers[id] = svc.container
+	}
+
+	return containers
+}
+
+// Stop stops the services and port proxies.
+func (m *serviceManager) Stop() {
+	if m.cancel == nil {
+		return
+	}
+
+	for _, l := range m.listeners {
+		l.Close()
+	}
+
+	m.cancel()
+	m.wg.Wait()
+
+	m.cancel = nil
+}
+
+// newService creates the service container from the service configuration. Expressions are evaluated against the
+// given context.
+func (m *serviceManager) newService(id string, cfg *model.Container, ac *actions.Context) (*service, error) {
+	health, unsupported, err := parseServiceOptions(cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options for service %s: %v", id, err)
+	}
+
+	for _, option := range unsupported {
+		m.logger.Warn(fmt.Sprintf("Option %s of service %s is not supported, it's ignored", option, id))
+	}
+
+	if len(cfg.Volumes) > 0 {
+		m.logger.Warn(fmt.Sprintf("Volumes of service %s are not supported, they're ignored", id))
+	}
+
+	container, err := newContainer(m.client, "service "+id, cfg, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := &service{id: id, base: container, health: health, mapped: make(map[string]string)}
+
+	for _, spec := range cfg.Ports {
+		port, err := parseServicePort(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %s for service %s: %v", spec, id, err)
+		}
+
+		opts := dagger.ContainerWithExposedPortOpts{Protocol: dagger.Tcp}
+
+		if port.protocol == "udp" {
+			opts.Protocol = dagger.Udp
+		}
+
+		container = container.WithExposedPort(port.container, opts)
+
+		svc.ports = append(svc.ports, port)
+	}
+
+	// run the default command of the image as the service
+	svc.container = container.WithExec(nil)
+
+	return svc, nil
+}
+
+// keepAlive binds a long-running keeper container to the service, so dagger keeps the service running until the
+// context is cancelled.
+func (m *serviceManager) keepAlive(ctx context.Context, svc *service) {
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		_, err := m.client.Container().
+			From(serviceHelperImage).
+			WithServiceBinding(svc.id, svc.container).
+			WithEnvVariable("GHX_SERVICE_STARTED_AT", time.Now().String()). // avoid cached results
+			WithExec([]string{"sleep", "infinity"}).
+			Sync(ctx)
+
+		if err != nil && ctx.Err() == nil {
+			m.logger.Warn(fmt.Sprintf("Service container %s stopped: %v", svc.id, err))
+		}
+	}()
+}
+
+// resolve resolves the address and the hostname of the service.
+func (m *serviceManager) resolve(ctx context.Context, svc *service) error {
+	out, err := m.client.Container().
+		From(serviceHelperImage).
+		WithServiceBinding(svc.id, svc.container).
+		WithEnvVariable("GHX_SERVICE_RESOLVED_AT", time.Now().String()). // avoid cached results
+		WithExec([]string{"getent", "hosts", svc.id}).
+		Stdout(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start service %s: %v", svc.id, err)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return fmt.Errorf("failed to resolve address of service %s", svc.id)
+	}
+
+	svc.ip = fields[0]
+
+	hostname, err := svc.container.Hostname(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get hostname of service %s: %v", svc.id, err)
+	}
+
+	svc.hostname = hostname
+
+	m.logger.Debug(fmt.Sprintf("Service %s is running at %s (%s)", svc.id, svc.ip, svc.hostname))
+
+	return nil
+}
+
+// waitHealthy waits until the health command of the service succeeds. Each attempt runs the command in a sidecar
+// container from the service image with the service bound by its id.
+func (m *serviceManager) waitHealthy(ctx context.Context, svc *service) error {
+	if svc.health.cmd == "" {
+		m.logger.Info(fmt.Sprintf("Service container %s is ready", svc.id))
+		return nil
+	}
+
+	sidecar := svc.base.WithServiceBinding(svc.id, svc.container)
+
+	var err error
+
+	for attempt := 0; attempt <= svc.health.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(svc.health.interval):
+			}
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, svc.health.timeout)
+
+		_, err = sidecar.
+			WithEnvVariable("GHX_SERVICE_CHECKED_AT", time.Now().String()). // avoid cached results
+			WithExec([]string{"sh", "-c", svc.health.cmd}, dagger.ContainerWithExecOpts{SkipEntrypoint: true}).
+			Sync(checkCtx)
+
+		cancel()
+
+		if err == nil {
+			break
+		}
+
+		m.logger.Debug(fmt.Sprintf("Waiting for service %s to be healthy: %v", svc.id, err))
+	}
+
+	if err != nil {
+		return fmt.Errorf("service %s is unhealthy, health-cmd failed %d times, note that it runs in a sidecar where the service is reachable as %s instead of localhost: %v", svc.id, svc.health.retries+1, svc.id, err)
+	}
+
+	m.logger.Info(fmt.Sprintf("Service container %s is healthy", svc.id))
+
+	return nil
+}
+
+// expose proxies the mapped ports from localhost to the service for the steps running on the host.
+func (m *serviceManager) expose(svc *service) error {
+	for _, port := range svc.ports {
+		if port.protocol != "tcp" {
+			m.logger.Warn(fmt.Sprintf("Port %d/%s of service %s is not mapped to localhost, only tcp ports are supported", port.container, port.protocol, svc.id))
+			continue
+		}
+
+		l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port.host)))
+		if err != nil {
+			return fmt.Errorf("failed to map port %d of service %s: %v", port.container, svc.id, err)
+		}
+
+		m.listeners = append(m.listeners, l)
+
+		hostPort := l.Addr().(*net.TCPAddr).Port
+
+		svc.mapped[strconv.Itoa(port.container)] = strconv.Itoa(hostPort)
+
+		m.logger.Info(fmt.Sprintf("Map port %d of service %s to localhost:%d", port.container, svc.id, hostPort))
+
+		m.wg.Add(1)
+
+		go m.proxy(l, net.JoinHostPort(svc.ip, strconv.Itoa(port.container)))
+	}
+
+	return nil
+}
+
+// proxy forwards connections accepted by the listener to the target address until the listener is closed.
+func (m *serviceManager) proxy(l net.Listener, target string) {
+	defer m.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				m.logger.Debug(fmt.Sprintf("Failed to connect %s: %v", target, err))
+				return
+			}
+			defer upstream.Close()
+
+			done := make(chan struct{}, 2)
+
+			go func() { io.Copy(upstream, conn); done <- struct{}{} }() //nolint:errcheck // connection errors end the copy
+			go func() { io.Copy(conn, upstream); done <- struct{}{} }() //nolint:errcheck // connection errors end the copy
+
+			<-done
+		}()
+	}
+}
+
+// parseServicePort parses a port mapping in `[host:]container[/protocol]` format.
+func parseServicePort(spec string) (servicePort, error) {
+	port := servicePort{protocol: "tcp"}
+
+	spec = strings.TrimSpace(spec)
+
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		port.protocol = strings.ToLower(spec[i+1:])
+		spec = spec[:i]
+	}
+
+	if port.protocol != "tcp" && port.protocol != "udp" {
+		return port, fmt.Errorf("unsupported protocol %s", port.protocol)
+	}
+
+	host, container := "", spec
+
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		host, container = spec[:i], spec[i+1:]
+	}
+
+	var err error
+
+	if port.container, err = strconv.Atoi(container); err != nil {
+		return port, fmt.Errorf("invalid container port %s", container)
+	}
+
+	if host != "" {
+		if port.host, err = strconv.Atoi(host); err != nil {
+			return port, fmt.Errorf("invalid host port %s", host)
+		}
+	}
+
+	return port, nil
+}
+
+// parseServiceOptions parses docker create options of the service. Only health options are supported, the rest is
+// returned as unsupported options.
+func parseServiceOptions(options string) (healthCheck, []string, error) {
+	health := defaultHealthCheck
+
+	args, err := splitArgs(options)
+	if err != nil {
+		return health, nil, err
+	}
+
+	var unsupported []string
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+
+		if !strings.HasPrefix(name, "--health-") {
+			unsupported = append(unsupported, name)
+
+			// skip the value of the option, so it's not reported as another option
+			if !hasValue && optionTakesValue(name) && i+1 < len(args) {
+				i++
+			}
+
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return health, nil, fmt.Errorf("missing value for %s", name)
+			}
+
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--health-cmd":
+			health.cmd = value
+		case "--health-interval":
+			if health.interval, err = time.ParseDuration(value); err != nil {
+				return health, nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+		case "--health-timeout":
+			if health.timeout, err = time.ParseDuration(value); err != nil {
+				return health, nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+		case "--health-retries":
+			if health.retries, err = strconv.Atoi(value); err != nil {
+				return health, nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+		default:
+			unsupported = append(unsupported, name)
+		}
+	}
+
+	return health, unsupported, nil
+}
+
+// dockerBoolOptions are the options of docker create without a value. Other options take a value, either after `=`
+// or as the next argument.
+var dockerBoolOptions = map[string]bool{
+	"--detach":                true,
+	"--disable-content-trust": true,
+	"--help":                  true,
+	"--init":                  true,
+	"--interactive":           true,
+	"--no-healthcheck":        true,
+	"--oom-kill-disable":      true,
+	"--privileged":            true,
+	"--publish-all":           true,
+	"--quiet":                 true,
+	"--read-only":             true,
+	"--rm":                    true,
+	"--tty":                   true,
+}
+
+// dockerBoolShorthands are the shorthands of docker create options without a value. They can be combined, e.g. `-it`.
+const dockerBoolShorthands = "diPqt"
+
+// optionTakesValue returns true if the docker create option takes a value.
+func optionTakesValue(name string) bool {
+	if strings.HasPrefix(name, "--") {
+		return !dockerBoolOptions[name]
+	}
+
+	if !strings.HasPrefix(name, "-") || len(name) < 2 {
+		return false
+	}
+
+	// combined shorthands take a value only if the last one does, e.g. `-ie FOO=bar`
+	return !strings.ContainsRune(dockerBoolShorthands, rune(name[len(name)-1]))
+}
+
+// splitArgs splits the string into arguments like a shell does for single and double-quoted strings.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseServicePort(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected servicePort
+		wantErr  bool
+	}{
+		{spec: "5432", expected: servicePort{container: 5432, protocol: "tcp"}},
+		{spec: "8080:80", expected: servicePort{host: 8080, container: 80, protocol: "tcp"}},
+		{spec: " 53/udp ", expected: servicePort{container: 53, protocol: "udp"}},
+		{spec: "5353:53/UDP", expected: servicePort{host: 5353, container: 53, protocol: "udp"}},
+		{spec: "127.0.0.1:8080:80", wantErr: true},
+		{spec: "80/sctp", wantErr: true},
+		{spec: "http", wantErr: true},
+		{spec: "http:80", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			port, err := parseServicePort(tt.spec)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tt.wantErr, err)
+			}
+
+			if !tt.wantErr && port != tt.expected {
+				t.Errorf("Expected %+v, but got %+v", tt.expected, port)
+			}
+		})
+	}
+}
+
+func TestParseServiceOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     string
+		expected    healthCheck
+		unsupported []string
+		wantErr     bool
+	}{
+		{
+			name:     "empty",
+			expected: defaultHealthCheck,
+		},
+		{
+			name:    "health options",
+			options: `--health-cmd "pg_isready -U postgres" --health-interval 10s --health-timeout=5s --health-retries 5`,
+			expected: healthCheck{
+				cmd:      "pg_isready -U postgres",
+				interval: 10 * time.Second,
+				timeout:  5 * time.Second,
+				retries:  5,
+			},
+		},
+		{
+			name:     "single quoted health command",
+			options:  `--health-cmd='redis-cli ping'`,
+			expected: healthCheck{cmd: "redis-cli ping", interval: time.Second, timeout: 5 * time.Second, retries: 30},
+		},
+		{
+			name:        "unsupported options with values",
+			options:     `--cpus 2 --memory=1g -e FOO=bar --health-cmd true`,
+			expected:    healthCheck{cmd: "true", interval: time.Second, timeout: 5 * time.Second, retries: 30},
+			unsupported: []string{"--cpus", "--memory", "-e"},
+		},
+		{
+			name:        "unsupported options without values",
+			options:     `--privileged --init -it --health-retries 3`,
+			expected:    healthCheck{interval: time.Second, timeout: 5 * time.Second, retries: 3},
+			unsupported: []string{"--privileged", "--init", "-it"},
+		},
+		{
+			name:        "unsupported health option",
+			options:     `--health-start-period 30s --health-retries 3`,
+			expected:    healthCheck{interval: time.Second, timeout: 5 * time.Second, retries: 3},
+			unsupported: []string{"--health-start-period"},
+		},
+		{name: "missing value", options: `--health-cmd`, wantErr: true},
+		{name: "invalid interval", options: `--health-interval 10`, wantErr: true},
+		{name: "invalid retries", options: `--health-retries many`, wantErr: true},
+		{name: "unterminated quote", options: `--health-cmd "pg_isready`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health, unsupported, err := parseServiceOptions(tt.options)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tt.wantErr, err)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if health != tt.expected {
+				t.Errorf("Expected health %+v, but got %+v", tt.expected, health)
+			}
+
+			if !reflect.DeepEqual(unsupported, tt.unsupported) {
+				t.Errorf("Expected unsupported options %v, but got %v", tt.unsupported, unsupported)
+			}
+		})
+	}
+}
+
+func TestOptionTakesValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "--cpus", expected: true},
+		{name: "--privileged"},
+		{name: "--rm"},
+		{name: "-e", expected: true},
+		{name: "-h", expected: true},
+		{name: "-t"},
+		{name: "-it"},
+		{name: "-ie", expected: true},
+		{name: "value"},
+	}
+
+	for _, tt := range tests {
+		if result := optionTakesValue(tt.name); result != tt.expected {
+			t.Errorf("Expected %v for %s, but got %v", tt.expected, tt.name, result)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{input: "", expected: nil},
+		{input: "  a \t b\nc  ", expected: []string{"a", "b", "c"}},
+		{input: `--health-cmd "pg_isready -U postgres"`, expected: []string{"--health-cmd", "pg_isready -U postgres"}},
+		{input: `--health-cmd='redis-cli ping'`, expected: []string{"--health-cmd=redis-cli ping"}},
+		{input: `"it's" 'say "hi"'`, expected: []string{"it's", `say "hi"`}},
+		{input: `""`, expected: []string{""}},
+		{input: `'unterminated`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			args, err := splitArgs(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tt.wantErr, err)
+			}
+
+			if !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("Expected %q, but got %q", tt.expected, args)
+			}
+		})
+	}
+}
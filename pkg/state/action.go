@@ -2,32 +2,265 @@ package state
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"dagger.io/dagger"
 
+	"github.com/aweris/ghx/pkg/cache"
 	"github.com/aweris/ghx/pkg/config"
 	"github.com/aweris/ghx/pkg/model"
 )
 
 // ActionState represents a single action metadata and where it is stored
 type ActionState struct {
-	Source   string        // source of the action
-	Path     string        // path of the action stored on disk
-	Metadata *model.Action // metadata of the action
+	Source      string        // source of the action
+	Commit      string        // commit SHA the action ref resolved to. Empty for local actions
+	Replacement string        // source the action is loaded from if the source is replaced
+	Hash        string        // content hash of the action tree stored on disk
+	Path        string        // path of the action stored on disk
+	Metadata    *model.Action // metadata of the action
+}
+
+// ActionOptions represents the options to load actions
+type ActionOptions struct {
+	// Source is the options to resolve remote action sources.
+	Source model.ActionSourceOptions
+
+	// Lockfile is the lockfile to verify remote actions against. Verification is skipped if it's nil.
+	Lockfile *Lockfile
+
+	// Update updates the lockfile with the resolved actions instead of refusing drift.
+	Update bool
+
+	// Cache is the persistent cache to store remote actions. Actions are exported to ghx data home on every load if
+	// it's nil.
+	Cache *cache.Cache
+
+	// Offline resolves remote actions only from the cache without accessing the network.
+	Offline bool
 }
 
 // LoadAction loads the action from the given source and stores it in the state
-func LoadAction(ctx context.Context, client *dagger.Client, source string) (*ActionState, error) {
-	action, err := model.LoadActionFromSource(ctx, client, source)
+func LoadAction(ctx context.Context, client *dagger.Client, source string, opts ActionOptions) (*ActionState, error) {
+	var (
+		as  *ActionState
+		err error
+	)
+
+	target, replaced := opts.Source.Replace(source)
+
+	switch {
+	case model.IsLocalActionSource(target) || opts.Cache == nil && !opts.Offline:
+		as, err = loadActionToDataHome(ctx, client, source, opts)
+	default:
+		as, err = loadActionFromCache(ctx, client, source, target, opts)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// local actions are part of the repository and replacements are meant for local development, so they're not locked
+	if opts.Lockfile != nil && as.Commit != "" && !replaced {
+		if err := opts.Lockfile.Verify(source, as.Commit, as.Hash); err != nil {
+			if !opts.Update {
+				return nil, fmt.Errorf("%v. Run `ghx actions lock` or pass --update to accept the change", err)
+			}
+
+			opts.Lockfile.Lock(source, as.Commit, as.Hash)
+		}
+	}
+
+	return as, nil
+}
+
+// loadActionToDataHome loads the action and exports it under ghx data home.
+func loadActionToDataHome(ctx context.Context, client *dagger.Client, source string, opts ActionOptions) (*ActionState, error) {
+	action, err := model.LoadActionFromSource(ctx, client, source, opts.Source)
 	if err != nil {
 		return nil, err
 	}
 
 	path := config.GetPath("actions", source)
 
-	if _, err = action.Directory.Export(ctx, path); err != nil {
+	export := func(target string) error {
+		_, err := action.Directory.Export(ctx, target)
+		return err
+	}
+
+	if err := exportDir(path, export); err != nil {
+		return nil, fmt.Errorf("failed to export action %s: %v", source, err)
+	}
+
+	hash, err := HashDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash action %s: %v", source, err)
+	}
+
+	return &ActionState{
+		Source:      source,
+		Commit:      action.Commit,
+		Replacement: action.Replacement,
+		Hash:        hash,
+		Path:        path,
+		Metadata:    action,
+	}, nil
+}
+
+// exportDir exports a directory to the path with the export function. Export only writes files, so the directory is
+// exported to a temporary directory next to the path first and then replaces the path. Otherwise, files left from a
+// previous export would stay in the action tree and change its hash.
+func exportDir(path string, export func(target string) error) error {
+	parent := filepath.Dir(path)
+
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(parent, "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := export(tmp); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// loadActionFromCache loads the remote action from the cache. If the action is missing, it's downloaded into the cache
+// unless offline mode is enabled. Target is the source to load after applying replacements.
+func loadActionFromCache(ctx context.Context, client *dagger.Client, source, target string, opts ActionOptions) (*ActionState, error) {
+	if opts.Cache == nil {
+		return nil, fmt.Errorf("action cache is required in offline mode")
+	}
+
+	repo, path, ref, err := model.ParseActionSource(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *cache.Entry
+
+	if opts.Offline {
+		hosts, err := opts.Source.Hosts()
+		if err != nil {
+			return nil, err
+		}
+
+		// look up the servers in the same order as resolving the action online
+		for _, host := range hosts {
+			if found, ok := opts.Cache.Resolve(host, repo, ref, path); ok {
+				entry = found
+				break
+			}
+		}
+
+		if entry == nil {
+			return nil, fmt.Errorf("action %s is not in the cache %s and offline mode is enabled", target, opts.Cache.Dir())
+		}
+	} else {
+		dir, host, commit, err := model.ResolveActionDirectory(ctx, client, target, opts.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+
+		entry, ok = opts.Cache.Get(host, repo, commit, path)
+		if !ok {
+			fill := func(target string) error {
+				_, err := dir.Export(ctx, target)
+				return err
+			}
+
+			entry, err = opts.Cache.Put(host, repo, commit, path, fill, HashDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to cache action %s: %v", target, err)
+			}
+		}
+	}
+
+	opts.Cache.Touch(entry, ref)
+
+	actionPath := opts.Cache.EntryDir(entry)
+
+	action, err := model.LoadActionFromDirectory(ctx, client.Host().Directory(actionPath), target)
+	if err != nil {
 		return nil, err
 	}
 
-	return &ActionState{Source: source, Path: path, Metadata: action}, nil
+	action.Commit = entry.Commit
+
+	if target != source {
+		action.Replacement = target
+	}
+
+	return &ActionState{
+		Source:      source,
+		Commit:      entry.Commit,
+		Replacement: action.Replacement,
+		Hash:        entry.Hash,
+		Path:        actionPath,
+		Metadata:    action,
+	}, nil
+}
+
+// LoadActionTree loads the actions of the given sources and the actions used by the steps of composite actions,
+// recursively. Actions are returned in the order they are loaded, each source once, so the result covers every action
+// the runner loads for the sources.
+func LoadActionTree(sources []string, load func(source string) (*ActionState, error)) ([]*ActionState, error) {
+	var (
+		actions []*ActionState
+		visit   func(source string) error
+	)
+
+	visited := make(map[string]bool)
+
+	visit = func(source string) error {
+		if visited[source] {
+			return nil
+		}
+
+		visited[source] = true
+
+		as, err := load(source)
+		if err != nil {
+			return err
+		}
+
+		actions = append(actions, as)
+
+		if as.Metadata == nil || as.Metadata.Runs.Using != model.ActionRunsUsingComposite {
+			return nil
+		}
+
+		for _, step := range as.Metadata.Runs.Steps {
+			if step.Type() != model.StepTypeAction {
+				continue
+			}
+
+			if err := visit(step.Uses); err != nil {
+				return fmt.Errorf("composite action %s: %w", source, err)
+			}
+		}
+
+		return nil
+	}
+
+	for _, source := range sources {
+		if err := visit(source); err != nil {
+			return nil, err
+		}
+	}
+
+	return actions, nil
 }
@@ -0,0 +1,131 @@
+package state
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/ghx/pkg/model"
+)
+
+func TestLoadActionTree(t *testing.T) {
+	metadata := map[string]*model.Action{
+		"aweris/composite@v1": {
+			Runs: model.ActionRuns{
+				Using: model.ActionRunsUsingComposite,
+				Steps: []model.Step{
+					{Run: "echo hello"},
+					{Uses: "actions/checkout@v3"},
+					{Uses: "aweris/nested@v1"},
+				},
+			},
+		},
+		"aweris/nested@v1": {
+			Runs: model.ActionRuns{
+				Using: model.ActionRunsUsingComposite,
+				Steps: []model.Step{
+					{Uses: "actions/setup-go@v4"},
+					{Uses: "aweris/composite@v1"},
+				},
+			},
+		},
+		"actions/checkout@v3": {Runs: model.ActionRuns{Using: model.ActionRunsUsingNode16}},
+		"actions/setup-go@v4": {Runs: model.ActionRuns{Using: model.ActionRunsUsingNode16}},
+	}
+
+	lockfile := NewLockfile(filepath.Join(t.TempDir(), DefaultLockfile))
+
+	var loaded []string
+
+	actions, err := LoadActionTree([]string{"actions/checkout@v3", "aweris/composite@v1"}, func(source string) (*ActionState, error) {
+		loaded = append(loaded, source)
+
+		lockfile.Lock(source, "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", "sha256:abc")
+
+		return &ActionState{Source: source, Metadata: metadata[source]}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	expected := []string{"actions/checkout@v3", "aweris/composite@v1", "aweris/nested@v1", "actions/setup-go@v4"}
+
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Errorf("Expected actions %v to be loaded once, but got %v", expected, loaded)
+	}
+
+	if len(actions) != len(expected) {
+		t.Errorf("Expected %d actions, but got %d", len(expected), len(actions))
+	}
+
+	for _, source := range expected {
+		if _, ok := lockfile.Actions[source]; !ok {
+			t.Errorf("Expected action %s to be locked", source)
+		}
+	}
+}
+
+func TestExportDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "actions", "actions", "checkout@v3")
+
+	write := func(files map[string]string) func(target string) error {
+		return func(target string) error {
+			for name, content := range files {
+				if err := os.MkdirAll(filepath.Dir(filepath.Join(target, name)), 0755); err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(filepath.Join(target, name), []byte(content), 0600); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	files := map[string]string{"action.yml": "name: checkout", "dist/index.js": "console.log('v3')"}
+
+	if err := exportDir(path, write(files)); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	expected, err := HashDir(path)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	// a file from an earlier resolution of the same source must not survive the next export
+	if err := os.WriteFile(filepath.Join(path, "dist", "stale.js"), []byte("stale"), 0600); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if err := exportDir(path, write(files)); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "dist", "stale.js")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected stale file to be removed, but got %v", err)
+	}
+
+	if hash, err := HashDir(path); err != nil || hash != expected {
+		t.Errorf("Expected hash %s, but got %s (%v)", expected, hash, err)
+	}
+
+	// temporary directories are cleaned up even if the export fails
+	if err := exportDir(path, func(string) error { return errors.New("export failed") }); err == nil {
+		t.Errorf("Expected export error, but got nil")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the action directory, but got %v (%v)", entries, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "action.yml")); err != nil {
+		t.Errorf("Expected failed export to keep the previous action, but got %v", err)
+	}
+}
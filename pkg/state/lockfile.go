@@ -0,0 +1,193 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultLockfile is the default path of the action lockfile relative to the working directory.
+const DefaultLockfile = "ghx.lock.json"
+
+// lockfileVersion is the version of the lockfile format.
+const lockfileVersion = 1
+
+// Lockfile pins the actions used by the job to resolved commits and content hashes to make runs reproducible.
+type Lockfile struct {
+	Version int                      `json:"version"` // version of the lockfile format
+	Actions map[string]*LockedAction `json:"actions"` // map of action source to locked action
+
+	path    string // path of the lockfile on disk
+	changed bool   // true if the lockfile is modified since it's loaded
+}
+
+// LockedAction represents the resolved state of an action source.
+type LockedAction struct {
+	Commit string `json:"commit"` // commit SHA the action ref resolved to
+	Hash   string `json:"hash"`   // content hash of the exported action tree
+}
+
+// NewLockfile creates a new empty lockfile for the given path.
+func NewLockfile(path string) *Lockfile {
+	return &Lockfile{Version: lockfileVersion, Actions: make(map[string]*LockedAction), path: path}
+}
+
+// LoadLockfile loads the lockfile from the given path.
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile := NewLockfile(path)
+
+	if err := json.Unmarshal(data, lockfile); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %v", path, err)
+	}
+
+	if lockfile.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d in %s", lockfile.Version, path)
+	}
+
+	if lockfile.Actions == nil {
+		lockfile.Actions = make(map[string]*LockedAction)
+	}
+
+	return lockfile, nil
+}
+
+// Path returns the path of the lockfile.
+func (l *Lockfile) Path() string {
+	return l.path
+}
+
+// Changed returns true if the lockfile is modified since it's loaded.
+func (l *Lockfile) Changed() bool {
+	return l.changed
+}
+
+// Lock pins the action source to the given commit and content hash.
+func (l *Lockfile) Lock(source, commit, hash string) {
+	if locked, ok := l.Actions[source]; ok && locked.Commit == commit && locked.Hash == hash {
+		return
+	}
+
+	l.Actions[source] = &LockedAction{Commit: commit, Hash: hash}
+	l.changed = true
+}
+
+// Verify checks the resolved commit and content hash of the action source against the lockfile.
+func (l *Lockfile) Verify(source, commit, hash string) error {
+	locked, ok := l.Actions[source]
+	if !ok {
+		return fmt.Errorf("action %s is not locked in %s", source, l.path)
+	}
+
+	if locked.Commit != commit {
+		return fmt.Errorf("action %s resolved to commit %s but %s is locked in %s", source, commit, locked.Commit, l.path)
+	}
+
+	if locked.Hash != hash {
+		return fmt.Errorf("content of action %s is %s but %s is locked in %s", source, hash, locked.Hash, l.path)
+	}
+
+	return nil
+}
+
+// Save writes the lockfile to its path.
+func (l *Lockfile) Save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(l.path, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+
+	l.changed = false
+
+	return nil
+}
+
+// HashDir calculates a content hash of the given directory. The hash covers relative paths, file modes and contents of
+// all files in the directory, so it's stable across exports of the same tree.
+func HashDir(dir string) (string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		paths = append(paths, path)
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(paths)
+
+	hash := sha256.New()
+
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%o\x00", filepath.ToSlash(rel), info.Mode().Perm()&0111)
+
+		if err := hashFileContent(hash, path, info); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// hashFileContent writes the content of the file to the hash. For symlinks, the link target is used as content.
+func hashFileContent(w io.Writer, path string, info fs.FileInfo) error {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "%s\x00", target)
+
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	content := sha256.New()
+
+	if _, err := io.Copy(content, file); err != nil {
+		return err
+	}
+
+	_, err = w.Write(content.Sum(nil))
+
+	return err
+}
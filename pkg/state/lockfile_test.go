@@ -0,0 +1,127 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashDir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "action.yml"), "name: test", 0644)
+	writeFile(t, filepath.Join(dir, "dist", "index.js"), "console.log('hello')", 0644)
+
+	hash, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	again, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if hash != again {
+		t.Errorf("Expected same hash for same content, but got %s and %s", hash, again)
+	}
+
+	writeFile(t, filepath.Join(dir, "dist", "index.js"), "console.log('changed')", 0644)
+
+	changed, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if hash == changed {
+		t.Errorf("Expected different hash after content change, but got %s", changed)
+	}
+
+	if err := os.Chmod(filepath.Join(dir, "dist", "index.js"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	executable, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if executable == changed {
+		t.Errorf("Expected different hash after mode change, but got %s", executable)
+	}
+}
+
+func TestLockfile_Verify(t *testing.T) {
+	lockfile := NewLockfile(filepath.Join(t.TempDir(), DefaultLockfile))
+
+	lockfile.Lock("actions/checkout@v3", "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", "sha256:abc")
+
+	tests := []struct {
+		name    string
+		source  string
+		commit  string
+		hash    string
+		wantErr bool
+	}{
+		{name: "match", source: "actions/checkout@v3", commit: "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", hash: "sha256:abc"},
+		{name: "commit drift", source: "actions/checkout@v3", commit: "f43a0e5ff2bd294095638e18286ca9a3d1956744", hash: "sha256:abc", wantErr: true},
+		{name: "content drift", source: "actions/checkout@v3", commit: "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", hash: "sha256:def", wantErr: true},
+		{name: "not locked", source: "actions/setup-go@v4", commit: "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", hash: "sha256:abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := lockfile.Verify(tt.source, tt.commit, tt.hash)
+
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error, but got nil")
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, but got %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestLockfile_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultLockfile)
+
+	lockfile := NewLockfile(path)
+	lockfile.Lock("actions/checkout@v3", "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", "sha256:abc")
+
+	if !lockfile.Changed() {
+		t.Errorf("Expected lockfile to be changed after lock")
+	}
+
+	if err := lockfile.Save(); err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	loaded, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	if err := loaded.Verify("actions/checkout@v3", "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", "sha256:abc"); err != nil {
+		t.Errorf("Expected no error, but got %s", err.Error())
+	}
+
+	loaded.Lock("actions/checkout@v3", "8e5e7e5ab8b370d6c329ec480221332ada57f0ab", "sha256:abc")
+
+	if loaded.Changed() {
+		t.Errorf("Expected lockfile not to be changed after locking same values")
+	}
+}
+
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+}
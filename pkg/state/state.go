@@ -15,21 +15,43 @@ import (
 
 var _ io.Closer = new(State)
 
+// Job statuses used by status check functions like success() and failure().
+const (
+	JobStatusSuccess   = "success"
+	JobStatusFailure   = "failure"
+	JobStatusCancelled = "cancelled"
+)
+
 type State struct {
-	JobName   string                  `json:"job-name"`   // name of the job
-	Actions   map[string]*ActionState `json:"actions"`    // map of action source to state of the action
-	Env       map[string]string       `json:"env"`        // environment variables of the workflow and job
-	StepOrder []string                `json:"step-order"` // order of the steps to make sure custom id is respected
-	Steps     map[string]*StepState   `json:"steps"`      // map of step id to state of the step
+	JobName      string                  `json:"job-name"`     // name of the job
+	Job          *model.Job              `json:"job"`          // job configuration. Nil if steps are added without a job
+	JobStatus    string                  `json:"job-status"`   // current status of the job
+	Actions      map[string]*ActionState `json:"actions"`      // map of action source to state of the action
+	Replacements map[string]string       `json:"replacements"` // map of action source to the source to load instead
+	Env          map[string]string       `json:"env"`          // environment variables of the workflow and job
+	StepOrder    []string                `json:"step-order"`   // order of the steps to make sure custom id is respected
+	Steps        map[string]*StepState   `json:"steps"`        // map of step id to state of the step
+	Annotations  []*model.Annotation     `json:"annotations"`  // annotations created by the steps of the job
+	JobOutputs   map[string]string       `json:"job-outputs"`  // outputs of the job evaluated after the steps
+
+	// WorkflowPath is the path of the workflow file of the job. Empty if steps are added without a workflow
+	WorkflowPath string `json:"workflow-path"`
+
+	// Container is the container the steps of the job run in. Empty if the steps run on the host
+	Container actions.JobContainer `json:"container"`
+
+	// Services is the map of service id to the running service containers of the job
+	Services map[string]actions.JobServices `json:"services"`
 }
 
 // GetState returns the state of the runner from the state file
 func GetState() (*State, error) {
 	// Ensure initialize proper empty state
 	s := &State{
-		Actions: make(map[string]*ActionState),
-		Env:     make(map[string]string),
-		Steps:   make(map[string]*StepState),
+		Actions:      make(map[string]*ActionState),
+		Replacements: make(map[string]string),
+		Env:          make(map[string]string),
+		Steps:        make(map[string]*StepState),
 	}
 
 	if err := config.EnsureFile("state.json"); err != nil {
@@ -48,9 +70,14 @@ func (s *State) Close() error {
 	return config.WriteJSONFile("state.json", s)
 }
 
-// AddAction adds a new action to the state
-func (s *State) AddAction(ctx context.Context, client *dagger.Client, source string) error {
-	action, err := LoadAction(ctx, client, source)
+// AddAction adds a new action to the state. Replacements configured in the state are applied unless the options
+// already have replacements.
+func (s *State) AddAction(ctx context.Context, client *dagger.Client, source string, opts ActionOptions) error {
+	if opts.Source.Replacements == nil {
+		opts.Source.Replacements = s.Replacements
+	}
+
+	action, err := LoadAction(ctx, client, source, opts)
 	if err != nil {
 		return err
 	}
@@ -60,6 +87,22 @@ func (s *State) AddAction(ctx context.Context, client *dagger.Client, source str
 	return nil
 }
 
+// AddReplacement adds a replace directive to load the replacement instead of the action source
+func (s *State) AddReplacement(source, replacement string) error {
+	if err := model.ValidateActionReplacement(source, replacement); err != nil {
+		return err
+	}
+
+	s.Replacements[source] = replacement
+
+	return nil
+}
+
+// RemoveReplacement removes the replace directive of the action source
+func (s *State) RemoveReplacement(source string) {
+	delete(s.Replacements, source)
+}
+
 // GetActionState returns the state of the action with the given source
 func (s *State) GetActionState(source string) (*ActionState, bool) {
 	as, ok := s.Actions[source]
@@ -69,7 +112,9 @@ func (s *State) GetActionState(source string) (*ActionState, bool) {
 
 // AddWorkflowAndJob adds a new job to the state
 func (s *State) AddWorkflowAndJob(workflow *model.Workflow, job *model.Job) error {
+	s.WorkflowPath = workflow.Path
 	s.JobName = job.Name
+	s.Job = job
 
 	// Add the workflow and job environment variables to the state while ensuring that the job environment variables
 	// override the workflow environment variables
@@ -138,11 +183,19 @@ func (s *State) GetStepOrder() []string {
 	return s.StepOrder
 }
 
+// AddAnnotation adds a new annotation to the state
+func (s *State) AddAnnotation(annotation *model.Annotation) {
+	s.Annotations = append(s.Annotations, annotation)
+}
+
 func (s *State) GetActionsContext() *actions.Context {
 	// load the actions context from the environment variables
 	ac := actions.NewContextFromEnv()
 
 	ac.Env = s.Env
+	ac.Job.Status = s.JobStatus
+	ac.Job.Container = s.Container
+	ac.Job.Services = s.Services
 
 	for _, ss := range s.Steps {
 		ac.Steps[ss.Step.ID] = ss.Result
@@ -150,3 +203,12 @@ func (s *State) GetActionsContext() *actions.Context {
 
 	return ac
 }
+
+// GetStepActionsContext returns the expression context to evaluate the values of the given step with.
+func (s *State) GetStepActionsContext(ss *StepState) *actions.Context {
+	if ss.Context != nil {
+		return ss.Context
+	}
+
+	return s.GetActionsContext()
+}
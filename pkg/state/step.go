@@ -1,11 +1,18 @@
 package state
 
-import "github.com/aweris/ghx/pkg/model"
+import (
+	"github.com/aweris/ghx/pkg/actions"
+	"github.com/aweris/ghx/pkg/model"
+)
 
 type StepState struct {
 	Step   *model.Step       // step metadata
 	Result *model.StepResult // result of the step
 	State  map[string]string // state of the step
+
+	// Context is the expression context of the step. Nil for the steps of the job, they're evaluated against the job
+	// context. Steps of composite actions are evaluated against the context of the composite action.
+	Context *actions.Context `json:"-"`
 }
 
 // NewStepState creates a new step state with the given step